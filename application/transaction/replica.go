@@ -0,0 +1,181 @@
+package transaction
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gopricy/mao-bft/pb"
+	rbccommon "github.com/gopricy/mao-bft/rbc/common"
+	"github.com/pkg/errors"
+)
+
+// ReplicaRBC is the RBC node of a replica, it broadcasts blocks only when it leads the current view.
+type ReplicaRBC interface {
+	RBCLeader
+	// IsLeader returns whether this node leads the current view.
+	IsLeader() bool
+	// BroadcastTransaction forwards a transaction received from client to all other peers.
+	BroadcastTransaction(*pb.Transaction)
+	// SuspectLeader votes to replace the leader of current view.
+	SuspectLeader()
+	// CatchUp synchronizes blocks that other peers committed before the current view.
+	CatchUp()
+}
+
+// watchedTxn is a transaction that should be committed before due, otherwise leader is suspected.
+type watchedTxn struct {
+	txn *pb.Transaction
+	due time.Time
+}
+
+// Replica is the application of a node in view change mode. It acts as leader when its node leads the view, and as
+// follower otherwise. Transactions proposed to any replica are forwarded to all peers, every replica watches them and
+// suspects the leader if one of them is not committed in time.
+type Replica struct {
+	*Leader
+	RBC ReplicaRBC
+	// Timeout is how long a transaction can stay uncommitted before leader is suspected.
+	Timeout time.Duration
+	// watched maps transaction uuid to transactions that are not committed yet.
+	watched map[string]*watchedTxn
+	mu      sync.Mutex
+}
+
+var _ rbccommon.ViewChangeApplication = &Replica{}
+
+func NewReplica(blocksize int, dir string, timeout time.Duration) *Replica {
+	res := new(Replica)
+	res.Leader = NewLeader(blocksize, dir)
+	res.Timeout = timeout
+	res.watched = make(map[string]*watchedTxn)
+	return res
+}
+
+func (r *Replica) SetRBCReplica(rbc ReplicaRBC) {
+	r.RBC = rbc
+	r.SetRBCLeader(rbc)
+}
+
+// ProposeTransaction assigns a uuid to a transaction sent by client, and forwards it to all peers.
+func (r *Replica) ProposeTransaction(txn *pb.Transaction) (string, error) {
+	if txn.TransactionUuid != "" {
+		return "", errors.New("uuid can not be set by client")
+	}
+	id, err := uuid.NewUUID()
+	if err != nil {
+		return "", err
+	}
+	txn.TransactionUuid = id.String()
+	if err := r.AcceptForwarded(txn); err != nil {
+		return "", err
+	}
+	r.RBC.BroadcastTransaction(txn)
+	return txn.TransactionUuid, nil
+}
+
+// AcceptForwarded watches a transaction, and queues it if this replica leads the current view.
+func (r *Replica) AcceptForwarded(txn *pb.Transaction) error {
+	if txn.TransactionUuid == "" {
+		return errors.New("forwarded transaction must have uuid")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.watched[txn.TransactionUuid]; ok {
+		return nil
+	}
+	if r.RBC.IsLeader() {
+		if err := r.queue(txn); err != nil {
+			return err
+		}
+	}
+	r.watched[txn.TransactionUuid] = &watchedTxn{txn: txn, due: time.Now().Add(r.Timeout)}
+	return nil
+}
+
+// queue adds a transaction to event queue, and sends a block once it's full. r.mu must be held.
+func (r *Replica) queue(txn *pb.Transaction) error {
+	_, t, err := r.Queue.AddForwardedTxToEventQueue(txn, r.PendingLedger)
+	if err != nil {
+		return err
+	}
+	if t == r.MaxBlockSize {
+		return r.createBlockAndSend()
+	}
+	return nil
+}
+
+// NewView resets all watched transactions. The new leader catches up with the highest committed block and proposes
+// every watched transaction that is not known by its blockchain on top of it.
+func (r *Replica) NewView(view int64, leader string, isLeader bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Blocks proposed in previous views will never be committed through this replica.
+	r.Queue.Clear()
+	r.Blockchain.DropPending()
+	r.RBC.CatchUp()
+	r.PendingLedger = r.Ledger.Copy()
+
+	due := time.Now().Add(r.Timeout)
+	var ids []string
+	for id, w := range r.watched {
+		w.due = due
+		ids = append(ids, id)
+	}
+	if !isLeader {
+		return
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if r.GetTransactionStatus(id) != pb.TransactionStatus_UNKNOWN {
+			continue
+		}
+		// Transaction can become invalid after catching up, it's dropped once overdue.
+		_ = r.queue(r.watched[id].txn)
+	}
+}
+
+// Run watches transactions until stop is closed, leader is suspected if any of them is overdue.
+func (r *Replica) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(r.Timeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if r.overdue() {
+				r.RBC.SuspectLeader()
+			}
+		}
+	}
+}
+
+// overdue stops watching committed transactions and transactions that are invalid against the committed ledger,
+// it returns whether any other transaction is overdue.
+func (r *Replica) overdue() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	res := false
+	for id, w := range r.watched {
+		if r.GetTransactionStatus(id) == pb.TransactionStatus_COMMITTED {
+			delete(r.watched, id)
+			continue
+		}
+		if now.Before(w.due) {
+			continue
+		}
+		// An honest leader rejects transactions that overdraw, they will never be committed.
+		if !r.Ledger.ValidateTransaction(w.txn) {
+			delete(r.watched, id)
+			continue
+		}
+		res = true
+	}
+	return res
+}
@@ -2,11 +2,13 @@ package transaction
 
 import (
 	"log"
+	"path/filepath"
 	"sync"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/gopricy/mao-bft/blockchain"
 	"github.com/gopricy/mao-bft/pb"
+	rbccommon "github.com/gopricy/mao-bft/rbc/common"
 	"github.com/gopricy/mao-bft/rbc/follower"
 	mao_utils "github.com/gopricy/mao-bft/utils"
 	"github.com/pkg/errors"
@@ -30,7 +32,7 @@ type Application interface {
 
 type RBCLeader interface {
 	RBCSend(bytes []byte)
-	SetMode(int)
+	SetAdversary(rbccommon.Adversary)
 }
 
 type common struct {
@@ -87,7 +89,24 @@ func (c *common) RBCReceive(bytes []byte) (bool, error) {
 	if err != nil {
 		return false, errors.Wrap(err, "Can't decode Block")
 	}
+	return c.commit(block)
+}
+
+// RBCReceiveCertified commits a delivered block along with its quorum certificate, which is kept in the blockchain
+// and served to peers that sync.
+func (c *common) RBCReceiveCertified(bytes []byte, certificate *pb.QuorumCertificate) (bool, error) {
+	block, err := mao_utils.DecodeBlock(bytes)
+	if err != nil {
+		return false, errors.Wrap(err, "Can't decode Block")
+	}
+	block.Certificate = certificate
+	return c.commit(block)
+}
 
+var _ rbccommon.CertifiedApplication = &common{}
+
+// commit commits block and transactions of all blocks it makes committed.
+func (c *common) commit(block *pb.Block) (bool, error) {
 	// Below is critical section that only one thread can enter at the same time.
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -105,10 +124,38 @@ func (c *common) RBCReceive(bytes []byte) (bool, error) {
 	return shouldSync, nil
 }
 
+// StateDir returns a directory next to blockchain data, so RBC state is kept along with the blocks it voted on.
+func (c *common) StateDir() string {
+	if c.Blockchain.Path() == "" {
+		return ""
+	}
+	return filepath.Clean(c.Blockchain.Path()) + ".rbc"
+}
+
+var _ rbccommon.PersistentApplication = &common{}
+
+// IsCommitted returns whether a block delivered by RBC has been committed.
+func (c *common) IsCommitted(bytes []byte) bool {
+	block, err := mao_utils.DecodeBlock(bytes)
+	if err != nil {
+		return false
+	}
+	return c.Blockchain.IsCommitted(block.CurHash)
+}
+
+// LastCommitted returns the last committed block and the number of committed blocks.
+func (c *common) LastCommitted() (*pb.Block, int64) {
+	c.Blockchain.Mu.RLock()
+	defer c.Blockchain.Mu.RUnlock()
+	return mao_utils.GetLastBlockFromArray(c.Blockchain.Chain), int64(len(c.Blockchain.Chain) - 1)
+}
+
 func (c *common) GetTransactionStatus(txUuid string) pb.TransactionStatus {
 	if c.Queue.Exist(txUuid) {
 		return pb.TransactionStatus_UNKNOWN
 	}
+	c.Blockchain.Mu.RLock()
+	defer c.Blockchain.Mu.RUnlock()
 	return c.Blockchain.TxStatus[txUuid]
 }
 
@@ -130,7 +177,25 @@ func NewLeader(blocksize int, dir string) *Leader {
 	return res
 }
 
-// TODO: expose this API in a binary.
+var _ rbccommon.TransactionApplication = &Leader{}
+
+// ProposeTransaction proposes a transaction sent by client through TransactionService.
+func (l *Leader) ProposeTransaction(txn *pb.Transaction) (string, error) {
+	if txn.TransactionUuid != "" {
+		return "", errors.New("uuid can not be set by client")
+	}
+	switch v := txn.Message.(type) {
+	case *pb.Transaction_WireMsg:
+		amount := int(v.WireMsg.Amount)
+		return l.ProposeTransfer(v.WireMsg.FromId, v.WireMsg.ToId, amount/100, amount%100)
+	case *pb.Transaction_DepositMsg:
+		amount := int(v.DepositMsg.Amount)
+		return l.ProposeDeposit(v.DepositMsg.AccountId, amount/100, amount%100)
+	default:
+		return "", errors.New("unsupported txn type")
+	}
+}
+
 func (l *Leader) ProposeTransfer(from, to string, dollar, cents int) (string, error) {
 	if dollar > MaximumTxn || cents >= 100 || cents < 0 {
 		return "", errors.New("invalid amount, transaction limit is 1M")
@@ -214,3 +279,10 @@ func NewFollower(dir string) *Follower {
 	res.common = newcommon(dir)
 	return res
 }
+
+var _ rbccommon.TransactionApplication = &Follower{}
+
+// ProposeTransaction rejects the transaction, only leader can propose transactions.
+func (f *Follower) ProposeTransaction(txn *pb.Transaction) (string, error) {
+	return "", errors.New("transaction can only be proposed to leader")
+}
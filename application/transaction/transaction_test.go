@@ -3,6 +3,7 @@ package transaction
 import (
 	"github.com/gopricy/mao-bft/blockchain"
 	"github.com/gopricy/mao-bft/pb"
+	rbccommon "github.com/gopricy/mao-bft/rbc/common"
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
 	"os"
@@ -88,4 +89,47 @@ func TestCommon_InitWithNonEmptyStorage(t *testing.T) {
 	assert.Equal(t, common.PendingLedger.Accounts["user2"], int32(10))
 
 	os.Remove(tmpDir)
-}
\ No newline at end of file
+}
+
+// Defines a RBC leader that records every block it is asked to send.
+type testRBCLeader struct {
+	sent [][]byte
+}
+
+func (tl *testRBCLeader) RBCSend(bytes []byte) {
+	tl.sent = append(tl.sent, bytes)
+}
+
+func (tl *testRBCLeader) SetAdversary(rbccommon.Adversary) {}
+
+func TestLeader_ProposeTransaction(t *testing.T) {
+	rbcLeader := &testRBCLeader{}
+	leader := NewLeader(1, "")
+	leader.SetRBCLeader(rbcLeader)
+
+	id, err := leader.ProposeTransaction(constructDepositTransaction("", 1050, "user1"))
+	assert.Nil(t, err)
+	assert.NotEmpty(t, id)
+	assert.Equal(t, len(rbcLeader.sent), 1)
+	assert.Equal(t, leader.GetTransactionStatus(id), pb.TransactionStatus_PENDING)
+	balance, ok := leader.PendingLedger.GetBalance("user1")
+	assert.True(t, ok)
+	assert.Equal(t, balance, 1050)
+
+	// Client is not allowed to assign uuid.
+	_, err = leader.ProposeTransaction(constructDepositTransaction("1", 10, "user1"))
+	assert.NotNil(t, err)
+	// Wire from an account without enough balance is rejected.
+	_, err = leader.ProposeTransaction(constructWireTransaction("", 2000, "user1", "user1"))
+	assert.NotNil(t, err)
+	// Negative amount is rejected.
+	_, err = leader.ProposeTransaction(constructDepositTransaction("", -10, "user1"))
+	assert.NotNil(t, err)
+	assert.Equal(t, len(rbcLeader.sent), 1)
+}
+
+func TestFollower_ProposeTransactionIsRejected(t *testing.T) {
+	follower := NewFollower("")
+	_, err := follower.ProposeTransaction(constructDepositTransaction("", 10, "user1"))
+	assert.NotNil(t, err)
+}
@@ -82,6 +82,18 @@ func (l *Ledger) Reconcile(blocks []*pb.Block, isCommit []bool, isCommitLedger b
 	}
 }
 
+// Copy returns a ledger with the same balances.
+func (l *Ledger) Copy() *Ledger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	res := NewLedger()
+	for k, v := range l.Accounts {
+		res.Accounts[k] = v
+	}
+	return res
+}
+
 func (l *Ledger) GetBalance(act string) (int, bool) {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
@@ -164,14 +176,34 @@ func (q *EventQueue) AddTxToEventQueue(tx *pb.Transaction, pendingLedger *Ledger
 	if err != nil {
 		return "", 0, err
 	}
-	uuidStr := id.String()
-	tx.TransactionUuid = uuidStr
+	tx.TransactionUuid = id.String()
+	return q.pushBack(tx, pendingLedger)
+}
+
+// AddForwardedTxToEventQueue adds a transaction forwarded by a peer if it's valid, the UUID is assigned by that peer.
+func (q *EventQueue) AddForwardedTxToEventQueue(tx *pb.Transaction, pendingLedger *Ledger) (string, int, error) {
+	q.Mu.Lock()
+	defer q.Mu.Unlock()
+
+	if tx.TransactionUuid == "" {
+		return "", -1, errors.New("forwarded transaction must have uuid")
+	}
+	if q.exist(tx.TransactionUuid) {
+		return "", -1, errors.New("Duplicate transaction: " + tx.TransactionUuid)
+	}
+	if !pendingLedger.ValidateTransaction(tx) {
+		return "", -1, errors.New("Invalid Transaction: " + proto.MarshalTextString(tx))
+	}
+	return q.pushBack(tx, pendingLedger)
+}
 
+// pushBack appends a validated transaction to queue, q.Mu must be held.
+func (q *EventQueue) pushBack(tx *pb.Transaction, pendingLedger *Ledger) (string, int, error) {
 	q.Q.PushBack(tx)
 	if err := pendingLedger.CommitTxn(tx); err != nil {
 		return "", -1, errors.New("Cannot commit transaction in pending ledger.")
 	}
-	return uuidStr, q.Q.Len(), nil
+	return tx.TransactionUuid, q.Q.Len(), nil
 }
 
 // Get a list of transactions to form a block. It returns a list of TXs
@@ -196,12 +228,24 @@ func (q *EventQueue) GetTransactions(maxTx int) ([]*pb.Transaction, error) {
 func (q *EventQueue) Exist(uuid string) bool {
 	q.Mu.RLock()
 	defer q.Mu.RUnlock()
+	return q.exist(uuid)
+}
+
+// Clear drops all transactions in queue.
+func (q *EventQueue) Clear() {
+	q.Mu.Lock()
+	defer q.Mu.Unlock()
+	q.Q.Init()
+}
 
+// exist returns whether a transaction is in queue, q.Mu must be held.
+func (q *EventQueue) exist(uuid string) bool {
 	p := q.Q.Front()
 	for p != nil {
 		if p.Value.(*pb.Transaction).TransactionUuid == uuid {
 			return true
 		}
+		p = p.Next()
 	}
 	return false
 }
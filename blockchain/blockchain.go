@@ -58,6 +58,11 @@ func NewBlockchain(path string) *Blockchain{
 	return res
 }
 
+// Path returns the path that blockchain stores persistent states, it's empty if blockchain is non-persistent.
+func (bc *Blockchain) Path() string {
+	return bc.path
+}
+
 // Reconcile replicate blockchain to be same as state stored in persistent storage.
 func (bc *Blockchain) Reconcile() {
 	blockMap := make(map[pb.BlockState]map[string]*pb.Block)
@@ -357,6 +362,20 @@ func (bc *Blockchain) GetLastStagedBlock() []byte {
 	return bytes
 }
 
+// IsCommitted returns whether a block with given hash is committed. Chain is scanned from tail, since recently
+// delivered blocks are checked most often.
+// This function is thread safe.
+func (bc *Blockchain) IsCommitted(hash []byte) bool {
+	bc.Mu.RLock()
+	defer bc.Mu.RUnlock()
+	for i := len(bc.Chain) - 1; i >= 0; i-- {
+		if mao_utils.IsSameBytes(bc.Chain[i].CurHash, hash) {
+			return true
+		}
+	}
+	return false
+}
+
 // TODO: Optimize this function to be O(1)
 // IsBlockAlreadyInChain returns whether block is in either staged area or committed area.
 func (bc *Blockchain) IsBlockAlreadyInChain(block *pb.Block) bool {
@@ -374,6 +393,7 @@ func (bc *Blockchain) IsBlockAlreadyInChain(block *pb.Block) bool {
 }
 
 // GetBlocksForSyncRequest will read the committed chain and try to answer the question.
+// If latestStaged is empty, it answers all blocks committed after lastCommit.
 // It returns an empty array or nil if not able to answer.
 func (bc *Blockchain) GetAnswerForSyncRequest(lastCommit []byte, latestStaged []byte) []*pb.Block {
 	foundBegin := false
@@ -385,6 +405,8 @@ func (bc *Blockchain) GetAnswerForSyncRequest(lastCommit []byte, latestStaged []
 	if err != nil {
 		return nil
 	}
+	bc.Mu.RLock()
+	defer bc.Mu.RUnlock()
 	var res []*pb.Block
 	for _, committedBlock := range bc.Chain {
 		if mao_utils.IsSameBlock(committedBlock, lastStagedBlock) {
@@ -396,5 +418,28 @@ func (bc *Blockchain) GetAnswerForSyncRequest(lastCommit []byte, latestStaged []
 				foundBegin = true
 		}
 	}
+	if len(latestStaged) == 0 {
+		return res
+	}
 	return nil
+}
+
+// DropPending removes all pending blocks, this happens once the node stops proposing on top of them.
+// This function is thread safe.
+func (bc *Blockchain) DropPending() {
+	bc.Mu.Lock()
+	defer bc.Mu.Unlock()
+
+	for iter := bc.Pending.Front(); iter != nil; iter = iter.Next() {
+		block := iter.Value.(*pb.Block)
+		if bc.persistent {
+			bc.logger.RemoveBlock(block, pb.BlockState_BS_PENDING)
+		}
+		for _, tx := range block.Content.Txs {
+			if bc.TxStatus[tx.TransactionUuid] == pb.TransactionStatus_PENDING {
+				delete(bc.TxStatus, tx.TransactionUuid)
+			}
+		}
+	}
+	bc.Pending.Init()
 }
\ No newline at end of file
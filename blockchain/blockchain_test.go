@@ -336,3 +336,33 @@ func TestBlockchain_GetAnswerForSyncRequest2(t *testing.T) {
 	assert.True(t, mao_utils.IsSameBlock(answerBlocks[0], pending1))
 	assert.True(t, mao_utils.IsSameBlock(answerBlocks[1], pending2))
 }
+
+func TestBlockchain_GetAnswerForSyncRequestWithoutStaged(t *testing.T) {
+	bc := NewBlockchain("")
+	pending1, _ := bc.CreateNewPendingBlock([]*pb.Transaction{
+		constructDepositTransaction("1", 10, "user1")})
+	pending2, _ := bc.CreateNewPendingBlock([]*pb.Transaction{
+		constructDepositTransaction("2", 10, "user2")})
+	_, _, _ = bc.CommitBlock(pending1)
+	_, _, _ = bc.CommitBlock(pending2)
+
+	headBytes, err := mao_utils.EncodeBlock(bc.Chain[0])
+	assert.Nil(t, err)
+	// Without latest staged, all committed blocks after head are answered.
+	answerBlocks := bc.GetAnswerForSyncRequest(headBytes, nil)
+	assert.Equal(t, len(answerBlocks), 2)
+	assert.True(t, mao_utils.IsSameBlock(answerBlocks[0], pending1))
+	assert.True(t, mao_utils.IsSameBlock(answerBlocks[1], pending2))
+}
+
+func TestBlockchain_DropPending(t *testing.T) {
+	bc := getSampleBlockchain()
+	bc.DropPending()
+	assert.Equal(t, bc.Pending.Len(), 0)
+	assert.Equal(t, len(bc.Chain), 2)
+	// Only pending transaction is forgotten.
+	_, ok := bc.TxStatus["3"]
+	assert.False(t, ok)
+	assert.Equal(t, bc.TxStatus["4"], pb.TransactionStatus_STAGED)
+	assert.Equal(t, bc.TxStatus["1"], pb.TransactionStatus_COMMITTED)
+}
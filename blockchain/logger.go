@@ -73,6 +73,14 @@ func (logger *Logger) WriteBlock(block pb.Block, state pb.BlockState) {
 	<- done
 }
 
+// RemoveBlock removes a block dump from disk. System will exist if encounters any failure.
+func (logger *Logger) RemoveBlock(block *pb.Block, state pb.BlockState) {
+	fileName := mao_utils.GetFileNameFromBlockDump(pb.BlockDump{Block: block, State: state})
+	if err := os.Remove(logger.dir + "/" + fileName); err != nil && !os.IsNotExist(err) {
+		log.Fatalln("Failed to remove block from disk: " + logger.dir + "/" + fileName, err)
+	}
+}
+
 // ReadAllBlocks read all block dumps from local disk, return a list of block dump.
 func (logger *Logger) ReadAllBlocks() ([]pb.BlockDump, error) {
 	files, err := ioutil.ReadDir(logger.dir)
@@ -22,6 +22,7 @@ import (
 	"github.com/fatih/color"
 	"github.com/gopricy/mao-bft/application/transaction"
 	"github.com/gopricy/mao-bft/pb"
+	"github.com/gopricy/mao-bft/rbc/adversary"
 	"github.com/gopricy/mao-bft/rbc/common"
 	"github.com/gopricy/mao-bft/rbc/mock"
 	"github.com/gopricy/mao-bft/rbc/sign"
@@ -32,6 +33,7 @@ import (
 
 const rbcSetting = "rbc_setting.json"
 const privateKeys = "private_keys.json"
+const tlsIdentities = "tls_identities.json"
 
 func main() {
 	t := flag.String("t", "", "type of app")
@@ -48,7 +50,11 @@ func main() {
 	}
 
 	if args[0] == "init" {
-		rbcsetting, allpks, _ := mock.InitPeers(1)
+		rbcsetting, allpks := mock.InitPeers(1)
+		identities, err := mock.InitTLS(&rbcsetting)
+		if err != nil {
+			panic(err)
+		}
 		bytes, err := json.Marshal(rbcsetting)
 		if err != nil {
 			panic(err)
@@ -60,6 +66,12 @@ func main() {
 			panic(err)
 		}
 		write(privateKeys, keys)
+
+		ids, err := json.Marshal(identities)
+		if err != nil {
+			panic(err)
+		}
+		write(tlsIdentities, ids)
 		return
 	}
 
@@ -87,12 +99,21 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	var identities map[string]common.TLSIdentity
+	idBytes, err := ioutil.ReadFile(tlsIdentities)
+	if err != nil {
+		panic(err)
+	}
+	err = json.Unmarshal(idBytes, &identities)
+	if err != nil {
+		panic(err)
+	}
 	var g errgroup.Group
 	logging.SetLevel(logging.DEBUG, "RBC")
 	switch *t {
 	case "leader":
 		leaderApp := transaction.NewLeader(1, "pstl")
-		l, s, err := mock.NewLeader(leaderApp, keys[0], rbcSetting, &g)
+		l, s, err := mock.NewTLSLeader(leaderApp, keys[0], identities["mao"], rbcSetting, &g)
 		defer s()
 		if err != nil {
 			panic(err)
@@ -102,7 +123,7 @@ func main() {
 
 	case "follower":
 		followerApp := transaction.NewFollower(fmt.Sprintf("pstf%d", i))
-		err, s := mock.NewFollower(followerApp, i, keys[i], rbcSetting, &g)
+		err, s := mock.NewTLSFollower(followerApp, i, keys[i], identities[fmt.Sprintf("f%d", i)], rbcSetting, &g)
 		defer s()
 		if err != nil {
 			panic(err)
@@ -118,7 +139,7 @@ type handler interface {
 }
 
 type rbcHandler interface {
-	SetMode(int)
+	SetAdversary(common.Adversary)
 }
 
 func parseCommand(userInput string, ledger *transaction.Ledger, h handler, rh rbcHandler) (string, [][]byte) {
@@ -128,7 +149,7 @@ func parseCommand(userInput string, ledger *transaction.Ledger, h handler, rh rb
 	getStatus := regexp.MustCompile(`(?i)status (\S+)`)
 	getBalance := regexp.MustCompile(`(?i)balance (\S+)`)
 	setLevel := regexp.MustCompile(`(?i)level (?i)(INFO|DEBUG)`)
-	byzantineMode := regexp.MustCompile(`(?i)mode (0|1|2|3|4)`)
+	byzantineMode := regexp.MustCompile(`(?i)mode (\S+)(?: (\S+))?`)
 
 	dep := deposit.FindSubmatch([]byte(userInput))
 	trans := transfer.FindSubmatch([]byte(userInput))
@@ -163,9 +184,25 @@ func parseCommand(userInput string, ledger *transaction.Ledger, h handler, rh rb
 		fmt.Println("Level set to DEBUG")
 		return "handled", nil
 	case len(mode) != 0:
-		m, _ := strconv.Atoi(string(mode[1]))
-		rh.SetMode(m)
-		fmt.Printf("Set byzantine mode to %d\n", m)
+		// Peers are separated by comma, e.g. "mode equivocate f1,f2", all peers are targeted if none is given.
+		var targets []string
+		if len(mode[2]) != 0 {
+			targets = strings.Split(string(mode[2]), ",")
+		}
+		a, err := adversary.New(string(mode[1]), targets...)
+		if err != nil {
+			fmt.Println(err)
+			for _, name := range adversary.Names() {
+				fmt.Printf("  %s: %s\n", name, adversary.Describe(name))
+			}
+			return "handled", nil
+		}
+		rh.SetAdversary(a)
+		against := "all peers"
+		if len(targets) != 0 {
+			against = strings.Join(targets, ", ")
+		}
+		fmt.Printf("Set byzantine mode to %s against %s\n", mode[1], against)
 		return "handled", nil
 	default:
 		return "unknown", nil
@@ -1,17 +1,23 @@
 package maobft
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/gopricy/mao-bft/application/transaction"
+	"github.com/gopricy/mao-bft/pb"
+	"github.com/gopricy/mao-bft/rbc/adversary"
 	"github.com/gopricy/mao-bft/rbc/common"
+	"github.com/gopricy/mao-bft/rbc/follower"
 	"github.com/gopricy/mao-bft/rbc/mock"
+	"github.com/gopricy/mao-bft/rbc/sign"
 	"github.com/op/go-logging"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
 )
 
 const faultLimit = 1
@@ -69,7 +75,7 @@ func createApps(num int) (res []common.Application) {
 func TestIntegration_ValidSingleTxPerBlock(t *testing.T) {
 	var g errgroup.Group
 
-	rbcSetting, priKeys, _ := mock.InitPeers(faultLimit)
+	rbcSetting, priKeys := mock.InitPeers(faultLimit)
 	var stoppers []func()
 	apps := createApps(followerNum + 1)
 	l, s := mock.StartLeader(t, apps[0], priKeys[0], rbcSetting, &g)
@@ -99,10 +105,74 @@ func TestIntegration_ValidSingleTxPerBlock(t *testing.T) {
 	//assert.Nil(t, cleaner())
 }
 
+func TestIntegration_LargerCluster(t *testing.T) {
+	var g errgroup.Group
+
+	// 5 nodes tolerate 1 fault with quorums that aren't those of 3f + 1 nodes.
+	rbcSetting, priKeys, err := mock.InitCluster(5, faultLimit)
+	assert.Nil(t, err)
+	var stoppers []func()
+	apps := createApps(5)
+	l, s := mock.StartLeader(t, apps[0], priKeys[0], rbcSetting, &g)
+	apps[0].(*transaction.Leader).SetRBCLeader(l)
+	stoppers = append(stoppers, s)
+	ss := mock.StartFollowers(t, apps[1:], priKeys[1:], rbcSetting, &g)
+	stoppers = append(stoppers, ss...)
+
+	exp := mockTransactions(apps[0].(*transaction.Leader))
+
+	time.Sleep(time.Second * 1)
+	for _, s := range stoppers {
+		s()
+	}
+	assert.Nil(t, g.Wait())
+
+	for _, f := range apps[1:] {
+		assert.Equal(t, exp, f.(*transaction.Follower).Ledger.Accounts)
+	}
+	_, _, err = mock.InitCluster(3, faultLimit)
+	assert.Error(t, err)
+}
+
+func TestIntegration_MutualTLS(t *testing.T) {
+	var g errgroup.Group
+
+	rbcSetting, priKeys := mock.InitPeers(faultLimit)
+	identities, err := mock.InitTLS(&rbcSetting)
+	assert.Nil(t, err)
+	apps := createApps(followerNum + 1)
+	l, s, err := mock.NewTLSLeader(apps[0], priKeys[0], identities["mao"], rbcSetting, &g)
+	assert.Nil(t, err)
+	apps[0].(*transaction.Leader).SetRBCLeader(l)
+	stoppers := []func(){s}
+	for i := 1; i <= followerNum; i++ {
+		name := fmt.Sprintf("f%d", i)
+		err, s := mock.NewTLSFollower(apps[i], i, priKeys[i], identities[name], rbcSetting, &g)
+		assert.Nil(t, err)
+		stoppers = append(stoppers, s)
+	}
+
+	exp := mockTransactions(apps[0].(*transaction.Leader))
+
+	time.Sleep(time.Second * 1)
+	for _, s := range stoppers {
+		s()
+	}
+	assert.Nil(t, g.Wait())
+
+	ledgers := []*transaction.Ledger{apps[0].(*transaction.Leader).Ledger}
+	for _, f := range apps[1:] {
+		ledgers = append(ledgers, f.(*transaction.Follower).Ledger)
+	}
+	for _, l := range ledgers {
+		assert.Equal(t, exp, l.Accounts)
+	}
+}
+
 func TestIntegration_OneServerDown(t *testing.T) {
 	var g errgroup.Group
 
-	rbcSetting, priKeys, _ := mock.InitPeers(faultLimit)
+	rbcSetting, priKeys := mock.InitPeers(faultLimit)
 	var stoppers []func()
 	apps := createApps(followerNum + 1)
 	l, s := mock.StartLeader(t, apps[0], priKeys[0], rbcSetting, &g)
@@ -134,7 +204,7 @@ func TestIntegration_OneServerDown(t *testing.T) {
 
 func TestIntegration_InvalidTransaction(t *testing.T) {
 	var g errgroup.Group
-	rbcSetting, priKeys, _ := mock.InitPeers(faultLimit)
+	rbcSetting, priKeys := mock.InitPeers(faultLimit)
 	var stoppers []func()
 	apps := createApps(followerNum + 1)
 	l, s := mock.StartLeader(t, apps[0], priKeys[0], rbcSetting, &g)
@@ -163,3 +233,195 @@ func TestIntegration_InvalidTransaction(t *testing.T) {
 	assert.True(t, strings.Contains(errs[0].Error(), "Invalid transaction:"))
 	//cleaner()
 }
+
+func TestIntegration_TransactionService(t *testing.T) {
+	var g errgroup.Group
+	rbcSetting, priKeys := mock.InitPeers(faultLimit)
+	var stoppers []func()
+	apps := createApps(followerNum + 1)
+	l, s := mock.StartLeader(t, apps[0], priKeys[0], rbcSetting, &g)
+	apps[0].(*transaction.Leader).SetRBCLeader(l)
+	stoppers = append(stoppers, s)
+	stoppers = append(stoppers, mock.StartFollowers(t, apps[1:], priKeys[1:], rbcSetting, &g)...)
+
+	dial := func(p *common.Peer) pb.TransactionServiceClient {
+		conn, err := grpc.Dial(fmt.Sprintf("%s:%d", p.IP, p.PORT), grpc.WithInsecure())
+		assert.Nil(t, err)
+		return pb.NewTransactionServiceClient(conn)
+	}
+	leaderClient := dial(rbcSetting.AllPeers["mao"])
+	followerClient := dial(rbcSetting.AllPeers["f1"])
+
+	deposit := &pb.ProposeTransactionRequest{Transaction: &pb.Transaction{
+		Message: &pb.Transaction_DepositMsg{DepositMsg: &pb.DepositMessage{AccountId: "001", Amount: 5050}},
+	}}
+	res, err := leaderClient.ProposeTransaction(context.Background(), deposit)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, res.TransactionUuid)
+
+	_, err = followerClient.ProposeTransaction(context.Background(), deposit)
+	assert.NotNil(t, err)
+
+	time.Sleep(time.Second * 1)
+	for _, c := range []pb.TransactionServiceClient{leaderClient, followerClient} {
+		status, err := c.GetTransactionStatus(context.Background(),
+			&pb.GetTransactionStatusRequest{TransactionUuid: res.TransactionUuid})
+		assert.Nil(t, err)
+		assert.Equal(t, pb.TransactionStatus_COMMITTED, status.Status)
+	}
+
+	for _, s := range stoppers {
+		s()
+	}
+	assert.Nil(t, g.Wait())
+}
+
+func TestIntegration_ViewChangeOnLeaderDown(t *testing.T) {
+	var g errgroup.Group
+	rbcSetting, priKeys := mock.InitPeers(faultLimit)
+	rbcSetting.ViewChangeTimeout = time.Millisecond * 500
+	names := []string{"f1", "f2", "f3", "mao"}
+	keys := map[string]sign.PrivateKey{"mao": priKeys[0], "f1": priKeys[1], "f2": priKeys[2], "f3": priKeys[3]}
+
+	stop := make(chan struct{})
+	replicas := map[string]*transaction.Replica{}
+	stoppers := map[string]func(){}
+	for _, name := range names {
+		app := transaction.NewReplica(1, "", rbcSetting.ViewChangeTimeout)
+		r, s, err := mock.NewReplica(app, name, keys[name], rbcSetting, &g)
+		assert.Nil(t, err)
+		app.SetRBCReplica(r)
+		go app.Run(stop)
+		replicas[name], stoppers[name] = app, s
+	}
+	// f1 leads view 0, since it's the first peer ordered by name.
+	propose := func(name string, amount int32) string {
+		p := rbcSetting.AllPeers[name]
+		conn, err := grpc.Dial(fmt.Sprintf("%s:%d", p.IP, p.PORT), grpc.WithInsecure())
+		assert.Nil(t, err)
+		defer conn.Close()
+		res, err := pb.NewTransactionServiceClient(conn).ProposeTransaction(context.Background(),
+			&pb.ProposeTransactionRequest{Transaction: &pb.Transaction{
+				Message: &pb.Transaction_DepositMsg{DepositMsg: &pb.DepositMessage{AccountId: "001", Amount: amount}},
+			}})
+		assert.Nil(t, err)
+		return res.TransactionUuid
+	}
+	committed := func(id string, alive []string) bool {
+		for _, name := range alive {
+			if replicas[name].GetTransactionStatus(id) != pb.TransactionStatus_COMMITTED {
+				return false
+			}
+		}
+		return true
+	}
+	waitCommitted := func(id string, alive []string) {
+		for i := 0; i < 50 && !committed(id, alive); i++ {
+			time.Sleep(time.Millisecond * 100)
+		}
+		assert.True(t, committed(id, alive))
+	}
+
+	waitCommitted(propose("f2", 100), names)
+
+	// Leader goes silent, followers should time out and move to view 1 led by f2.
+	stoppers["f1"]()
+	alive := names[1:]
+	waitCommitted(propose("f3", 50), alive)
+	for _, name := range alive {
+		balance, ok := replicas[name].Ledger.GetBalance("001")
+		assert.True(t, ok)
+		assert.Equal(t, 150, balance)
+	}
+
+	close(stop)
+	for _, name := range alive {
+		stoppers[name]()
+	}
+	assert.Nil(t, g.Wait())
+}
+
+func TestIntegration_MemoryTransport(t *testing.T) {
+	rbcSetting, priKeys := mock.InitPeers(faultLimit)
+	network := common.NewMemoryNetwork()
+	apps := createApps(followerNum + 1)
+	l := mock.NewMemoryLeader(apps[0], priKeys[0], rbcSetting, network)
+	apps[0].(*transaction.Leader).SetRBCLeader(l)
+	for i := 1; i < followerNum; i++ {
+		mock.NewMemoryFollower(apps[i], i, priKeys[i], rbcSetting, network)
+	}
+	// The last follower is down, f nodes can't stop the cluster.
+
+	exp := mockTransactions(apps[0].(*transaction.Leader))
+
+	time.Sleep(time.Second * 1)
+
+	ledgers := []*transaction.Ledger{apps[0].(*transaction.Leader).Ledger}
+	for _, f := range apps[1:followerNum] {
+		ledgers = append(ledgers, f.(*transaction.Follower).Ledger)
+	}
+	for _, l := range ledgers {
+		assert.Equal(t, exp, l.Accounts)
+	}
+	assert.Equal(t, map[string]int32{}, apps[followerNum].(*transaction.Follower).Ledger.Accounts)
+
+	// Every committed block can be audited with public keys of the cluster.
+	chain := apps[1].(*transaction.Follower).Blockchain.Chain
+	assert.True(t, len(chain) > 1)
+	for _, block := range chain[1:] {
+		assert.Nil(t, common.VerifyBlockCertificate(&rbcSetting, block))
+	}
+}
+
+func TestIntegration_ReplicatedMode(t *testing.T) {
+	rbcSetting, priKeys := mock.InitPeers(faultLimit)
+	// Blocks of a single transaction are replicated.
+	rbcSetting.ReplicatedLimit = 1024
+	network := common.NewMemoryNetwork()
+	apps := createApps(followerNum + 1)
+	l := mock.NewMemoryLeader(apps[0], priKeys[0], rbcSetting, network)
+	apps[0].(*transaction.Leader).SetRBCLeader(l)
+	for i := 1; i <= followerNum; i++ {
+		mock.NewMemoryFollower(apps[i], i, priKeys[i], rbcSetting, network)
+	}
+
+	exp := mockTransactions(apps[0].(*transaction.Leader))
+
+	time.Sleep(time.Second * 1)
+
+	for _, f := range apps[1:] {
+		assert.Equal(t, exp, f.(*transaction.Follower).Ledger.Accounts)
+		for _, block := range f.(*transaction.Follower).Blockchain.Chain[1:] {
+			assert.Equal(t, pb.BroadcastMode_BM_REPLICATED, block.Certificate.Mode)
+			assert.Nil(t, common.VerifyBlockCertificate(&rbcSetting, block))
+		}
+	}
+}
+
+func TestIntegration_ByzantineFollower(t *testing.T) {
+	rbcSetting, priKeys := mock.InitPeers(faultLimit)
+	network := common.NewMemoryNetwork()
+	apps := createApps(followerNum + 1)
+	l := mock.NewMemoryLeader(apps[0], priKeys[0], rbcSetting, network)
+	apps[0].(*transaction.Leader).SetRBCLeader(l)
+	var followers []*follower.Follower
+	for i := 1; i <= followerNum; i++ {
+		followers = append(followers, mock.NewMemoryFollower(apps[i], i, priKeys[i], rbcSetting, network))
+	}
+	// f nodes that corrupt everything they send can't stop the cluster.
+	byzantine, err := adversary.New("mutate")
+	assert.Nil(t, err)
+	followers[followerNum-1].SetAdversary(adversary.Chain(byzantine, &adversary.Duplicate{Copies: 2}))
+
+	exp := mockTransactions(apps[0].(*transaction.Leader))
+
+	time.Sleep(time.Second * 1)
+
+	ledgers := []*transaction.Ledger{apps[0].(*transaction.Leader).Ledger}
+	for _, f := range apps[1:followerNum] {
+		ledgers = append(ledgers, f.(*transaction.Follower).Ledger)
+	}
+	for _, l := range ledgers {
+		assert.Equal(t, exp, l.Accounts)
+	}
+}
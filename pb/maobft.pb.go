@@ -31,6 +31,123 @@ const (
 // of the legacy proto package is being used.
 const _ = proto.ProtoPackageIsVersion4
 
+// BroadcastMode is how a RBC instance carries its value.
+type BroadcastMode int32
+
+const (
+	// AVID splits the value into erasure coded shards under a Merkle root, each PREPARE and ECHO carries a shard.
+	BroadcastMode_BM_AVID BroadcastMode = 0
+	// REPLICATED is Bracha's broadcast, PREPARE and ECHO carry the whole value, and votes are on its hash.
+	BroadcastMode_BM_REPLICATED BroadcastMode = 1
+)
+
+// Enum value maps for BroadcastMode.
+var (
+	BroadcastMode_name = map[int32]string{
+		0: "BM_AVID",
+		1: "BM_REPLICATED",
+	}
+	BroadcastMode_value = map[string]int32{
+		"BM_AVID":       0,
+		"BM_REPLICATED": 1,
+	}
+)
+
+func (x BroadcastMode) Enum() *BroadcastMode {
+	p := new(BroadcastMode)
+	*p = x
+	return p
+}
+
+func (x BroadcastMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BroadcastMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_maobft_proto_enumTypes[0].Descriptor()
+}
+
+func (BroadcastMode) Type() protoreflect.EnumType {
+	return &file_maobft_proto_enumTypes[0]
+}
+
+func (x BroadcastMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BroadcastMode.Descriptor instead.
+func (BroadcastMode) EnumDescriptor() ([]byte, []int) {
+	return file_maobft_proto_rawDescGZIP(), []int{0}
+}
+
+// MessageType is the type of a signed RBC message.
+type MessageType int32
+
+const (
+	MessageType_MT_UNKNOWN MessageType = 0
+	MessageType_MT_PREPARE MessageType = 1
+	MessageType_MT_ECHO    MessageType = 2
+	MessageType_MT_READY   MessageType = 3
+	MessageType_MT_BVAL    MessageType = 4
+	MessageType_MT_AUX     MessageType = 5
+	MessageType_MT_CONF    MessageType = 6
+	MessageType_MT_TERM    MessageType = 7
+	MessageType_MT_COIN    MessageType = 8
+)
+
+// Enum value maps for MessageType.
+var (
+	MessageType_name = map[int32]string{
+		0: "MT_UNKNOWN",
+		1: "MT_PREPARE",
+		2: "MT_ECHO",
+		3: "MT_READY",
+		4: "MT_BVAL",
+		5: "MT_AUX",
+		6: "MT_CONF",
+		7: "MT_TERM",
+		8: "MT_COIN",
+	}
+	MessageType_value = map[string]int32{
+		"MT_UNKNOWN": 0,
+		"MT_PREPARE": 1,
+		"MT_ECHO":    2,
+		"MT_READY":   3,
+		"MT_BVAL":    4,
+		"MT_AUX":     5,
+		"MT_CONF":    6,
+		"MT_TERM":    7,
+		"MT_COIN":    8,
+	}
+)
+
+func (x MessageType) Enum() *MessageType {
+	p := new(MessageType)
+	*p = x
+	return p
+}
+
+func (x MessageType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MessageType) Descriptor() protoreflect.EnumDescriptor {
+	return file_maobft_proto_enumTypes[1].Descriptor()
+}
+
+func (MessageType) Type() protoreflect.EnumType {
+	return &file_maobft_proto_enumTypes[1]
+}
+
+func (x MessageType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MessageType.Descriptor instead.
+func (MessageType) EnumDescriptor() ([]byte, []int) {
+	return file_maobft_proto_rawDescGZIP(), []int{1}
+}
+
 type BlockState int32
 
 const (
@@ -67,11 +184,11 @@ func (x BlockState) String() string {
 }
 
 func (BlockState) Descriptor() protoreflect.EnumDescriptor {
-	return file_maobft_proto_enumTypes[0].Descriptor()
+	return file_maobft_proto_enumTypes[2].Descriptor()
 }
 
 func (BlockState) Type() protoreflect.EnumType {
-	return &file_maobft_proto_enumTypes[0]
+	return &file_maobft_proto_enumTypes[2]
 }
 
 func (x BlockState) Number() protoreflect.EnumNumber {
@@ -80,7 +197,71 @@ func (x BlockState) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use BlockState.Descriptor instead.
 func (BlockState) EnumDescriptor() ([]byte, []int) {
-	return file_maobft_proto_rawDescGZIP(), []int{0}
+	return file_maobft_proto_rawDescGZIP(), []int{2}
+}
+
+// AgreementType is the type of a binary agreement message.
+type AgreementType int32
+
+const (
+	AgreementType_AT_UNKNOWN AgreementType = 0
+	// BVAL broadcasts a value that sender holds in a round.
+	AgreementType_AT_BVAL AgreementType = 1
+	// AUX broadcasts a value that sender got BVAL of from 2f+1 peers in a round.
+	AgreementType_AT_AUX AgreementType = 2
+	// TERM announces the value that sender decided, it's valid in any round.
+	AgreementType_AT_TERM AgreementType = 3
+	// CONF broadcasts values that sender has in bin, once it got AUX of them from N-f peers in a round.
+	AgreementType_AT_CONF AgreementType = 4
+	// COIN shares the signature share of sender for the coin of a round, once sender got CONF from N-f peers.
+	AgreementType_AT_COIN AgreementType = 5
+)
+
+// Enum value maps for AgreementType.
+var (
+	AgreementType_name = map[int32]string{
+		0: "AT_UNKNOWN",
+		1: "AT_BVAL",
+		2: "AT_AUX",
+		3: "AT_TERM",
+		4: "AT_CONF",
+		5: "AT_COIN",
+	}
+	AgreementType_value = map[string]int32{
+		"AT_UNKNOWN": 0,
+		"AT_BVAL":    1,
+		"AT_AUX":     2,
+		"AT_TERM":    3,
+		"AT_CONF":    4,
+		"AT_COIN":    5,
+	}
+)
+
+func (x AgreementType) Enum() *AgreementType {
+	p := new(AgreementType)
+	*p = x
+	return p
+}
+
+func (x AgreementType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AgreementType) Descriptor() protoreflect.EnumDescriptor {
+	return file_maobft_proto_enumTypes[3].Descriptor()
+}
+
+func (AgreementType) Type() protoreflect.EnumType {
+	return &file_maobft_proto_enumTypes[3]
+}
+
+func (x AgreementType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AgreementType.Descriptor instead.
+func (AgreementType) EnumDescriptor() ([]byte, []int) {
+	return file_maobft_proto_rawDescGZIP(), []int{3}
 }
 
 type TransactionStatus int32
@@ -122,11 +303,11 @@ func (x TransactionStatus) String() string {
 }
 
 func (TransactionStatus) Descriptor() protoreflect.EnumDescriptor {
-	return file_maobft_proto_enumTypes[1].Descriptor()
+	return file_maobft_proto_enumTypes[4].Descriptor()
 }
 
 func (TransactionStatus) Type() protoreflect.EnumType {
-	return &file_maobft_proto_enumTypes[1]
+	return &file_maobft_proto_enumTypes[4]
 }
 
 func (x TransactionStatus) Number() protoreflect.EnumNumber {
@@ -135,7 +316,7 @@ func (x TransactionStatus) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use TransactionStatus.Descriptor instead.
 func (TransactionStatus) EnumDescriptor() ([]byte, []int) {
-	return file_maobft_proto_rawDescGZIP(), []int{1}
+	return file_maobft_proto_rawDescGZIP(), []int{4}
 }
 
 // A merkle proof is a data structure that proves a content is stored in the Merkle tree.
@@ -268,6 +449,73 @@ func (x *ProofPair) GetIsRightChild() bool {
 	return false
 }
 
+// InstanceId identifies a RBC instance, which is the sequence-th broadcast of broadcaster in epoch.
+type InstanceId struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Name of the node that broadcasts.
+	Broadcaster string `protobuf:"bytes,1,opt,name=broadcaster,proto3" json:"broadcaster,omitempty"`
+	// Epoch of the broadcaster, it's the view in view change mode.
+	Epoch int64 `protobuf:"varint,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	// Sequence number of the broadcast in epoch.
+	Sequence int64 `protobuf:"varint,3,opt,name=sequence,proto3" json:"sequence,omitempty"`
+}
+
+func (x *InstanceId) Reset() {
+	*x = InstanceId{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_maobft_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *InstanceId) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstanceId) ProtoMessage() {}
+
+func (x *InstanceId) ProtoReflect() protoreflect.Message {
+	mi := &file_maobft_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InstanceId.ProtoReflect.Descriptor instead.
+func (*InstanceId) Descriptor() ([]byte, []int) {
+	return file_maobft_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *InstanceId) GetBroadcaster() string {
+	if x != nil {
+		return x.Broadcaster
+	}
+	return ""
+}
+
+func (x *InstanceId) GetEpoch() int64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *InstanceId) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
 type Payload struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -276,12 +524,18 @@ type Payload struct {
 	MerkleProof *MerkleProof `protobuf:"bytes,1,opt,name=merkle_proof,json=merkleProof,proto3" json:"merkle_proof,omitempty"`
 	PrevHash    []byte       `protobuf:"bytes,2,opt,name=prev_hash,json=prevHash,proto3" json:"prev_hash,omitempty"`
 	Data        []byte       `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	// The RBC instance this payload belongs to.
+	Instance *InstanceId `protobuf:"bytes,4,opt,name=instance,proto3" json:"instance,omitempty"`
+	// Signature of sender over the Envelope of this payload.
+	Signature []byte `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+	// How the broadcaster sends data, data is the whole value in replicated mode.
+	Mode BroadcastMode `protobuf:"varint,6,opt,name=mode,proto3,enum=pb.BroadcastMode" json:"mode,omitempty"`
 }
 
 func (x *Payload) Reset() {
 	*x = Payload{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_maobft_proto_msgTypes[2]
+		mi := &file_maobft_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -294,7 +548,7 @@ func (x *Payload) String() string {
 func (*Payload) ProtoMessage() {}
 
 func (x *Payload) ProtoReflect() protoreflect.Message {
-	mi := &file_maobft_proto_msgTypes[2]
+	mi := &file_maobft_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -307,7 +561,7 @@ func (x *Payload) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Payload.ProtoReflect.Descriptor instead.
 func (*Payload) Descriptor() ([]byte, []int) {
-	return file_maobft_proto_rawDescGZIP(), []int{2}
+	return file_maobft_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *Payload) GetMerkleProof() *MerkleProof {
@@ -331,6 +585,151 @@ func (x *Payload) GetData() []byte {
 	return nil
 }
 
+func (x *Payload) GetInstance() *InstanceId {
+	if x != nil {
+		return x.Instance
+	}
+	return nil
+}
+
+func (x *Payload) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *Payload) GetMode() BroadcastMode {
+	if x != nil {
+		return x.Mode
+	}
+	return BroadcastMode_BM_AVID
+}
+
+// Envelope is the canonical form of a RBC message that its sender signs. It's never sent, receiver rebuilds it from
+// the message to verify the signature, so that every field of the message is authenticated.
+type Envelope struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type        MessageType  `protobuf:"varint,1,opt,name=type,proto3,enum=pb.MessageType" json:"type,omitempty"`
+	Instance    *InstanceId  `protobuf:"bytes,2,opt,name=instance,proto3" json:"instance,omitempty"`
+	MerkleRoot  []byte       `protobuf:"bytes,3,opt,name=merkle_root,json=merkleRoot,proto3" json:"merkle_root,omitempty"`
+	PrevHash    []byte       `protobuf:"bytes,4,opt,name=prev_hash,json=prevHash,proto3" json:"prev_hash,omitempty"`
+	MerkleProof *MerkleProof `protobuf:"bytes,5,opt,name=merkle_proof,json=merkleProof,proto3" json:"merkle_proof,omitempty"`
+	Data        []byte       `protobuf:"bytes,6,opt,name=data,proto3" json:"data,omitempty"`
+	// The cluster that message is sent in, so that a message can't be replayed in another cluster.
+	ClusterId string `protobuf:"bytes,7,opt,name=cluster_id,json=clusterId,proto3" json:"cluster_id,omitempty"`
+	// Round of a binary agreement message.
+	Round int64 `protobuf:"varint,8,opt,name=round,proto3" json:"round,omitempty"`
+	// Values of a binary agreement message.
+	Values []bool        `protobuf:"varint,9,rep,packed,name=values,proto3" json:"values,omitempty"`
+	Mode   BroadcastMode `protobuf:"varint,10,opt,name=mode,proto3,enum=pb.BroadcastMode" json:"mode,omitempty"`
+}
+
+func (x *Envelope) Reset() {
+	*x = Envelope{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_maobft_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Envelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Envelope) ProtoMessage() {}
+
+func (x *Envelope) ProtoReflect() protoreflect.Message {
+	mi := &file_maobft_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Envelope.ProtoReflect.Descriptor instead.
+func (*Envelope) Descriptor() ([]byte, []int) {
+	return file_maobft_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Envelope) GetType() MessageType {
+	if x != nil {
+		return x.Type
+	}
+	return MessageType_MT_UNKNOWN
+}
+
+func (x *Envelope) GetInstance() *InstanceId {
+	if x != nil {
+		return x.Instance
+	}
+	return nil
+}
+
+func (x *Envelope) GetMerkleRoot() []byte {
+	if x != nil {
+		return x.MerkleRoot
+	}
+	return nil
+}
+
+func (x *Envelope) GetPrevHash() []byte {
+	if x != nil {
+		return x.PrevHash
+	}
+	return nil
+}
+
+func (x *Envelope) GetMerkleProof() *MerkleProof {
+	if x != nil {
+		return x.MerkleProof
+	}
+	return nil
+}
+
+func (x *Envelope) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Envelope) GetClusterId() string {
+	if x != nil {
+		return x.ClusterId
+	}
+	return ""
+}
+
+func (x *Envelope) GetRound() int64 {
+	if x != nil {
+		return x.Round
+	}
+	return 0
+}
+
+func (x *Envelope) GetValues() []bool {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+func (x *Envelope) GetMode() BroadcastMode {
+	if x != nil {
+		return x.Mode
+	}
+	return BroadcastMode_BM_AVID
+}
+
 // This serves as the logger for blockchain. Any
 type BlockDump struct {
 	state         protoimpl.MessageState
@@ -346,7 +745,7 @@ type BlockDump struct {
 func (x *BlockDump) Reset() {
 	*x = BlockDump{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_maobft_proto_msgTypes[3]
+		mi := &file_maobft_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -359,7 +758,7 @@ func (x *BlockDump) String() string {
 func (*BlockDump) ProtoMessage() {}
 
 func (x *BlockDump) ProtoReflect() protoreflect.Message {
-	mi := &file_maobft_proto_msgTypes[3]
+	mi := &file_maobft_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -372,7 +771,7 @@ func (x *BlockDump) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BlockDump.ProtoReflect.Descriptor instead.
 func (*BlockDump) Descriptor() ([]byte, []int) {
-	return file_maobft_proto_rawDescGZIP(), []int{3}
+	return file_maobft_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *BlockDump) GetBlock() *Block {
@@ -398,12 +797,15 @@ type Block struct {
 	Content *BlockContent `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
 	// Hash of this block.
 	CurHash []byte `protobuf:"bytes,2,opt,name=cur_hash,json=curHash,proto3" json:"cur_hash,omitempty"`
+	// Certificate that this block is delivered, it's set once the block is delivered, so it's not part of the data that's
+	// broadcast.
+	Certificate *QuorumCertificate `protobuf:"bytes,3,opt,name=certificate,proto3" json:"certificate,omitempty"`
 }
 
 func (x *Block) Reset() {
 	*x = Block{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_maobft_proto_msgTypes[4]
+		mi := &file_maobft_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -416,7 +818,7 @@ func (x *Block) String() string {
 func (*Block) ProtoMessage() {}
 
 func (x *Block) ProtoReflect() protoreflect.Message {
-	mi := &file_maobft_proto_msgTypes[4]
+	mi := &file_maobft_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -429,7 +831,7 @@ func (x *Block) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Block.ProtoReflect.Descriptor instead.
 func (*Block) Descriptor() ([]byte, []int) {
-	return file_maobft_proto_rawDescGZIP(), []int{4}
+	return file_maobft_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *Block) GetContent() *BlockContent {
@@ -446,21 +848,166 @@ func (x *Block) GetCurHash() []byte {
 	return nil
 }
 
-type BlockContent struct {
+func (x *Block) GetCertificate() *QuorumCertificate {
+	if x != nil {
+		return x.Certificate
+	}
+	return nil
+}
+
+// ReadyVote is a signed READY in a quorum certificate.
+type ReadyVote struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// This defines the transactions that is contained in this block.
-	Txs []*Transaction `protobuf:"bytes,1,rep,name=txs,proto3" json:"txs,omitempty"`
-	// Hash of previous block, nil if block is head.
+	Peer     string `protobuf:"bytes,1,opt,name=peer,proto3" json:"peer,omitempty"`
+	PrevHash []byte `protobuf:"bytes,2,opt,name=prev_hash,json=prevHash,proto3" json:"prev_hash,omitempty"`
+	// Signature of peer over the Envelope of the READY.
+	Signature []byte `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (x *ReadyVote) Reset() {
+	*x = ReadyVote{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_maobft_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReadyVote) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadyVote) ProtoMessage() {}
+
+func (x *ReadyVote) ProtoReflect() protoreflect.Message {
+	mi := &file_maobft_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadyVote.ProtoReflect.Descriptor instead.
+func (*ReadyVote) Descriptor() ([]byte, []int) {
+	return file_maobft_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ReadyVote) GetPeer() string {
+	if x != nil {
+		return x.Peer
+	}
+	return ""
+}
+
+func (x *ReadyVote) GetPrevHash() []byte {
+	if x != nil {
+		return x.PrevHash
+	}
+	return nil
+}
+
+func (x *ReadyVote) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+// QuorumCertificate proves that 2f+1 peers were ready to deliver the data of merkle_root in instance.
+type QuorumCertificate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Instance *InstanceId `protobuf:"bytes,1,opt,name=instance,proto3" json:"instance,omitempty"`
+	// Merkle root of the data in AVID mode, or its hash in replicated mode.
+	MerkleRoot []byte        `protobuf:"bytes,2,opt,name=merkle_root,json=merkleRoot,proto3" json:"merkle_root,omitempty"`
+	Readies    []*ReadyVote  `protobuf:"bytes,3,rep,name=readies,proto3" json:"readies,omitempty"`
+	Mode       BroadcastMode `protobuf:"varint,4,opt,name=mode,proto3,enum=pb.BroadcastMode" json:"mode,omitempty"`
+}
+
+func (x *QuorumCertificate) Reset() {
+	*x = QuorumCertificate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_maobft_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *QuorumCertificate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuorumCertificate) ProtoMessage() {}
+
+func (x *QuorumCertificate) ProtoReflect() protoreflect.Message {
+	mi := &file_maobft_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuorumCertificate.ProtoReflect.Descriptor instead.
+func (*QuorumCertificate) Descriptor() ([]byte, []int) {
+	return file_maobft_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *QuorumCertificate) GetInstance() *InstanceId {
+	if x != nil {
+		return x.Instance
+	}
+	return nil
+}
+
+func (x *QuorumCertificate) GetMerkleRoot() []byte {
+	if x != nil {
+		return x.MerkleRoot
+	}
+	return nil
+}
+
+func (x *QuorumCertificate) GetReadies() []*ReadyVote {
+	if x != nil {
+		return x.Readies
+	}
+	return nil
+}
+
+func (x *QuorumCertificate) GetMode() BroadcastMode {
+	if x != nil {
+		return x.Mode
+	}
+	return BroadcastMode_BM_AVID
+}
+
+type BlockContent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// This defines the transactions that is contained in this block.
+	Txs []*Transaction `protobuf:"bytes,1,rep,name=txs,proto3" json:"txs,omitempty"`
+	// Hash of previous block, nil if block is head.
 	PrevHash []byte `protobuf:"bytes,2,opt,name=prev_hash,json=prevHash,proto3" json:"prev_hash,omitempty"`
 }
 
 func (x *BlockContent) Reset() {
 	*x = BlockContent{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_maobft_proto_msgTypes[5]
+		mi := &file_maobft_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -473,7 +1020,7 @@ func (x *BlockContent) String() string {
 func (*BlockContent) ProtoMessage() {}
 
 func (x *BlockContent) ProtoReflect() protoreflect.Message {
-	mi := &file_maobft_proto_msgTypes[5]
+	mi := &file_maobft_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -486,7 +1033,7 @@ func (x *BlockContent) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BlockContent.ProtoReflect.Descriptor instead.
 func (*BlockContent) Descriptor() ([]byte, []int) {
-	return file_maobft_proto_rawDescGZIP(), []int{5}
+	return file_maobft_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *BlockContent) GetTxs() []*Transaction {
@@ -517,7 +1064,7 @@ type WireMessage struct {
 func (x *WireMessage) Reset() {
 	*x = WireMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_maobft_proto_msgTypes[6]
+		mi := &file_maobft_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -530,7 +1077,7 @@ func (x *WireMessage) String() string {
 func (*WireMessage) ProtoMessage() {}
 
 func (x *WireMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_maobft_proto_msgTypes[6]
+	mi := &file_maobft_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -543,7 +1090,7 @@ func (x *WireMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WireMessage.ProtoReflect.Descriptor instead.
 func (*WireMessage) Descriptor() ([]byte, []int) {
-	return file_maobft_proto_rawDescGZIP(), []int{6}
+	return file_maobft_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *WireMessage) GetFromId() string {
@@ -581,7 +1128,7 @@ type DepositMessage struct {
 func (x *DepositMessage) Reset() {
 	*x = DepositMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_maobft_proto_msgTypes[7]
+		mi := &file_maobft_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -594,7 +1141,7 @@ func (x *DepositMessage) String() string {
 func (*DepositMessage) ProtoMessage() {}
 
 func (x *DepositMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_maobft_proto_msgTypes[7]
+	mi := &file_maobft_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -607,7 +1154,7 @@ func (x *DepositMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DepositMessage.ProtoReflect.Descriptor instead.
 func (*DepositMessage) Descriptor() ([]byte, []int) {
-	return file_maobft_proto_rawDescGZIP(), []int{7}
+	return file_maobft_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *DepositMessage) GetAccountId() string {
@@ -640,7 +1187,7 @@ type Transaction struct {
 func (x *Transaction) Reset() {
 	*x = Transaction{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_maobft_proto_msgTypes[8]
+		mi := &file_maobft_proto_msgTypes[12]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -653,7 +1200,7 @@ func (x *Transaction) String() string {
 func (*Transaction) ProtoMessage() {}
 
 func (x *Transaction) ProtoReflect() protoreflect.Message {
-	mi := &file_maobft_proto_msgTypes[8]
+	mi := &file_maobft_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -666,7 +1213,7 @@ func (x *Transaction) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
 func (*Transaction) Descriptor() ([]byte, []int) {
-	return file_maobft_proto_rawDescGZIP(), []int{8}
+	return file_maobft_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *Transaction) GetTransactionUuid() string {
@@ -722,7 +1269,7 @@ type PrepareResponse struct {
 func (x *PrepareResponse) Reset() {
 	*x = PrepareResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_maobft_proto_msgTypes[9]
+		mi := &file_maobft_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -735,7 +1282,7 @@ func (x *PrepareResponse) String() string {
 func (*PrepareResponse) ProtoMessage() {}
 
 func (x *PrepareResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_maobft_proto_msgTypes[9]
+	mi := &file_maobft_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -748,7 +1295,7 @@ func (x *PrepareResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PrepareResponse.ProtoReflect.Descriptor instead.
 func (*PrepareResponse) Descriptor() ([]byte, []int) {
-	return file_maobft_proto_rawDescGZIP(), []int{9}
+	return file_maobft_proto_rawDescGZIP(), []int{13}
 }
 
 type EchoResponse struct {
@@ -760,7 +1307,7 @@ type EchoResponse struct {
 func (x *EchoResponse) Reset() {
 	*x = EchoResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_maobft_proto_msgTypes[10]
+		mi := &file_maobft_proto_msgTypes[14]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -773,7 +1320,7 @@ func (x *EchoResponse) String() string {
 func (*EchoResponse) ProtoMessage() {}
 
 func (x *EchoResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_maobft_proto_msgTypes[10]
+	mi := &file_maobft_proto_msgTypes[14]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -786,7 +1333,7 @@ func (x *EchoResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EchoResponse.ProtoReflect.Descriptor instead.
 func (*EchoResponse) Descriptor() ([]byte, []int) {
-	return file_maobft_proto_rawDescGZIP(), []int{10}
+	return file_maobft_proto_rawDescGZIP(), []int{14}
 }
 
 type ReadyRequest struct {
@@ -796,12 +1343,16 @@ type ReadyRequest struct {
 
 	MerkleRoot []byte `protobuf:"bytes,1,opt,name=merkle_root,json=merkleRoot,proto3" json:"merkle_root,omitempty"`
 	PrevHash   []byte `protobuf:"bytes,2,opt,name=prev_hash,json=prevHash,proto3" json:"prev_hash,omitempty"`
+	// The RBC instance this READY belongs to.
+	Instance *InstanceId `protobuf:"bytes,3,opt,name=instance,proto3" json:"instance,omitempty"`
+	// Signature of sender over the Envelope of this READY.
+	Signature []byte `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
 }
 
 func (x *ReadyRequest) Reset() {
 	*x = ReadyRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_maobft_proto_msgTypes[11]
+		mi := &file_maobft_proto_msgTypes[15]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -814,7 +1365,7 @@ func (x *ReadyRequest) String() string {
 func (*ReadyRequest) ProtoMessage() {}
 
 func (x *ReadyRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_maobft_proto_msgTypes[11]
+	mi := &file_maobft_proto_msgTypes[15]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -827,7 +1378,7 @@ func (x *ReadyRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReadyRequest.ProtoReflect.Descriptor instead.
 func (*ReadyRequest) Descriptor() ([]byte, []int) {
-	return file_maobft_proto_rawDescGZIP(), []int{11}
+	return file_maobft_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *ReadyRequest) GetMerkleRoot() []byte {
@@ -844,6 +1395,20 @@ func (x *ReadyRequest) GetPrevHash() []byte {
 	return nil
 }
 
+func (x *ReadyRequest) GetInstance() *InstanceId {
+	if x != nil {
+		return x.Instance
+	}
+	return nil
+}
+
+func (x *ReadyRequest) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
 type ReadyResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -853,7 +1418,7 @@ type ReadyResponse struct {
 func (x *ReadyResponse) Reset() {
 	*x = ReadyResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_maobft_proto_msgTypes[12]
+		mi := &file_maobft_proto_msgTypes[16]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -866,7 +1431,7 @@ func (x *ReadyResponse) String() string {
 func (*ReadyResponse) ProtoMessage() {}
 
 func (x *ReadyResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_maobft_proto_msgTypes[12]
+	mi := &file_maobft_proto_msgTypes[16]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -879,7 +1444,7 @@ func (x *ReadyResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReadyResponse.ProtoReflect.Descriptor instead.
 func (*ReadyResponse) Descriptor() ([]byte, []int) {
-	return file_maobft_proto_rawDescGZIP(), []int{12}
+	return file_maobft_proto_rawDescGZIP(), []int{16}
 }
 
 type SyncRequest struct {
@@ -894,7 +1459,7 @@ type SyncRequest struct {
 func (x *SyncRequest) Reset() {
 	*x = SyncRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_maobft_proto_msgTypes[13]
+		mi := &file_maobft_proto_msgTypes[17]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -907,7 +1472,7 @@ func (x *SyncRequest) String() string {
 func (*SyncRequest) ProtoMessage() {}
 
 func (x *SyncRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_maobft_proto_msgTypes[13]
+	mi := &file_maobft_proto_msgTypes[17]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -920,7 +1485,7 @@ func (x *SyncRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SyncRequest.ProtoReflect.Descriptor instead.
 func (*SyncRequest) Descriptor() ([]byte, []int) {
-	return file_maobft_proto_rawDescGZIP(), []int{13}
+	return file_maobft_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *SyncRequest) GetLastCommit() []byte {
@@ -948,7 +1513,7 @@ type SyncResponse struct {
 func (x *SyncResponse) Reset() {
 	*x = SyncResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_maobft_proto_msgTypes[14]
+		mi := &file_maobft_proto_msgTypes[18]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -961,7 +1526,7 @@ func (x *SyncResponse) String() string {
 func (*SyncResponse) ProtoMessage() {}
 
 func (x *SyncResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_maobft_proto_msgTypes[14]
+	mi := &file_maobft_proto_msgTypes[18]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -974,7 +1539,7 @@ func (x *SyncResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SyncResponse.ProtoReflect.Descriptor instead.
 func (*SyncResponse) Descriptor() ([]byte, []int) {
-	return file_maobft_proto_rawDescGZIP(), []int{14}
+	return file_maobft_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *SyncResponse) GetResponse() [][]byte {
@@ -984,31 +1549,39 @@ func (x *SyncResponse) GetResponse() [][]byte {
 	return nil
 }
 
-type ProposeTransactionRequest struct {
+// ViewChangeVote is sent by a node that wants to move to new_view, it carries the node's committed state.
+type ViewChangeVote struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Transaction *Transaction `protobuf:"bytes,1,opt,name=transaction,proto3" json:"transaction,omitempty"` // TODO(chenweilunster): Implement client signature authentication.
+	// The view that sender wants to move to.
+	NewView int64 `protobuf:"varint,1,opt,name=new_view,json=newView,proto3" json:"new_view,omitempty"`
+	// The last block committed by sender.
+	LastCommit *Block `protobuf:"bytes,2,opt,name=last_commit,json=lastCommit,proto3" json:"last_commit,omitempty"`
+	// Number of blocks committed by sender, chain head excluded.
+	Height int64 `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+	// The cluster that vote is sent in.
+	ClusterId string `protobuf:"bytes,4,opt,name=cluster_id,json=clusterId,proto3" json:"cluster_id,omitempty"`
 }
 
-func (x *ProposeTransactionRequest) Reset() {
-	*x = ProposeTransactionRequest{}
+func (x *ViewChangeVote) Reset() {
+	*x = ViewChangeVote{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_maobft_proto_msgTypes[15]
+		mi := &file_maobft_proto_msgTypes[19]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ProposeTransactionRequest) String() string {
+func (x *ViewChangeVote) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ProposeTransactionRequest) ProtoMessage() {}
+func (*ViewChangeVote) ProtoMessage() {}
 
-func (x *ProposeTransactionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_maobft_proto_msgTypes[15]
+func (x *ViewChangeVote) ProtoReflect() protoreflect.Message {
+	mi := &file_maobft_proto_msgTypes[19]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1019,44 +1592,65 @@ func (x *ProposeTransactionRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ProposeTransactionRequest.ProtoReflect.Descriptor instead.
-func (*ProposeTransactionRequest) Descriptor() ([]byte, []int) {
-	return file_maobft_proto_rawDescGZIP(), []int{15}
+// Deprecated: Use ViewChangeVote.ProtoReflect.Descriptor instead.
+func (*ViewChangeVote) Descriptor() ([]byte, []int) {
+	return file_maobft_proto_rawDescGZIP(), []int{19}
 }
 
-func (x *ProposeTransactionRequest) GetTransaction() *Transaction {
+func (x *ViewChangeVote) GetNewView() int64 {
 	if x != nil {
-		return x.Transaction
+		return x.NewView
+	}
+	return 0
+}
+
+func (x *ViewChangeVote) GetLastCommit() *Block {
+	if x != nil {
+		return x.LastCommit
 	}
 	return nil
 }
 
-type ProposeTransactionResponse struct {
+func (x *ViewChangeVote) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *ViewChangeVote) GetClusterId() string {
+	if x != nil {
+		return x.ClusterId
+	}
+	return ""
+}
+
+type ViewChangeRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// Server assigned uuid for this transaction. This transaction is used to get transaction status.
-	TransactionUuid string `protobuf:"bytes,1,opt,name=transaction_uuid,json=transactionUuid,proto3" json:"transaction_uuid,omitempty"`
+	// Signed ViewChangeVote.
+	Vote []byte `protobuf:"bytes,1,opt,name=vote,proto3" json:"vote,omitempty"`
 }
 
-func (x *ProposeTransactionResponse) Reset() {
-	*x = ProposeTransactionResponse{}
+func (x *ViewChangeRequest) Reset() {
+	*x = ViewChangeRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_maobft_proto_msgTypes[16]
+		mi := &file_maobft_proto_msgTypes[20]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ProposeTransactionResponse) String() string {
+func (x *ViewChangeRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ProposeTransactionResponse) ProtoMessage() {}
+func (*ViewChangeRequest) ProtoMessage() {}
 
-func (x *ProposeTransactionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_maobft_proto_msgTypes[16]
+func (x *ViewChangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_maobft_proto_msgTypes[20]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1067,43 +1661,41 @@ func (x *ProposeTransactionResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ProposeTransactionResponse.ProtoReflect.Descriptor instead.
-func (*ProposeTransactionResponse) Descriptor() ([]byte, []int) {
-	return file_maobft_proto_rawDescGZIP(), []int{16}
+// Deprecated: Use ViewChangeRequest.ProtoReflect.Descriptor instead.
+func (*ViewChangeRequest) Descriptor() ([]byte, []int) {
+	return file_maobft_proto_rawDescGZIP(), []int{20}
 }
 
-func (x *ProposeTransactionResponse) GetTransactionUuid() string {
+func (x *ViewChangeRequest) GetVote() []byte {
 	if x != nil {
-		return x.TransactionUuid
+		return x.Vote
 	}
-	return ""
+	return nil
 }
 
-type GetTransactionStatusRequest struct {
+type ViewChangeResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
-
-	TransactionUuid string `protobuf:"bytes,1,opt,name=transaction_uuid,json=transactionUuid,proto3" json:"transaction_uuid,omitempty"`
 }
 
-func (x *GetTransactionStatusRequest) Reset() {
-	*x = GetTransactionStatusRequest{}
+func (x *ViewChangeResponse) Reset() {
+	*x = ViewChangeResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_maobft_proto_msgTypes[17]
+		mi := &file_maobft_proto_msgTypes[21]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *GetTransactionStatusRequest) String() string {
+func (x *ViewChangeResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetTransactionStatusRequest) ProtoMessage() {}
+func (*ViewChangeResponse) ProtoMessage() {}
 
-func (x *GetTransactionStatusRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_maobft_proto_msgTypes[17]
+func (x *ViewChangeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_maobft_proto_msgTypes[21]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1114,43 +1706,39 @@ func (x *GetTransactionStatusRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetTransactionStatusRequest.ProtoReflect.Descriptor instead.
-func (*GetTransactionStatusRequest) Descriptor() ([]byte, []int) {
-	return file_maobft_proto_rawDescGZIP(), []int{17}
-}
-
-func (x *GetTransactionStatusRequest) GetTransactionUuid() string {
-	if x != nil {
-		return x.TransactionUuid
-	}
-	return ""
+// Deprecated: Use ViewChangeResponse.ProtoReflect.Descriptor instead.
+func (*ViewChangeResponse) Descriptor() ([]byte, []int) {
+	return file_maobft_proto_rawDescGZIP(), []int{21}
 }
 
-type GetTransactionStatusResponse struct {
+// EquivocationEvidence proves that a leader sent PREPAREs of two different Merkle roots on the same prev_hash in one
+// epoch. Both PREPAREs carry the leader's signature, so anyone can verify it.
+type EquivocationEvidence struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Status TransactionStatus `protobuf:"varint,1,opt,name=status,proto3,enum=pb.TransactionStatus" json:"status,omitempty"`
+	First  *Payload `protobuf:"bytes,1,opt,name=first,proto3" json:"first,omitempty"`
+	Second *Payload `protobuf:"bytes,2,opt,name=second,proto3" json:"second,omitempty"`
 }
 
-func (x *GetTransactionStatusResponse) Reset() {
-	*x = GetTransactionStatusResponse{}
+func (x *EquivocationEvidence) Reset() {
+	*x = EquivocationEvidence{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_maobft_proto_msgTypes[18]
+		mi := &file_maobft_proto_msgTypes[22]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *GetTransactionStatusResponse) String() string {
+func (x *EquivocationEvidence) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetTransactionStatusResponse) ProtoMessage() {}
+func (*EquivocationEvidence) ProtoMessage() {}
 
-func (x *GetTransactionStatusResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_maobft_proto_msgTypes[18]
+func (x *EquivocationEvidence) ProtoReflect() protoreflect.Message {
+	mi := &file_maobft_proto_msgTypes[22]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1161,16 +1749,603 @@ func (x *GetTransactionStatusResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetTransactionStatusResponse.ProtoReflect.Descriptor instead.
-func (*GetTransactionStatusResponse) Descriptor() ([]byte, []int) {
-	return file_maobft_proto_rawDescGZIP(), []int{18}
+// Deprecated: Use EquivocationEvidence.ProtoReflect.Descriptor instead.
+func (*EquivocationEvidence) Descriptor() ([]byte, []int) {
+	return file_maobft_proto_rawDescGZIP(), []int{22}
 }
 
-func (x *GetTransactionStatusResponse) GetStatus() TransactionStatus {
+func (x *EquivocationEvidence) GetFirst() *Payload {
 	if x != nil {
-		return x.Status
+		return x.First
 	}
-	return TransactionStatus_UNKNOWN
+	return nil
+}
+
+func (x *EquivocationEvidence) GetSecond() *Payload {
+	if x != nil {
+		return x.Second
+	}
+	return nil
+}
+
+type ReportEquivocationResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ReportEquivocationResponse) Reset() {
+	*x = ReportEquivocationResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_maobft_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReportEquivocationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportEquivocationResponse) ProtoMessage() {}
+
+func (x *ReportEquivocationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_maobft_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportEquivocationResponse.ProtoReflect.Descriptor instead.
+func (*ReportEquivocationResponse) Descriptor() ([]byte, []int) {
+	return file_maobft_proto_rawDescGZIP(), []int{23}
+}
+
+type GetEquivocationEvidenceRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetEquivocationEvidenceRequest) Reset() {
+	*x = GetEquivocationEvidenceRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_maobft_proto_msgTypes[24]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetEquivocationEvidenceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEquivocationEvidenceRequest) ProtoMessage() {}
+
+func (x *GetEquivocationEvidenceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_maobft_proto_msgTypes[24]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEquivocationEvidenceRequest.ProtoReflect.Descriptor instead.
+func (*GetEquivocationEvidenceRequest) Descriptor() ([]byte, []int) {
+	return file_maobft_proto_rawDescGZIP(), []int{24}
+}
+
+type GetEquivocationEvidenceResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Evidence against each leader this node has convicted.
+	Evidence []*EquivocationEvidence `protobuf:"bytes,1,rep,name=evidence,proto3" json:"evidence,omitempty"`
+}
+
+func (x *GetEquivocationEvidenceResponse) Reset() {
+	*x = GetEquivocationEvidenceResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_maobft_proto_msgTypes[25]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetEquivocationEvidenceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEquivocationEvidenceResponse) ProtoMessage() {}
+
+func (x *GetEquivocationEvidenceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_maobft_proto_msgTypes[25]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEquivocationEvidenceResponse.ProtoReflect.Descriptor instead.
+func (*GetEquivocationEvidenceResponse) Descriptor() ([]byte, []int) {
+	return file_maobft_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *GetEquivocationEvidenceResponse) GetEvidence() []*EquivocationEvidence {
+	if x != nil {
+		return x.Evidence
+	}
+	return nil
+}
+
+// AgreementMessage belongs to the binary agreement on whether the proposal of instance.broadcaster is in the common
+// subset of epoch instance.epoch.
+type AgreementMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Instance *InstanceId   `protobuf:"bytes,1,opt,name=instance,proto3" json:"instance,omitempty"`
+	Type     AgreementType `protobuf:"varint,2,opt,name=type,proto3,enum=pb.AgreementType" json:"type,omitempty"`
+	Round    int64         `protobuf:"varint,3,opt,name=round,proto3" json:"round,omitempty"`
+	// Value of BVAL, AUX and TERM.
+	Value bool `protobuf:"varint,4,opt,name=value,proto3" json:"value,omitempty"`
+	// Values of CONF.
+	Values []bool `protobuf:"varint,5,rep,packed,name=values,proto3" json:"values,omitempty"`
+	// Signature of sender over the Envelope of this message.
+	Signature []byte `protobuf:"bytes,6,opt,name=signature,proto3" json:"signature,omitempty"`
+	// Threshold signature share of COIN.
+	Share []byte `protobuf:"bytes,7,opt,name=share,proto3" json:"share,omitempty"`
+}
+
+func (x *AgreementMessage) Reset() {
+	*x = AgreementMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_maobft_proto_msgTypes[26]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AgreementMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgreementMessage) ProtoMessage() {}
+
+func (x *AgreementMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_maobft_proto_msgTypes[26]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgreementMessage.ProtoReflect.Descriptor instead.
+func (*AgreementMessage) Descriptor() ([]byte, []int) {
+	return file_maobft_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *AgreementMessage) GetInstance() *InstanceId {
+	if x != nil {
+		return x.Instance
+	}
+	return nil
+}
+
+func (x *AgreementMessage) GetType() AgreementType {
+	if x != nil {
+		return x.Type
+	}
+	return AgreementType_AT_UNKNOWN
+}
+
+func (x *AgreementMessage) GetRound() int64 {
+	if x != nil {
+		return x.Round
+	}
+	return 0
+}
+
+func (x *AgreementMessage) GetValue() bool {
+	if x != nil {
+		return x.Value
+	}
+	return false
+}
+
+func (x *AgreementMessage) GetValues() []bool {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+func (x *AgreementMessage) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *AgreementMessage) GetShare() []byte {
+	if x != nil {
+		return x.Share
+	}
+	return nil
+}
+
+type AgreementResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *AgreementResponse) Reset() {
+	*x = AgreementResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_maobft_proto_msgTypes[27]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AgreementResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgreementResponse) ProtoMessage() {}
+
+func (x *AgreementResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_maobft_proto_msgTypes[27]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgreementResponse.ProtoReflect.Descriptor instead.
+func (*AgreementResponse) Descriptor() ([]byte, []int) {
+	return file_maobft_proto_rawDescGZIP(), []int{27}
+}
+
+// ConsensusMessage is a frame of a Consensus stream, it carries a message of the caller or an acknowledgement of the
+// callee.
+type ConsensusMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Sequence numbers messages of a stream from 1 in the order they're sent, it's 0 in an acknowledgement.
+	Sequence uint64 `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// Ack acknowledges that the callee handled all messages of the stream up to this sequence.
+	Ack uint64 `protobuf:"varint,2,opt,name=ack,proto3" json:"ack,omitempty"`
+	// Types that are assignable to Body:
+	//	*ConsensusMessage_Prepare
+	//	*ConsensusMessage_Echo
+	//	*ConsensusMessage_Ready
+	//	*ConsensusMessage_Agreement
+	Body isConsensusMessage_Body `protobuf_oneof:"body"`
+}
+
+func (x *ConsensusMessage) Reset() {
+	*x = ConsensusMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_maobft_proto_msgTypes[28]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ConsensusMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConsensusMessage) ProtoMessage() {}
+
+func (x *ConsensusMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_maobft_proto_msgTypes[28]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConsensusMessage.ProtoReflect.Descriptor instead.
+func (*ConsensusMessage) Descriptor() ([]byte, []int) {
+	return file_maobft_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ConsensusMessage) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *ConsensusMessage) GetAck() uint64 {
+	if x != nil {
+		return x.Ack
+	}
+	return 0
+}
+
+func (m *ConsensusMessage) GetBody() isConsensusMessage_Body {
+	if m != nil {
+		return m.Body
+	}
+	return nil
+}
+
+func (x *ConsensusMessage) GetPrepare() *Payload {
+	if x, ok := x.GetBody().(*ConsensusMessage_Prepare); ok {
+		return x.Prepare
+	}
+	return nil
+}
+
+func (x *ConsensusMessage) GetEcho() *Payload {
+	if x, ok := x.GetBody().(*ConsensusMessage_Echo); ok {
+		return x.Echo
+	}
+	return nil
+}
+
+func (x *ConsensusMessage) GetReady() *ReadyRequest {
+	if x, ok := x.GetBody().(*ConsensusMessage_Ready); ok {
+		return x.Ready
+	}
+	return nil
+}
+
+func (x *ConsensusMessage) GetAgreement() *AgreementMessage {
+	if x, ok := x.GetBody().(*ConsensusMessage_Agreement); ok {
+		return x.Agreement
+	}
+	return nil
+}
+
+type isConsensusMessage_Body interface {
+	isConsensusMessage_Body()
+}
+
+type ConsensusMessage_Prepare struct {
+	Prepare *Payload `protobuf:"bytes,3,opt,name=prepare,proto3,oneof"`
+}
+
+type ConsensusMessage_Echo struct {
+	Echo *Payload `protobuf:"bytes,4,opt,name=echo,proto3,oneof"`
+}
+
+type ConsensusMessage_Ready struct {
+	Ready *ReadyRequest `protobuf:"bytes,5,opt,name=ready,proto3,oneof"`
+}
+
+type ConsensusMessage_Agreement struct {
+	Agreement *AgreementMessage `protobuf:"bytes,6,opt,name=agreement,proto3,oneof"`
+}
+
+func (*ConsensusMessage_Prepare) isConsensusMessage_Body() {}
+
+func (*ConsensusMessage_Echo) isConsensusMessage_Body() {}
+
+func (*ConsensusMessage_Ready) isConsensusMessage_Body() {}
+
+func (*ConsensusMessage_Agreement) isConsensusMessage_Body() {}
+
+type ProposeTransactionRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Transaction *Transaction `protobuf:"bytes,1,opt,name=transaction,proto3" json:"transaction,omitempty"` // TODO(chenweilunster): Implement client signature authentication.
+}
+
+func (x *ProposeTransactionRequest) Reset() {
+	*x = ProposeTransactionRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_maobft_proto_msgTypes[29]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ProposeTransactionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProposeTransactionRequest) ProtoMessage() {}
+
+func (x *ProposeTransactionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_maobft_proto_msgTypes[29]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProposeTransactionRequest.ProtoReflect.Descriptor instead.
+func (*ProposeTransactionRequest) Descriptor() ([]byte, []int) {
+	return file_maobft_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ProposeTransactionRequest) GetTransaction() *Transaction {
+	if x != nil {
+		return x.Transaction
+	}
+	return nil
+}
+
+type ProposeTransactionResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Server assigned uuid for this transaction. This transaction is used to get transaction status.
+	TransactionUuid string `protobuf:"bytes,1,opt,name=transaction_uuid,json=transactionUuid,proto3" json:"transaction_uuid,omitempty"`
+}
+
+func (x *ProposeTransactionResponse) Reset() {
+	*x = ProposeTransactionResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_maobft_proto_msgTypes[30]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ProposeTransactionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProposeTransactionResponse) ProtoMessage() {}
+
+func (x *ProposeTransactionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_maobft_proto_msgTypes[30]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProposeTransactionResponse.ProtoReflect.Descriptor instead.
+func (*ProposeTransactionResponse) Descriptor() ([]byte, []int) {
+	return file_maobft_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ProposeTransactionResponse) GetTransactionUuid() string {
+	if x != nil {
+		return x.TransactionUuid
+	}
+	return ""
+}
+
+type GetTransactionStatusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TransactionUuid string `protobuf:"bytes,1,opt,name=transaction_uuid,json=transactionUuid,proto3" json:"transaction_uuid,omitempty"`
+}
+
+func (x *GetTransactionStatusRequest) Reset() {
+	*x = GetTransactionStatusRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_maobft_proto_msgTypes[31]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetTransactionStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTransactionStatusRequest) ProtoMessage() {}
+
+func (x *GetTransactionStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_maobft_proto_msgTypes[31]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTransactionStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetTransactionStatusRequest) Descriptor() ([]byte, []int) {
+	return file_maobft_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *GetTransactionStatusRequest) GetTransactionUuid() string {
+	if x != nil {
+		return x.TransactionUuid
+	}
+	return ""
+}
+
+type GetTransactionStatusResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status TransactionStatus `protobuf:"varint,1,opt,name=status,proto3,enum=pb.TransactionStatus" json:"status,omitempty"`
+}
+
+func (x *GetTransactionStatusResponse) Reset() {
+	*x = GetTransactionStatusResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_maobft_proto_msgTypes[32]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetTransactionStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTransactionStatusResponse) ProtoMessage() {}
+
+func (x *GetTransactionStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_maobft_proto_msgTypes[32]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTransactionStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetTransactionStatusResponse) Descriptor() ([]byte, []int) {
+	return file_maobft_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *GetTransactionStatusResponse) GetStatus() TransactionStatus {
+	if x != nil {
+		return x.Status
+	}
+	return TransactionStatus_UNKNOWN
 }
 
 var File_maobft_proto protoreflect.FileDescriptor
@@ -1188,57 +2363,117 @@ var file_maobft_proto_rawDesc = []byte{
 	0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x61, 0x72, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52,
 	0x09, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x61, 0x72, 0x79, 0x12, 0x22, 0x0a, 0x0c, 0x69, 0x73,
 	0x52, 0x69, 0x67, 0x68, 0x74, 0x43, 0x68, 0x69, 0x6c, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08,
-	0x52, 0x0c, 0x69, 0x73, 0x52, 0x69, 0x67, 0x68, 0x74, 0x43, 0x68, 0x69, 0x6c, 0x64, 0x22, 0x6e,
-	0x0a, 0x07, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x32, 0x0a, 0x0c, 0x6d, 0x65, 0x72,
-	0x6b, 0x6c, 0x65, 0x5f, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x0f, 0x2e, 0x70, 0x62, 0x2e, 0x4d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x50, 0x72, 0x6f, 0x6f, 0x66,
-	0x52, 0x0b, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x1b, 0x0a,
-	0x09, 0x70, 0x72, 0x65, 0x76, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c,
-	0x52, 0x08, 0x70, 0x72, 0x65, 0x76, 0x48, 0x61, 0x73, 0x68, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61,
-	0x74, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x52,
-	0x0a, 0x09, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x44, 0x75, 0x6d, 0x70, 0x12, 0x1f, 0x0a, 0x05, 0x62,
-	0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x70, 0x62, 0x2e,
-	0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x24, 0x0a, 0x05,
-	0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0e, 0x2e, 0x70, 0x62,
-	0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x05, 0x73, 0x74, 0x61,
-	0x74, 0x65, 0x22, 0x4e, 0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x2a, 0x0a, 0x07, 0x63,
-	0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70,
-	0x62, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x52, 0x07,
-	0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x75, 0x72, 0x5f, 0x68,
-	0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x63, 0x75, 0x72, 0x48, 0x61,
-	0x73, 0x68, 0x22, 0x4e, 0x0a, 0x0c, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x43, 0x6f, 0x6e, 0x74, 0x65,
-	0x6e, 0x74, 0x12, 0x21, 0x0a, 0x03, 0x74, 0x78, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
-	0x0f, 0x2e, 0x70, 0x62, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
-	0x52, 0x03, 0x74, 0x78, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x72, 0x65, 0x76, 0x5f, 0x68, 0x61,
+	0x52, 0x0c, 0x69, 0x73, 0x52, 0x69, 0x67, 0x68, 0x74, 0x43, 0x68, 0x69, 0x6c, 0x64, 0x22, 0x60,
+	0x0a, 0x0a, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x12, 0x20, 0x0a, 0x0b,
+	0x62, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0b, 0x62, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x65, 0x72, 0x12, 0x14,
+	0x0a, 0x05, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x65,
+	0x70, 0x6f, 0x63, 0x68, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65,
+	0x22, 0xdf, 0x01, 0x0a, 0x07, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x32, 0x0a, 0x0c,
+	0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x5f, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x62, 0x2e, 0x4d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x50, 0x72,
+	0x6f, 0x6f, 0x66, 0x52, 0x0b, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x50, 0x72, 0x6f, 0x6f, 0x66,
+	0x12, 0x1b, 0x0a, 0x09, 0x70, 0x72, 0x65, 0x76, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x08, 0x70, 0x72, 0x65, 0x76, 0x48, 0x61, 0x73, 0x68, 0x12, 0x12, 0x0a,
+	0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74,
+	0x61, 0x12, 0x2a, 0x0a, 0x08, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x70, 0x62, 0x2e, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63,
+	0x65, 0x49, 0x64, 0x52, 0x08, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x1c, 0x0a,
+	0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x25, 0x0a, 0x04, 0x6d,
+	0x6f, 0x64, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x70, 0x62, 0x2e, 0x42,
+	0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x04, 0x6d, 0x6f,
+	0x64, 0x65, 0x22, 0xd5, 0x02, 0x0a, 0x08, 0x45, 0x6e, 0x76, 0x65, 0x6c, 0x6f, 0x70, 0x65, 0x12,
+	0x23, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0f, 0x2e,
+	0x70, 0x62, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04,
+	0x74, 0x79, 0x70, 0x65, 0x12, 0x2a, 0x0a, 0x08, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x70, 0x62, 0x2e, 0x49, 0x6e, 0x73, 0x74,
+	0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x52, 0x08, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65,
+	0x12, 0x1f, 0x0a, 0x0b, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x5f, 0x72, 0x6f, 0x6f, 0x74, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x52, 0x6f, 0x6f,
+	0x74, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x72, 0x65, 0x76, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x70, 0x72, 0x65, 0x76, 0x48, 0x61, 0x73, 0x68, 0x12, 0x32,
+	0x0a, 0x0c, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x5f, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x62, 0x2e, 0x4d, 0x65, 0x72, 0x6b, 0x6c, 0x65,
+	0x50, 0x72, 0x6f, 0x6f, 0x66, 0x52, 0x0b, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x50, 0x72, 0x6f,
+	0x6f, 0x66, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65,
+	0x72, 0x5f, 0x69, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6c, 0x75, 0x73,
+	0x74, 0x65, 0x72, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x73, 0x18, 0x09, 0x20, 0x03, 0x28, 0x08, 0x52, 0x06, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x73, 0x12, 0x25, 0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x11, 0x2e, 0x70, 0x62, 0x2e, 0x42, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74,
+	0x4d, 0x6f, 0x64, 0x65, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x22, 0x52, 0x0a, 0x09, 0x42, 0x6c,
+	0x6f, 0x63, 0x6b, 0x44, 0x75, 0x6d, 0x70, 0x12, 0x1f, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x70, 0x62, 0x2e, 0x42, 0x6c, 0x6f, 0x63,
+	0x6b, 0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x24, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0e, 0x2e, 0x70, 0x62, 0x2e, 0x42, 0x6c, 0x6f,
+	0x63, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x22, 0x87,
+	0x01, 0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74,
+	0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70, 0x62, 0x2e, 0x42,
+	0x6c, 0x6f, 0x63, 0x6b, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x52, 0x07, 0x63, 0x6f, 0x6e,
+	0x74, 0x65, 0x6e, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x75, 0x72, 0x5f, 0x68, 0x61, 0x73, 0x68,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x63, 0x75, 0x72, 0x48, 0x61, 0x73, 0x68, 0x12,
+	0x37, 0x0a, 0x0b, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x62, 0x2e, 0x51, 0x75, 0x6f, 0x72, 0x75, 0x6d,
+	0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x0b, 0x63, 0x65, 0x72,
+	0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x22, 0x5a, 0x0a, 0x09, 0x52, 0x65, 0x61, 0x64,
+	0x79, 0x56, 0x6f, 0x74, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x65, 0x65, 0x72, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x65, 0x65, 0x72, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x72, 0x65,
+	0x76, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x70, 0x72,
+	0x65, 0x76, 0x48, 0x61, 0x73, 0x68, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74,
+	0x75, 0x72, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61,
+	0x74, 0x75, 0x72, 0x65, 0x22, 0xb0, 0x01, 0x0a, 0x11, 0x51, 0x75, 0x6f, 0x72, 0x75, 0x6d, 0x43,
+	0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x2a, 0x0a, 0x08, 0x69, 0x6e,
+	0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x70,
+	0x62, 0x2e, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x52, 0x08, 0x69, 0x6e,
+	0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65,
+	0x5f, 0x72, 0x6f, 0x6f, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x6d, 0x65, 0x72,
+	0x6b, 0x6c, 0x65, 0x52, 0x6f, 0x6f, 0x74, 0x12, 0x27, 0x0a, 0x07, 0x72, 0x65, 0x61, 0x64, 0x69,
+	0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65,
+	0x61, 0x64, 0x79, 0x56, 0x6f, 0x74, 0x65, 0x52, 0x07, 0x72, 0x65, 0x61, 0x64, 0x69, 0x65, 0x73,
+	0x12, 0x25, 0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11,
+	0x2e, 0x70, 0x62, 0x2e, 0x42, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x4d, 0x6f, 0x64,
+	0x65, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x22, 0x4e, 0x0a, 0x0c, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x21, 0x0a, 0x03, 0x74, 0x78, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x62, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x03, 0x74, 0x78, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x72,
+	0x65, 0x76, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x70,
+	0x72, 0x65, 0x76, 0x48, 0x61, 0x73, 0x68, 0x22, 0x53, 0x0a, 0x0b, 0x57, 0x69, 0x72, 0x65, 0x4d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x66, 0x72, 0x6f, 0x6d, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x66, 0x72, 0x6f, 0x6d, 0x49, 0x64, 0x12,
+	0x13, 0x0a, 0x05, 0x74, 0x6f, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x74, 0x6f, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x47, 0x0a, 0x0e,
+	0x44, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1d,
+	0x0a, 0x0a, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x09, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x16, 0x0a,
+	0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x61,
+	0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0xa8, 0x01, 0x0a, 0x0b, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x29, 0x0a, 0x10, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x75, 0x75, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x55, 0x75, 0x69, 0x64,
+	0x12, 0x2c, 0x0a, 0x08, 0x77, 0x69, 0x72, 0x65, 0x5f, 0x6d, 0x73, 0x67, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x62, 0x2e, 0x57, 0x69, 0x72, 0x65, 0x4d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x48, 0x00, 0x52, 0x07, 0x77, 0x69, 0x72, 0x65, 0x4d, 0x73, 0x67, 0x12, 0x35,
+	0x0a, 0x0b, 0x64, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x5f, 0x6d, 0x73, 0x67, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x62, 0x2e, 0x44, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x48, 0x00, 0x52, 0x0a, 0x64, 0x65, 0x70, 0x6f, 0x73,
+	0x69, 0x74, 0x4d, 0x73, 0x67, 0x42, 0x09, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x22, 0x11, 0x0a, 0x0f, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x22, 0x0e, 0x0a, 0x0c, 0x45, 0x63, 0x68, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x22, 0x96, 0x01, 0x0a, 0x0c, 0x52, 0x65, 0x61, 0x64, 0x79, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x5f, 0x72,
+	0x6f, 0x6f, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x6d, 0x65, 0x72, 0x6b, 0x6c,
+	0x65, 0x52, 0x6f, 0x6f, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x72, 0x65, 0x76, 0x5f, 0x68, 0x61,
 	0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x70, 0x72, 0x65, 0x76, 0x48, 0x61,
-	0x73, 0x68, 0x22, 0x53, 0x0a, 0x0b, 0x57, 0x69, 0x72, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
-	0x65, 0x12, 0x17, 0x0a, 0x07, 0x66, 0x72, 0x6f, 0x6d, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x06, 0x66, 0x72, 0x6f, 0x6d, 0x49, 0x64, 0x12, 0x13, 0x0a, 0x05, 0x74, 0x6f,
-	0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x6f, 0x49, 0x64, 0x12,
-	0x16, 0x0a, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52,
-	0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x47, 0x0a, 0x0e, 0x44, 0x65, 0x70, 0x6f, 0x73,
-	0x69, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x61, 0x63, 0x63,
-	0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x61,
-	0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x6d, 0x6f, 0x75,
-	0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74,
-	0x22, 0xa8, 0x01, 0x0a, 0x0b, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
-	0x12, 0x29, 0x0a, 0x10, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f,
-	0x75, 0x75, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x74, 0x72, 0x61, 0x6e,
-	0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x55, 0x75, 0x69, 0x64, 0x12, 0x2c, 0x0a, 0x08, 0x77,
-	0x69, 0x72, 0x65, 0x5f, 0x6d, 0x73, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e,
-	0x70, 0x62, 0x2e, 0x57, 0x69, 0x72, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x48, 0x00,
-	0x52, 0x07, 0x77, 0x69, 0x72, 0x65, 0x4d, 0x73, 0x67, 0x12, 0x35, 0x0a, 0x0b, 0x64, 0x65, 0x70,
-	0x6f, 0x73, 0x69, 0x74, 0x5f, 0x6d, 0x73, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12,
-	0x2e, 0x70, 0x62, 0x2e, 0x44, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61,
-	0x67, 0x65, 0x48, 0x00, 0x52, 0x0a, 0x64, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x4d, 0x73, 0x67,
-	0x42, 0x09, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x11, 0x0a, 0x0f, 0x50,
-	0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x0e,
-	0x0a, 0x0c, 0x45, 0x63, 0x68, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x4c,
-	0x0a, 0x0c, 0x52, 0x65, 0x61, 0x64, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f,
-	0x0a, 0x0b, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x5f, 0x72, 0x6f, 0x6f, 0x74, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x0c, 0x52, 0x0a, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x52, 0x6f, 0x6f, 0x74, 0x12,
-	0x1b, 0x0a, 0x09, 0x70, 0x72, 0x65, 0x76, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x0c, 0x52, 0x08, 0x70, 0x72, 0x65, 0x76, 0x48, 0x61, 0x73, 0x68, 0x22, 0x0f, 0x0a, 0x0d,
+	0x73, 0x68, 0x12, 0x2a, 0x0a, 0x08, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x70, 0x62, 0x2e, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e,
+	0x63, 0x65, 0x49, 0x64, 0x52, 0x08, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x1c,
+	0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x22, 0x0f, 0x0a, 0x0d,
 	0x52, 0x65, 0x61, 0x64, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x51, 0x0a,
 	0x0b, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1e, 0x0a, 0x0a,
 	0x6c, 0x61, 0x73, 0x74, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c,
@@ -1247,64 +2482,168 @@ var file_maobft_proto_rawDesc = []byte{
 	0x28, 0x0c, 0x52, 0x0c, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x53, 0x74, 0x61, 0x67, 0x65, 0x64,
 	0x22, 0x2a, 0x0a, 0x0c, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
 	0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x01, 0x20, 0x03,
-	0x28, 0x0c, 0x52, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x4e, 0x0a, 0x19,
-	0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69,
-	0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x31, 0x0a, 0x0b, 0x74, 0x72, 0x61,
-	0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f,
-	0x2e, 0x70, 0x62, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52,
-	0x0b, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x47, 0x0a, 0x1a,
-	0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69,
-	0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x29, 0x0a, 0x10, 0x74, 0x72,
-	0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x75, 0x75, 0x69, 0x64, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f,
-	0x6e, 0x55, 0x75, 0x69, 0x64, 0x22, 0x48, 0x0a, 0x1b, 0x47, 0x65, 0x74, 0x54, 0x72, 0x61, 0x6e,
-	0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x12, 0x29, 0x0a, 0x10, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74,
-	0x69, 0x6f, 0x6e, 0x5f, 0x75, 0x75, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f,
-	0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x55, 0x75, 0x69, 0x64, 0x22,
-	0x4d, 0x0a, 0x1c, 0x47, 0x65, 0x74, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f,
-	0x6e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
-	0x2d, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32,
-	0x15, 0x2e, 0x70, 0x62, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
-	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2a, 0x4d,
-	0x0a, 0x0a, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x0e, 0x0a, 0x0a,
-	0x42, 0x53, 0x5f, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x0e, 0x0a, 0x0a,
-	0x42, 0x53, 0x5f, 0x50, 0x45, 0x4e, 0x44, 0x49, 0x4e, 0x47, 0x10, 0x01, 0x12, 0x0d, 0x0a, 0x09,
-	0x42, 0x53, 0x5f, 0x53, 0x54, 0x41, 0x47, 0x45, 0x44, 0x10, 0x02, 0x12, 0x10, 0x0a, 0x0c, 0x42,
-	0x53, 0x5f, 0x43, 0x4f, 0x4d, 0x4d, 0x49, 0x54, 0x54, 0x45, 0x44, 0x10, 0x03, 0x2a, 0x56, 0x0a,
-	0x11, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74,
-	0x75, 0x73, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12,
-	0x0c, 0x0a, 0x08, 0x52, 0x45, 0x4a, 0x45, 0x43, 0x54, 0x45, 0x44, 0x10, 0x01, 0x12, 0x0b, 0x0a,
-	0x07, 0x50, 0x45, 0x4e, 0x44, 0x49, 0x4e, 0x47, 0x10, 0x02, 0x12, 0x0a, 0x0a, 0x06, 0x53, 0x54,
-	0x41, 0x47, 0x45, 0x44, 0x10, 0x03, 0x12, 0x0d, 0x0a, 0x09, 0x43, 0x4f, 0x4d, 0x4d, 0x49, 0x54,
-	0x54, 0x45, 0x44, 0x10, 0x04, 0x32, 0x38, 0x0a, 0x07, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65,
-	0x12, 0x2d, 0x0a, 0x07, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x12, 0x0b, 0x2e, 0x70, 0x62,
-	0x2e, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x1a, 0x13, 0x2e, 0x70, 0x62, 0x2e, 0x50, 0x72,
-	0x65, 0x70, 0x61, 0x72, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x32,
-	0x2f, 0x0a, 0x04, 0x45, 0x63, 0x68, 0x6f, 0x12, 0x27, 0x0a, 0x04, 0x45, 0x63, 0x68, 0x6f, 0x12,
-	0x0b, 0x2e, 0x70, 0x62, 0x2e, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x1a, 0x10, 0x2e, 0x70,
-	0x62, 0x2e, 0x45, 0x63, 0x68, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00,
-	0x32, 0x37, 0x0a, 0x05, 0x52, 0x65, 0x61, 0x64, 0x79, 0x12, 0x2e, 0x0a, 0x05, 0x52, 0x65, 0x61,
-	0x64, 0x79, 0x12, 0x10, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x61, 0x64, 0x79, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x61, 0x64, 0x79, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x32, 0x33, 0x0a, 0x04, 0x53, 0x79, 0x6e,
-	0x63, 0x12, 0x2b, 0x0a, 0x04, 0x53, 0x79, 0x6e, 0x63, 0x12, 0x0f, 0x2e, 0x70, 0x62, 0x2e, 0x53,
-	0x79, 0x6e, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e, 0x70, 0x62, 0x2e,
-	0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x32, 0xc8,
-	0x01, 0x0a, 0x12, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x65,
-	0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x55, 0x0a, 0x12, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65,
-	0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1d, 0x2e, 0x70, 0x62,
-	0x2e, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74,
-	0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x70, 0x62, 0x2e,
-	0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69,
-	0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x5b, 0x0a, 0x14,
+	0x28, 0x0c, 0x52, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x8e, 0x01, 0x0a,
+	0x0e, 0x56, 0x69, 0x65, 0x77, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x56, 0x6f, 0x74, 0x65, 0x12,
+	0x19, 0x0a, 0x08, 0x6e, 0x65, 0x77, 0x5f, 0x76, 0x69, 0x65, 0x77, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x07, 0x6e, 0x65, 0x77, 0x56, 0x69, 0x65, 0x77, 0x12, 0x2a, 0x0a, 0x0b, 0x6c, 0x61,
+	0x73, 0x74, 0x5f, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x09, 0x2e, 0x70, 0x62, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x0a, 0x6c, 0x61, 0x73, 0x74,
+	0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x1d,
+	0x0a, 0x0a, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x09, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x49, 0x64, 0x22, 0x27, 0x0a,
+	0x11, 0x56, 0x69, 0x65, 0x77, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x76, 0x6f, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x04, 0x76, 0x6f, 0x74, 0x65, 0x22, 0x14, 0x0a, 0x12, 0x56, 0x69, 0x65, 0x77, 0x43, 0x68,
+	0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x5e, 0x0a, 0x14,
+	0x45, 0x71, 0x75, 0x69, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x76, 0x69, 0x64,
+	0x65, 0x6e, 0x63, 0x65, 0x12, 0x21, 0x0a, 0x05, 0x66, 0x69, 0x72, 0x73, 0x74, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x70, 0x62, 0x2e, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64,
+	0x52, 0x05, 0x66, 0x69, 0x72, 0x73, 0x74, 0x12, 0x23, 0x0a, 0x06, 0x73, 0x65, 0x63, 0x6f, 0x6e,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x70, 0x62, 0x2e, 0x50, 0x61, 0x79,
+	0x6c, 0x6f, 0x61, 0x64, 0x52, 0x06, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x22, 0x1c, 0x0a, 0x1a,
+	0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x45, 0x71, 0x75, 0x69, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x20, 0x0a, 0x1e, 0x47, 0x65,
+	0x74, 0x45, 0x71, 0x75, 0x69, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x76, 0x69,
+	0x64, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x57, 0x0a, 0x1f,
+	0x47, 0x65, 0x74, 0x45, 0x71, 0x75, 0x69, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45,
+	0x76, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x34, 0x0a, 0x08, 0x65, 0x76, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x18, 0x2e, 0x70, 0x62, 0x2e, 0x45, 0x71, 0x75, 0x69, 0x76, 0x6f, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x45, 0x76, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x08, 0x65, 0x76, 0x69,
+	0x64, 0x65, 0x6e, 0x63, 0x65, 0x22, 0xdd, 0x01, 0x0a, 0x10, 0x41, 0x67, 0x72, 0x65, 0x65, 0x6d,
+	0x65, 0x6e, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x2a, 0x0a, 0x08, 0x69, 0x6e,
+	0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x70,
+	0x62, 0x2e, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x52, 0x08, 0x69, 0x6e,
+	0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x25, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x70, 0x62, 0x2e, 0x41, 0x67, 0x72, 0x65, 0x65, 0x6d,
+	0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x14, 0x0a,
+	0x05, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x72, 0x6f,
+	0x75, 0x6e, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x08, 0x52, 0x06, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x73, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12,
+	0x14, 0x0a, 0x05, 0x73, 0x68, 0x61, 0x72, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05,
+	0x73, 0x68, 0x61, 0x72, 0x65, 0x22, 0x13, 0x0a, 0x11, 0x41, 0x67, 0x72, 0x65, 0x65, 0x6d, 0x65,
+	0x6e, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0xf4, 0x01, 0x0a, 0x10, 0x43,
+	0x6f, 0x6e, 0x73, 0x65, 0x6e, 0x73, 0x75, 0x73, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12,
+	0x1a, 0x0a, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x61,
+	0x63, 0x6b, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x61, 0x63, 0x6b, 0x12, 0x27, 0x0a,
+	0x07, 0x70, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b,
+	0x2e, 0x70, 0x62, 0x2e, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x48, 0x00, 0x52, 0x07, 0x70,
+	0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x12, 0x21, 0x0a, 0x04, 0x65, 0x63, 0x68, 0x6f, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x70, 0x62, 0x2e, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61,
+	0x64, 0x48, 0x00, 0x52, 0x04, 0x65, 0x63, 0x68, 0x6f, 0x12, 0x28, 0x0a, 0x05, 0x72, 0x65, 0x61,
+	0x64, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65,
+	0x61, 0x64, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x05, 0x72, 0x65,
+	0x61, 0x64, 0x79, 0x12, 0x34, 0x0a, 0x09, 0x61, 0x67, 0x72, 0x65, 0x65, 0x6d, 0x65, 0x6e, 0x74,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x62, 0x2e, 0x41, 0x67, 0x72, 0x65,
+	0x65, 0x6d, 0x65, 0x6e, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x48, 0x00, 0x52, 0x09,
+	0x61, 0x67, 0x72, 0x65, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x42, 0x06, 0x0a, 0x04, 0x62, 0x6f, 0x64,
+	0x79, 0x22, 0x4e, 0x0a, 0x19, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x54, 0x72, 0x61, 0x6e,
+	0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x31,
+	0x0a, 0x0b, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x62, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0b, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x22, 0x47, 0x0a, 0x1a, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x54, 0x72, 0x61, 0x6e,
+	0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x29, 0x0a, 0x10, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x75,
+	0x75, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x74, 0x72, 0x61, 0x6e, 0x73,
+	0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x55, 0x75, 0x69, 0x64, 0x22, 0x48, 0x0a, 0x1b, 0x47, 0x65,
+	0x74, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x29, 0x0a, 0x10, 0x74, 0x72, 0x61,
+	0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x75, 0x75, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x55, 0x75, 0x69, 0x64, 0x22, 0x4d, 0x0a, 0x1c, 0x47, 0x65, 0x74, 0x54, 0x72, 0x61, 0x6e, 0x73,
+	0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2d, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x15, 0x2e, 0x70, 0x62, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x2a, 0x2f, 0x0a, 0x0d, 0x42, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74,
+	0x4d, 0x6f, 0x64, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x42, 0x4d, 0x5f, 0x41, 0x56, 0x49, 0x44, 0x10,
+	0x00, 0x12, 0x11, 0x0a, 0x0d, 0x42, 0x4d, 0x5f, 0x52, 0x45, 0x50, 0x4c, 0x49, 0x43, 0x41, 0x54,
+	0x45, 0x44, 0x10, 0x01, 0x2a, 0x88, 0x01, 0x0a, 0x0b, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x54, 0x79, 0x70, 0x65, 0x12, 0x0e, 0x0a, 0x0a, 0x4d, 0x54, 0x5f, 0x55, 0x4e, 0x4b, 0x4e, 0x4f,
+	0x57, 0x4e, 0x10, 0x00, 0x12, 0x0e, 0x0a, 0x0a, 0x4d, 0x54, 0x5f, 0x50, 0x52, 0x45, 0x50, 0x41,
+	0x52, 0x45, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x4d, 0x54, 0x5f, 0x45, 0x43, 0x48, 0x4f, 0x10,
+	0x02, 0x12, 0x0c, 0x0a, 0x08, 0x4d, 0x54, 0x5f, 0x52, 0x45, 0x41, 0x44, 0x59, 0x10, 0x03, 0x12,
+	0x0b, 0x0a, 0x07, 0x4d, 0x54, 0x5f, 0x42, 0x56, 0x41, 0x4c, 0x10, 0x04, 0x12, 0x0a, 0x0a, 0x06,
+	0x4d, 0x54, 0x5f, 0x41, 0x55, 0x58, 0x10, 0x05, 0x12, 0x0b, 0x0a, 0x07, 0x4d, 0x54, 0x5f, 0x43,
+	0x4f, 0x4e, 0x46, 0x10, 0x06, 0x12, 0x0b, 0x0a, 0x07, 0x4d, 0x54, 0x5f, 0x54, 0x45, 0x52, 0x4d,
+	0x10, 0x07, 0x12, 0x0b, 0x0a, 0x07, 0x4d, 0x54, 0x5f, 0x43, 0x4f, 0x49, 0x4e, 0x10, 0x08, 0x2a,
+	0x4d, 0x0a, 0x0a, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x0e, 0x0a,
+	0x0a, 0x42, 0x53, 0x5f, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x0e, 0x0a,
+	0x0a, 0x42, 0x53, 0x5f, 0x50, 0x45, 0x4e, 0x44, 0x49, 0x4e, 0x47, 0x10, 0x01, 0x12, 0x0d, 0x0a,
+	0x09, 0x42, 0x53, 0x5f, 0x53, 0x54, 0x41, 0x47, 0x45, 0x44, 0x10, 0x02, 0x12, 0x10, 0x0a, 0x0c,
+	0x42, 0x53, 0x5f, 0x43, 0x4f, 0x4d, 0x4d, 0x49, 0x54, 0x54, 0x45, 0x44, 0x10, 0x03, 0x2a, 0x5f,
+	0x0a, 0x0d, 0x41, 0x67, 0x72, 0x65, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12,
+	0x0e, 0x0a, 0x0a, 0x41, 0x54, 0x5f, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12,
+	0x0b, 0x0a, 0x07, 0x41, 0x54, 0x5f, 0x42, 0x56, 0x41, 0x4c, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06,
+	0x41, 0x54, 0x5f, 0x41, 0x55, 0x58, 0x10, 0x02, 0x12, 0x0b, 0x0a, 0x07, 0x41, 0x54, 0x5f, 0x54,
+	0x45, 0x52, 0x4d, 0x10, 0x03, 0x12, 0x0b, 0x0a, 0x07, 0x41, 0x54, 0x5f, 0x43, 0x4f, 0x4e, 0x46,
+	0x10, 0x04, 0x12, 0x0b, 0x0a, 0x07, 0x41, 0x54, 0x5f, 0x43, 0x4f, 0x49, 0x4e, 0x10, 0x05, 0x2a,
+	0x56, 0x0a, 0x11, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10,
+	0x00, 0x12, 0x0c, 0x0a, 0x08, 0x52, 0x45, 0x4a, 0x45, 0x43, 0x54, 0x45, 0x44, 0x10, 0x01, 0x12,
+	0x0b, 0x0a, 0x07, 0x50, 0x45, 0x4e, 0x44, 0x49, 0x4e, 0x47, 0x10, 0x02, 0x12, 0x0a, 0x0a, 0x06,
+	0x53, 0x54, 0x41, 0x47, 0x45, 0x44, 0x10, 0x03, 0x12, 0x0d, 0x0a, 0x09, 0x43, 0x4f, 0x4d, 0x4d,
+	0x49, 0x54, 0x54, 0x45, 0x44, 0x10, 0x04, 0x32, 0x38, 0x0a, 0x07, 0x50, 0x72, 0x65, 0x70, 0x61,
+	0x72, 0x65, 0x12, 0x2d, 0x0a, 0x07, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x12, 0x0b, 0x2e,
+	0x70, 0x62, 0x2e, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x1a, 0x13, 0x2e, 0x70, 0x62, 0x2e,
+	0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22,
+	0x00, 0x32, 0x2f, 0x0a, 0x04, 0x45, 0x63, 0x68, 0x6f, 0x12, 0x27, 0x0a, 0x04, 0x45, 0x63, 0x68,
+	0x6f, 0x12, 0x0b, 0x2e, 0x70, 0x62, 0x2e, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x1a, 0x10,
+	0x2e, 0x70, 0x62, 0x2e, 0x45, 0x63, 0x68, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x22, 0x00, 0x32, 0x37, 0x0a, 0x05, 0x52, 0x65, 0x61, 0x64, 0x79, 0x12, 0x2e, 0x0a, 0x05, 0x52,
+	0x65, 0x61, 0x64, 0x79, 0x12, 0x10, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x61, 0x64, 0x79, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x61, 0x64,
+	0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x32, 0x33, 0x0a, 0x04, 0x53,
+	0x79, 0x6e, 0x63, 0x12, 0x2b, 0x0a, 0x04, 0x53, 0x79, 0x6e, 0x63, 0x12, 0x0f, 0x2e, 0x70, 0x62,
+	0x2e, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e, 0x70,
+	0x62, 0x2e, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00,
+	0x32, 0x4b, 0x0a, 0x0a, 0x56, 0x69, 0x65, 0x77, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x3d,
+	0x0a, 0x0a, 0x56, 0x69, 0x65, 0x77, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x15, 0x2e, 0x70,
+	0x62, 0x2e, 0x56, 0x69, 0x65, 0x77, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x70, 0x62, 0x2e, 0x56, 0x69, 0x65, 0x77, 0x43, 0x68, 0x61,
+	0x6e, 0x67, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x32, 0x60, 0x0a,
+	0x0c, 0x45, 0x71, 0x75, 0x69, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x50, 0x0a,
+	0x12, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x45, 0x71, 0x75, 0x69, 0x76, 0x6f, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x18, 0x2e, 0x70, 0x62, 0x2e, 0x45, 0x71, 0x75, 0x69, 0x76, 0x6f, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x76, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x1a, 0x1e, 0x2e,
+	0x70, 0x62, 0x2e, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x45, 0x71, 0x75, 0x69, 0x76, 0x6f, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x32,
+	0x6d, 0x0a, 0x05, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x12, 0x64, 0x0a, 0x17, 0x47, 0x65, 0x74, 0x45,
+	0x71, 0x75, 0x69, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x76, 0x69, 0x64, 0x65,
+	0x6e, 0x63, 0x65, 0x12, 0x22, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x65, 0x74, 0x45, 0x71, 0x75, 0x69,
+	0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x76, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x23, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x65, 0x74,
+	0x45, 0x71, 0x75, 0x69, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x76, 0x69, 0x64,
+	0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x32, 0x47,
+	0x0a, 0x09, 0x41, 0x67, 0x72, 0x65, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x3a, 0x0a, 0x09, 0x41,
+	0x67, 0x72, 0x65, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x14, 0x2e, 0x70, 0x62, 0x2e, 0x41, 0x67,
+	0x72, 0x65, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x15,
+	0x2e, 0x70, 0x62, 0x2e, 0x41, 0x67, 0x72, 0x65, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x32, 0x4a, 0x0a, 0x09, 0x43, 0x6f, 0x6e, 0x73, 0x65,
+	0x6e, 0x73, 0x75, 0x73, 0x12, 0x3d, 0x0a, 0x09, 0x43, 0x6f, 0x6e, 0x73, 0x65, 0x6e, 0x73, 0x75,
+	0x73, 0x12, 0x14, 0x2e, 0x70, 0x62, 0x2e, 0x43, 0x6f, 0x6e, 0x73, 0x65, 0x6e, 0x73, 0x75, 0x73,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x14, 0x2e, 0x70, 0x62, 0x2e, 0x43, 0x6f, 0x6e,
+	0x73, 0x65, 0x6e, 0x73, 0x75, 0x73, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x00, 0x28,
+	0x01, 0x30, 0x01, 0x32, 0xc8, 0x01, 0x0a, 0x12, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x55, 0x0a, 0x12, 0x50, 0x72,
+	0x6f, 0x70, 0x6f, 0x73, 0x65, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x12, 0x1d, 0x2e, 0x70, 0x62, 0x2e, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x54, 0x72, 0x61,
+	0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x1e, 0x2e, 0x70, 0x62, 0x2e, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x54, 0x72, 0x61, 0x6e,
+	0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22,
+	0x00, 0x12, 0x5b, 0x0a, 0x14, 0x47, 0x65, 0x74, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1f, 0x2e, 0x70, 0x62, 0x2e, 0x47,
+	0x65, 0x74, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x70, 0x62, 0x2e,
 	0x47, 0x65, 0x74, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74,
-	0x61, 0x74, 0x75, 0x73, 0x12, 0x1f, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x65, 0x74, 0x54, 0x72, 0x61,
-	0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x65, 0x74, 0x54, 0x72,
-	0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x06, 0x5a, 0x04, 0x2e, 0x3b, 0x70,
-	0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x06,
+	0x5a, 0x04, 0x2e, 0x3b, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1319,59 +2658,107 @@ func file_maobft_proto_rawDescGZIP() []byte {
 	return file_maobft_proto_rawDescData
 }
 
-var file_maobft_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_maobft_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_maobft_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
+var file_maobft_proto_msgTypes = make([]protoimpl.MessageInfo, 33)
 var file_maobft_proto_goTypes = []interface{}{
-	(BlockState)(0),                      // 0: pb.BlockState
-	(TransactionStatus)(0),               // 1: pb.TransactionStatus
-	(*MerkleProof)(nil),                  // 2: pb.MerkleProof
-	(*ProofPair)(nil),                    // 3: pb.ProofPair
-	(*Payload)(nil),                      // 4: pb.Payload
-	(*BlockDump)(nil),                    // 5: pb.BlockDump
-	(*Block)(nil),                        // 6: pb.Block
-	(*BlockContent)(nil),                 // 7: pb.BlockContent
-	(*WireMessage)(nil),                  // 8: pb.WireMessage
-	(*DepositMessage)(nil),               // 9: pb.DepositMessage
-	(*Transaction)(nil),                  // 10: pb.Transaction
-	(*PrepareResponse)(nil),              // 11: pb.PrepareResponse
-	(*EchoResponse)(nil),                 // 12: pb.EchoResponse
-	(*ReadyRequest)(nil),                 // 13: pb.ReadyRequest
-	(*ReadyResponse)(nil),                // 14: pb.ReadyResponse
-	(*SyncRequest)(nil),                  // 15: pb.SyncRequest
-	(*SyncResponse)(nil),                 // 16: pb.SyncResponse
-	(*ProposeTransactionRequest)(nil),    // 17: pb.ProposeTransactionRequest
-	(*ProposeTransactionResponse)(nil),   // 18: pb.ProposeTransactionResponse
-	(*GetTransactionStatusRequest)(nil),  // 19: pb.GetTransactionStatusRequest
-	(*GetTransactionStatusResponse)(nil), // 20: pb.GetTransactionStatusResponse
+	(BroadcastMode)(0),                      // 0: pb.BroadcastMode
+	(MessageType)(0),                        // 1: pb.MessageType
+	(BlockState)(0),                         // 2: pb.BlockState
+	(AgreementType)(0),                      // 3: pb.AgreementType
+	(TransactionStatus)(0),                  // 4: pb.TransactionStatus
+	(*MerkleProof)(nil),                     // 5: pb.MerkleProof
+	(*ProofPair)(nil),                       // 6: pb.ProofPair
+	(*InstanceId)(nil),                      // 7: pb.InstanceId
+	(*Payload)(nil),                         // 8: pb.Payload
+	(*Envelope)(nil),                        // 9: pb.Envelope
+	(*BlockDump)(nil),                       // 10: pb.BlockDump
+	(*Block)(nil),                           // 11: pb.Block
+	(*ReadyVote)(nil),                       // 12: pb.ReadyVote
+	(*QuorumCertificate)(nil),               // 13: pb.QuorumCertificate
+	(*BlockContent)(nil),                    // 14: pb.BlockContent
+	(*WireMessage)(nil),                     // 15: pb.WireMessage
+	(*DepositMessage)(nil),                  // 16: pb.DepositMessage
+	(*Transaction)(nil),                     // 17: pb.Transaction
+	(*PrepareResponse)(nil),                 // 18: pb.PrepareResponse
+	(*EchoResponse)(nil),                    // 19: pb.EchoResponse
+	(*ReadyRequest)(nil),                    // 20: pb.ReadyRequest
+	(*ReadyResponse)(nil),                   // 21: pb.ReadyResponse
+	(*SyncRequest)(nil),                     // 22: pb.SyncRequest
+	(*SyncResponse)(nil),                    // 23: pb.SyncResponse
+	(*ViewChangeVote)(nil),                  // 24: pb.ViewChangeVote
+	(*ViewChangeRequest)(nil),               // 25: pb.ViewChangeRequest
+	(*ViewChangeResponse)(nil),              // 26: pb.ViewChangeResponse
+	(*EquivocationEvidence)(nil),            // 27: pb.EquivocationEvidence
+	(*ReportEquivocationResponse)(nil),      // 28: pb.ReportEquivocationResponse
+	(*GetEquivocationEvidenceRequest)(nil),  // 29: pb.GetEquivocationEvidenceRequest
+	(*GetEquivocationEvidenceResponse)(nil), // 30: pb.GetEquivocationEvidenceResponse
+	(*AgreementMessage)(nil),                // 31: pb.AgreementMessage
+	(*AgreementResponse)(nil),               // 32: pb.AgreementResponse
+	(*ConsensusMessage)(nil),                // 33: pb.ConsensusMessage
+	(*ProposeTransactionRequest)(nil),       // 34: pb.ProposeTransactionRequest
+	(*ProposeTransactionResponse)(nil),      // 35: pb.ProposeTransactionResponse
+	(*GetTransactionStatusRequest)(nil),     // 36: pb.GetTransactionStatusRequest
+	(*GetTransactionStatusResponse)(nil),    // 37: pb.GetTransactionStatusResponse
 }
 var file_maobft_proto_depIdxs = []int32{
-	3,  // 0: pb.MerkleProof.proof_pairs:type_name -> pb.ProofPair
-	2,  // 1: pb.Payload.merkle_proof:type_name -> pb.MerkleProof
-	6,  // 2: pb.BlockDump.block:type_name -> pb.Block
-	0,  // 3: pb.BlockDump.state:type_name -> pb.BlockState
-	7,  // 4: pb.Block.content:type_name -> pb.BlockContent
-	10, // 5: pb.BlockContent.txs:type_name -> pb.Transaction
-	8,  // 6: pb.Transaction.wire_msg:type_name -> pb.WireMessage
-	9,  // 7: pb.Transaction.deposit_msg:type_name -> pb.DepositMessage
-	10, // 8: pb.ProposeTransactionRequest.transaction:type_name -> pb.Transaction
-	1,  // 9: pb.GetTransactionStatusResponse.status:type_name -> pb.TransactionStatus
-	4,  // 10: pb.Prepare.Prepare:input_type -> pb.Payload
-	4,  // 11: pb.Echo.Echo:input_type -> pb.Payload
-	13, // 12: pb.Ready.Ready:input_type -> pb.ReadyRequest
-	15, // 13: pb.Sync.Sync:input_type -> pb.SyncRequest
-	17, // 14: pb.TransactionService.ProposeTransaction:input_type -> pb.ProposeTransactionRequest
-	19, // 15: pb.TransactionService.GetTransactionStatus:input_type -> pb.GetTransactionStatusRequest
-	11, // 16: pb.Prepare.Prepare:output_type -> pb.PrepareResponse
-	12, // 17: pb.Echo.Echo:output_type -> pb.EchoResponse
-	14, // 18: pb.Ready.Ready:output_type -> pb.ReadyResponse
-	16, // 19: pb.Sync.Sync:output_type -> pb.SyncResponse
-	18, // 20: pb.TransactionService.ProposeTransaction:output_type -> pb.ProposeTransactionResponse
-	20, // 21: pb.TransactionService.GetTransactionStatus:output_type -> pb.GetTransactionStatusResponse
-	16, // [16:22] is the sub-list for method output_type
-	10, // [10:16] is the sub-list for method input_type
-	10, // [10:10] is the sub-list for extension type_name
-	10, // [10:10] is the sub-list for extension extendee
-	0,  // [0:10] is the sub-list for field type_name
+	6,  // 0: pb.MerkleProof.proof_pairs:type_name -> pb.ProofPair
+	5,  // 1: pb.Payload.merkle_proof:type_name -> pb.MerkleProof
+	7,  // 2: pb.Payload.instance:type_name -> pb.InstanceId
+	0,  // 3: pb.Payload.mode:type_name -> pb.BroadcastMode
+	1,  // 4: pb.Envelope.type:type_name -> pb.MessageType
+	7,  // 5: pb.Envelope.instance:type_name -> pb.InstanceId
+	5,  // 6: pb.Envelope.merkle_proof:type_name -> pb.MerkleProof
+	0,  // 7: pb.Envelope.mode:type_name -> pb.BroadcastMode
+	11, // 8: pb.BlockDump.block:type_name -> pb.Block
+	2,  // 9: pb.BlockDump.state:type_name -> pb.BlockState
+	14, // 10: pb.Block.content:type_name -> pb.BlockContent
+	13, // 11: pb.Block.certificate:type_name -> pb.QuorumCertificate
+	7,  // 12: pb.QuorumCertificate.instance:type_name -> pb.InstanceId
+	12, // 13: pb.QuorumCertificate.readies:type_name -> pb.ReadyVote
+	0,  // 14: pb.QuorumCertificate.mode:type_name -> pb.BroadcastMode
+	17, // 15: pb.BlockContent.txs:type_name -> pb.Transaction
+	15, // 16: pb.Transaction.wire_msg:type_name -> pb.WireMessage
+	16, // 17: pb.Transaction.deposit_msg:type_name -> pb.DepositMessage
+	7,  // 18: pb.ReadyRequest.instance:type_name -> pb.InstanceId
+	11, // 19: pb.ViewChangeVote.last_commit:type_name -> pb.Block
+	8,  // 20: pb.EquivocationEvidence.first:type_name -> pb.Payload
+	8,  // 21: pb.EquivocationEvidence.second:type_name -> pb.Payload
+	27, // 22: pb.GetEquivocationEvidenceResponse.evidence:type_name -> pb.EquivocationEvidence
+	7,  // 23: pb.AgreementMessage.instance:type_name -> pb.InstanceId
+	3,  // 24: pb.AgreementMessage.type:type_name -> pb.AgreementType
+	8,  // 25: pb.ConsensusMessage.prepare:type_name -> pb.Payload
+	8,  // 26: pb.ConsensusMessage.echo:type_name -> pb.Payload
+	20, // 27: pb.ConsensusMessage.ready:type_name -> pb.ReadyRequest
+	31, // 28: pb.ConsensusMessage.agreement:type_name -> pb.AgreementMessage
+	17, // 29: pb.ProposeTransactionRequest.transaction:type_name -> pb.Transaction
+	4,  // 30: pb.GetTransactionStatusResponse.status:type_name -> pb.TransactionStatus
+	8,  // 31: pb.Prepare.Prepare:input_type -> pb.Payload
+	8,  // 32: pb.Echo.Echo:input_type -> pb.Payload
+	20, // 33: pb.Ready.Ready:input_type -> pb.ReadyRequest
+	22, // 34: pb.Sync.Sync:input_type -> pb.SyncRequest
+	25, // 35: pb.ViewChange.ViewChange:input_type -> pb.ViewChangeRequest
+	27, // 36: pb.Equivocation.ReportEquivocation:input_type -> pb.EquivocationEvidence
+	29, // 37: pb.Admin.GetEquivocationEvidence:input_type -> pb.GetEquivocationEvidenceRequest
+	31, // 38: pb.Agreement.Agreement:input_type -> pb.AgreementMessage
+	33, // 39: pb.Consensus.Consensus:input_type -> pb.ConsensusMessage
+	34, // 40: pb.TransactionService.ProposeTransaction:input_type -> pb.ProposeTransactionRequest
+	36, // 41: pb.TransactionService.GetTransactionStatus:input_type -> pb.GetTransactionStatusRequest
+	18, // 42: pb.Prepare.Prepare:output_type -> pb.PrepareResponse
+	19, // 43: pb.Echo.Echo:output_type -> pb.EchoResponse
+	21, // 44: pb.Ready.Ready:output_type -> pb.ReadyResponse
+	23, // 45: pb.Sync.Sync:output_type -> pb.SyncResponse
+	26, // 46: pb.ViewChange.ViewChange:output_type -> pb.ViewChangeResponse
+	28, // 47: pb.Equivocation.ReportEquivocation:output_type -> pb.ReportEquivocationResponse
+	30, // 48: pb.Admin.GetEquivocationEvidence:output_type -> pb.GetEquivocationEvidenceResponse
+	32, // 49: pb.Agreement.Agreement:output_type -> pb.AgreementResponse
+	33, // 50: pb.Consensus.Consensus:output_type -> pb.ConsensusMessage
+	35, // 51: pb.TransactionService.ProposeTransaction:output_type -> pb.ProposeTransactionResponse
+	37, // 52: pb.TransactionService.GetTransactionStatus:output_type -> pb.GetTransactionStatusResponse
+	42, // [42:53] is the sub-list for method output_type
+	31, // [31:42] is the sub-list for method input_type
+	31, // [31:31] is the sub-list for extension type_name
+	31, // [31:31] is the sub-list for extension extendee
+	0,  // [0:31] is the sub-list for field type_name
 }
 
 func init() { file_maobft_proto_init() }
@@ -1405,7 +2792,7 @@ func file_maobft_proto_init() {
 			}
 		}
 		file_maobft_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Payload); i {
+			switch v := v.(*InstanceId); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1417,6 +2804,30 @@ func file_maobft_proto_init() {
 			}
 		}
 		file_maobft_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Payload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_maobft_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Envelope); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_maobft_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*BlockDump); i {
 			case 0:
 				return &v.state
@@ -1428,8 +2839,152 @@ func file_maobft_proto_init() {
 				return nil
 			}
 		}
-		file_maobft_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Block); i {
+		file_maobft_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Block); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_maobft_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReadyVote); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_maobft_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*QuorumCertificate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_maobft_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BlockContent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_maobft_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WireMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_maobft_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DepositMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_maobft_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Transaction); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_maobft_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PrepareResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_maobft_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EchoResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_maobft_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReadyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_maobft_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReadyResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_maobft_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SyncRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_maobft_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SyncResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1440,8 +2995,8 @@ func file_maobft_proto_init() {
 				return nil
 			}
 		}
-		file_maobft_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*BlockContent); i {
+		file_maobft_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ViewChangeVote); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1452,8 +3007,8 @@ func file_maobft_proto_init() {
 				return nil
 			}
 		}
-		file_maobft_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*WireMessage); i {
+		file_maobft_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ViewChangeRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1464,8 +3019,8 @@ func file_maobft_proto_init() {
 				return nil
 			}
 		}
-		file_maobft_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DepositMessage); i {
+		file_maobft_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ViewChangeResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1476,8 +3031,8 @@ func file_maobft_proto_init() {
 				return nil
 			}
 		}
-		file_maobft_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Transaction); i {
+		file_maobft_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EquivocationEvidence); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1488,8 +3043,8 @@ func file_maobft_proto_init() {
 				return nil
 			}
 		}
-		file_maobft_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PrepareResponse); i {
+		file_maobft_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReportEquivocationResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1500,8 +3055,8 @@ func file_maobft_proto_init() {
 				return nil
 			}
 		}
-		file_maobft_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*EchoResponse); i {
+		file_maobft_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetEquivocationEvidenceRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1512,8 +3067,8 @@ func file_maobft_proto_init() {
 				return nil
 			}
 		}
-		file_maobft_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ReadyRequest); i {
+		file_maobft_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetEquivocationEvidenceResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1524,8 +3079,8 @@ func file_maobft_proto_init() {
 				return nil
 			}
 		}
-		file_maobft_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ReadyResponse); i {
+		file_maobft_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AgreementMessage); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1536,8 +3091,8 @@ func file_maobft_proto_init() {
 				return nil
 			}
 		}
-		file_maobft_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SyncRequest); i {
+		file_maobft_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AgreementResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1548,8 +3103,8 @@ func file_maobft_proto_init() {
 				return nil
 			}
 		}
-		file_maobft_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SyncResponse); i {
+		file_maobft_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ConsensusMessage); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1560,7 +3115,7 @@ func file_maobft_proto_init() {
 				return nil
 			}
 		}
-		file_maobft_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+		file_maobft_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ProposeTransactionRequest); i {
 			case 0:
 				return &v.state
@@ -1572,7 +3127,7 @@ func file_maobft_proto_init() {
 				return nil
 			}
 		}
-		file_maobft_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+		file_maobft_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ProposeTransactionResponse); i {
 			case 0:
 				return &v.state
@@ -1584,7 +3139,7 @@ func file_maobft_proto_init() {
 				return nil
 			}
 		}
-		file_maobft_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+		file_maobft_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetTransactionStatusRequest); i {
 			case 0:
 				return &v.state
@@ -1596,7 +3151,7 @@ func file_maobft_proto_init() {
 				return nil
 			}
 		}
-		file_maobft_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+		file_maobft_proto_msgTypes[32].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetTransactionStatusResponse); i {
 			case 0:
 				return &v.state
@@ -1609,19 +3164,25 @@ func file_maobft_proto_init() {
 			}
 		}
 	}
-	file_maobft_proto_msgTypes[8].OneofWrappers = []interface{}{
+	file_maobft_proto_msgTypes[12].OneofWrappers = []interface{}{
 		(*Transaction_WireMsg)(nil),
 		(*Transaction_DepositMsg)(nil),
 	}
+	file_maobft_proto_msgTypes[28].OneofWrappers = []interface{}{
+		(*ConsensusMessage_Prepare)(nil),
+		(*ConsensusMessage_Echo)(nil),
+		(*ConsensusMessage_Ready)(nil),
+		(*ConsensusMessage_Agreement)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_maobft_proto_rawDesc,
-			NumEnums:      2,
-			NumMessages:   19,
+			NumEnums:      5,
+			NumMessages:   33,
 			NumExtensions: 0,
-			NumServices:   5,
+			NumServices:   10,
 		},
 		GoTypes:           file_maobft_proto_goTypes,
 		DependencyIndexes: file_maobft_proto_depIdxs,
@@ -1930,6 +3491,402 @@ var _Sync_serviceDesc = grpc.ServiceDesc{
 	Metadata: "maobft.proto",
 }
 
+// ViewChangeClient is the client API for ViewChange service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ViewChangeClient interface {
+	ViewChange(ctx context.Context, in *ViewChangeRequest, opts ...grpc.CallOption) (*ViewChangeResponse, error)
+}
+
+type viewChangeClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewViewChangeClient(cc grpc.ClientConnInterface) ViewChangeClient {
+	return &viewChangeClient{cc}
+}
+
+func (c *viewChangeClient) ViewChange(ctx context.Context, in *ViewChangeRequest, opts ...grpc.CallOption) (*ViewChangeResponse, error) {
+	out := new(ViewChangeResponse)
+	err := c.cc.Invoke(ctx, "/pb.ViewChange/ViewChange", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ViewChangeServer is the server API for ViewChange service.
+type ViewChangeServer interface {
+	ViewChange(context.Context, *ViewChangeRequest) (*ViewChangeResponse, error)
+}
+
+// UnimplementedViewChangeServer can be embedded to have forward compatible implementations.
+type UnimplementedViewChangeServer struct {
+}
+
+func (*UnimplementedViewChangeServer) ViewChange(context.Context, *ViewChangeRequest) (*ViewChangeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ViewChange not implemented")
+}
+
+func RegisterViewChangeServer(s *grpc.Server, srv ViewChangeServer) {
+	s.RegisterService(&_ViewChange_serviceDesc, srv)
+}
+
+func _ViewChange_ViewChange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ViewChangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ViewChangeServer).ViewChange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.ViewChange/ViewChange",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ViewChangeServer).ViewChange(ctx, req.(*ViewChangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _ViewChange_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.ViewChange",
+	HandlerType: (*ViewChangeServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ViewChange",
+			Handler:    _ViewChange_ViewChange_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "maobft.proto",
+}
+
+// EquivocationClient is the client API for Equivocation service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type EquivocationClient interface {
+	// ReportEquivocation gossips evidence of equivocation among peers.
+	ReportEquivocation(ctx context.Context, in *EquivocationEvidence, opts ...grpc.CallOption) (*ReportEquivocationResponse, error)
+}
+
+type equivocationClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEquivocationClient(cc grpc.ClientConnInterface) EquivocationClient {
+	return &equivocationClient{cc}
+}
+
+func (c *equivocationClient) ReportEquivocation(ctx context.Context, in *EquivocationEvidence, opts ...grpc.CallOption) (*ReportEquivocationResponse, error) {
+	out := new(ReportEquivocationResponse)
+	err := c.cc.Invoke(ctx, "/pb.Equivocation/ReportEquivocation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EquivocationServer is the server API for Equivocation service.
+type EquivocationServer interface {
+	// ReportEquivocation gossips evidence of equivocation among peers.
+	ReportEquivocation(context.Context, *EquivocationEvidence) (*ReportEquivocationResponse, error)
+}
+
+// UnimplementedEquivocationServer can be embedded to have forward compatible implementations.
+type UnimplementedEquivocationServer struct {
+}
+
+func (*UnimplementedEquivocationServer) ReportEquivocation(context.Context, *EquivocationEvidence) (*ReportEquivocationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportEquivocation not implemented")
+}
+
+func RegisterEquivocationServer(s *grpc.Server, srv EquivocationServer) {
+	s.RegisterService(&_Equivocation_serviceDesc, srv)
+}
+
+func _Equivocation_ReportEquivocation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EquivocationEvidence)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EquivocationServer).ReportEquivocation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Equivocation/ReportEquivocation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EquivocationServer).ReportEquivocation(ctx, req.(*EquivocationEvidence))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Equivocation_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.Equivocation",
+	HandlerType: (*EquivocationServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ReportEquivocation",
+			Handler:    _Equivocation_ReportEquivocation_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "maobft.proto",
+}
+
+// AdminClient is the client API for Admin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type AdminClient interface {
+	// GetEquivocationEvidence returns all equivocation evidence this node holds.
+	GetEquivocationEvidence(ctx context.Context, in *GetEquivocationEvidenceRequest, opts ...grpc.CallOption) (*GetEquivocationEvidenceResponse, error)
+}
+
+type adminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminClient(cc grpc.ClientConnInterface) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) GetEquivocationEvidence(ctx context.Context, in *GetEquivocationEvidenceRequest, opts ...grpc.CallOption) (*GetEquivocationEvidenceResponse, error) {
+	out := new(GetEquivocationEvidenceResponse)
+	err := c.cc.Invoke(ctx, "/pb.Admin/GetEquivocationEvidence", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServer is the server API for Admin service.
+type AdminServer interface {
+	// GetEquivocationEvidence returns all equivocation evidence this node holds.
+	GetEquivocationEvidence(context.Context, *GetEquivocationEvidenceRequest) (*GetEquivocationEvidenceResponse, error)
+}
+
+// UnimplementedAdminServer can be embedded to have forward compatible implementations.
+type UnimplementedAdminServer struct {
+}
+
+func (*UnimplementedAdminServer) GetEquivocationEvidence(context.Context, *GetEquivocationEvidenceRequest) (*GetEquivocationEvidenceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEquivocationEvidence not implemented")
+}
+
+func RegisterAdminServer(s *grpc.Server, srv AdminServer) {
+	s.RegisterService(&_Admin_serviceDesc, srv)
+}
+
+func _Admin_GetEquivocationEvidence_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEquivocationEvidenceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetEquivocationEvidence(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Admin/GetEquivocationEvidence",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetEquivocationEvidence(ctx, req.(*GetEquivocationEvidenceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Admin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.Admin",
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetEquivocationEvidence",
+			Handler:    _Admin_GetEquivocationEvidence_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "maobft.proto",
+}
+
+// AgreementClient is the client API for Agreement service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type AgreementClient interface {
+	Agreement(ctx context.Context, in *AgreementMessage, opts ...grpc.CallOption) (*AgreementResponse, error)
+}
+
+type agreementClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAgreementClient(cc grpc.ClientConnInterface) AgreementClient {
+	return &agreementClient{cc}
+}
+
+func (c *agreementClient) Agreement(ctx context.Context, in *AgreementMessage, opts ...grpc.CallOption) (*AgreementResponse, error) {
+	out := new(AgreementResponse)
+	err := c.cc.Invoke(ctx, "/pb.Agreement/Agreement", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AgreementServer is the server API for Agreement service.
+type AgreementServer interface {
+	Agreement(context.Context, *AgreementMessage) (*AgreementResponse, error)
+}
+
+// UnimplementedAgreementServer can be embedded to have forward compatible implementations.
+type UnimplementedAgreementServer struct {
+}
+
+func (*UnimplementedAgreementServer) Agreement(context.Context, *AgreementMessage) (*AgreementResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Agreement not implemented")
+}
+
+func RegisterAgreementServer(s *grpc.Server, srv AgreementServer) {
+	s.RegisterService(&_Agreement_serviceDesc, srv)
+}
+
+func _Agreement_Agreement_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AgreementMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgreementServer).Agreement(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Agreement/Agreement",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgreementServer).Agreement(ctx, req.(*AgreementMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Agreement_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.Agreement",
+	HandlerType: (*AgreementServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Agreement",
+			Handler:    _Agreement_Agreement_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "maobft.proto",
+}
+
+// ConsensusClient is the client API for Consensus service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ConsensusClient interface {
+	Consensus(ctx context.Context, opts ...grpc.CallOption) (Consensus_ConsensusClient, error)
+}
+
+type consensusClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewConsensusClient(cc grpc.ClientConnInterface) ConsensusClient {
+	return &consensusClient{cc}
+}
+
+func (c *consensusClient) Consensus(ctx context.Context, opts ...grpc.CallOption) (Consensus_ConsensusClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Consensus_serviceDesc.Streams[0], "/pb.Consensus/Consensus", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &consensusConsensusClient{stream}
+	return x, nil
+}
+
+type Consensus_ConsensusClient interface {
+	Send(*ConsensusMessage) error
+	Recv() (*ConsensusMessage, error)
+	grpc.ClientStream
+}
+
+type consensusConsensusClient struct {
+	grpc.ClientStream
+}
+
+func (x *consensusConsensusClient) Send(m *ConsensusMessage) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *consensusConsensusClient) Recv() (*ConsensusMessage, error) {
+	m := new(ConsensusMessage)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ConsensusServer is the server API for Consensus service.
+type ConsensusServer interface {
+	Consensus(Consensus_ConsensusServer) error
+}
+
+// UnimplementedConsensusServer can be embedded to have forward compatible implementations.
+type UnimplementedConsensusServer struct {
+}
+
+func (*UnimplementedConsensusServer) Consensus(Consensus_ConsensusServer) error {
+	return status.Errorf(codes.Unimplemented, "method Consensus not implemented")
+}
+
+func RegisterConsensusServer(s *grpc.Server, srv ConsensusServer) {
+	s.RegisterService(&_Consensus_serviceDesc, srv)
+}
+
+func _Consensus_Consensus_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ConsensusServer).Consensus(&consensusConsensusServer{stream})
+}
+
+type Consensus_ConsensusServer interface {
+	Send(*ConsensusMessage) error
+	Recv() (*ConsensusMessage, error)
+	grpc.ServerStream
+}
+
+type consensusConsensusServer struct {
+	grpc.ServerStream
+}
+
+func (x *consensusConsensusServer) Send(m *ConsensusMessage) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *consensusConsensusServer) Recv() (*ConsensusMessage, error) {
+	m := new(ConsensusMessage)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var _Consensus_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.Consensus",
+	HandlerType: (*ConsensusServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Consensus",
+			Handler:       _Consensus_Consensus_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "maobft.proto",
+}
+
 // TransactionServiceClient is the client API for TransactionService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
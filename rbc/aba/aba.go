@@ -0,0 +1,325 @@
+// Package aba is asynchronous binary Byzantine agreement, it's the randomized agreement of Mostéfaoui, Moumen and
+// Raynal with the CONF phase that keeps an adversary from forcing bad coins. It runs on top of common.Common, whose
+// peers take part in each agreement and whose transport carries signed BVAL, AUX, CONF and TERM messages.
+//
+// In each round a node broadcasts its estimate in BVAL, relays values that f+1 peers sent, and accepts values that
+// 2f+1 peers sent into bin. It sends AUX of the first value in bin, and waits for N-f AUX of values in bin. It then
+// sends CONF of bin, and waits for N-f CONF of subsets of bin, whose union is vals. If vals is a single value, it's
+// the new estimate and it's decided if it equals the coin of the round, otherwise the coin is the new estimate. A
+// node that decides sends TERM, f+1 TERM of a value decide it for others, and 2f+1 TERM let a node halt.
+//
+// The coin is either local, known to every node in advance, or shared, tossed with threshold signature shares that
+// nodes send in COIN once they have vals.
+package aba
+
+import (
+	"github.com/gopricy/mao-bft/pb"
+	"github.com/gopricy/mao-bft/rbc/common"
+	"github.com/pkg/errors"
+)
+
+// MaxRoundsAhead bounds how far ahead of its current round an agreement takes messages, so that a faulty peer can't
+// make it hold unbounded state.
+const MaxRoundsAhead = 64
+
+// Agreement is one binary agreement, it isn't safe for concurrent use.
+type Agreement struct {
+	node     *common.Common
+	instance common.Instance
+	coin     Coin
+	n, f     int
+
+	round    int64
+	estimate bool
+	started  bool
+	rounds   map[int64]*round
+	decided  bool
+	decision bool
+	halted   bool
+	// term holds peers that sent TERM of each value.
+	term     [2]map[string]bool
+	sentTerm bool
+}
+
+// round is the state of a round.
+type round struct {
+	// bval holds peers that sent BVAL of each value.
+	bval     [2]map[string]bool
+	sentBval [2]bool
+	bin      [2]bool
+	// aux maps each peer that sent AUX to its value.
+	aux     map[string]bool
+	sentAux bool
+	// conf maps each peer that sent CONF to its values.
+	conf     map[string][2]bool
+	sentConf bool
+	sentCoin bool
+}
+
+// New returns the agreement of instance among peers of node, it tosses coin in each round.
+func New(node *common.Common, instance common.Instance, coin Coin) *Agreement {
+	return &Agreement{
+		node:     node,
+		instance: instance,
+		coin:     coin,
+		n:        len(node.AllPeers),
+		f:        node.ByzantineLimit,
+		rounds:   make(map[int64]*round),
+		term:     [2]map[string]bool{make(map[string]bool), make(map[string]bool)},
+	}
+}
+
+func index(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// values returns the values in set.
+func values(set [2]bool) []bool {
+	var res []bool
+	for i, ok := range set {
+		if ok {
+			res = append(res, i == 1)
+		}
+	}
+	return res
+}
+
+// Instance returns the instance this agreement decides on.
+func (a *Agreement) Instance() common.Instance {
+	return a.instance
+}
+
+// Round returns the current round.
+func (a *Agreement) Round() int64 {
+	return a.round
+}
+
+// Decision returns the decided value, ok is false until it's decided.
+func (a *Agreement) Decision() (value bool, ok bool) {
+	return a.decision, a.decided
+}
+
+// Halted returns whether this agreement no longer takes messages, it's decided by then.
+func (a *Agreement) Halted() bool {
+	return a.halted
+}
+
+// Started returns whether this agreement has its input.
+func (a *Agreement) Started() bool {
+	return a.started
+}
+
+func (a *Agreement) getRound(number int64) *round {
+	r, ok := a.rounds[number]
+	if !ok {
+		r = &round{
+			bval: [2]map[string]bool{make(map[string]bool), make(map[string]bool)},
+			aux:  make(map[string]bool),
+			conf: make(map[string][2]bool),
+		}
+		a.rounds[number] = r
+	}
+	return r
+}
+
+// broadcast signs a message of this agreement and sends it to all peers, including this node.
+func (a *Agreement) broadcast(req *pb.AgreementMessage) {
+	req.Instance = a.instance.Pb()
+	a.node.SignAgreement(req)
+	for _, p := range a.node.Peers() {
+		a.node.SendAgreement(p, req)
+	}
+}
+
+// Input starts the agreement with value, later inputs are ignored.
+func (a *Agreement) Input(value bool) {
+	if a.started || a.halted {
+		return
+	}
+	a.started = true
+	a.estimate = value
+	a.enterRound()
+	a.Advance()
+}
+
+// enterRound broadcasts BVAL of the estimate in the current round, and AUX if messages received early already put
+// a value in bin.
+func (a *Agreement) enterRound() {
+	r := a.getRound(a.round)
+	a.sendBval(a.round, r, a.estimate)
+	for _, value := range []bool{a.estimate, !a.estimate} {
+		if r.bin[index(value)] {
+			a.sendAux(a.round, r, value)
+		}
+	}
+}
+
+func (a *Agreement) sendBval(number int64, r *round, value bool) {
+	if r.sentBval[index(value)] {
+		return
+	}
+	r.sentBval[index(value)] = true
+	a.broadcast(&pb.AgreementMessage{Type: pb.AgreementType_AT_BVAL, Round: number, Value: value})
+}
+
+func (a *Agreement) sendAux(number int64, r *round, value bool) {
+	if r.sentAux {
+		return
+	}
+	r.sentAux = true
+	a.broadcast(&pb.AgreementMessage{Type: pb.AgreementType_AT_AUX, Round: number, Value: value})
+}
+
+// Handle handles a message from peer, its signature is verified by common.Common.
+func (a *Agreement) Handle(peer string, req *pb.AgreementMessage) error {
+	if a.halted {
+		return nil
+	}
+	if req.Type == pb.AgreementType_AT_TERM {
+		a.handleTerm(peer, req.Value)
+		return nil
+	}
+	if req.Round < a.round {
+		return nil
+	}
+	if req.Round >= a.round+MaxRoundsAhead {
+		return errors.Errorf("round %d is too far ahead of %d", req.Round, a.round)
+	}
+	r := a.getRound(req.Round)
+	v := index(req.Value)
+	switch req.Type {
+	case pb.AgreementType_AT_BVAL:
+		if r.bval[v][peer] {
+			return errors.Errorf("duplicate BVAL from %s", peer)
+		}
+		r.bval[v][peer] = true
+		// Values of f+1 peers come from at least one correct node.
+		if len(r.bval[v]) == a.f+1 {
+			a.sendBval(req.Round, r, req.Value)
+		}
+		if len(r.bval[v]) == 2*a.f+1 {
+			r.bin[v] = true
+			if a.started && req.Round == a.round {
+				a.sendAux(req.Round, r, req.Value)
+			}
+		}
+	case pb.AgreementType_AT_AUX:
+		if _, ok := r.aux[peer]; ok {
+			return errors.Errorf("duplicate AUX from %s", peer)
+		}
+		r.aux[peer] = req.Value
+	case pb.AgreementType_AT_CONF:
+		if _, ok := r.conf[peer]; ok {
+			return errors.Errorf("duplicate CONF from %s", peer)
+		}
+		var set [2]bool
+		for _, value := range req.Values {
+			set[index(value)] = true
+		}
+		if set == [2]bool{} {
+			return errors.Errorf("empty CONF from %s", peer)
+		}
+		r.conf[peer] = set
+	case pb.AgreementType_AT_COIN:
+		shared, ok := a.coin.(SharedCoin)
+		if !ok {
+			return errors.Errorf("unexpected COIN from %s", peer)
+		}
+		if err := shared.AddShare(peer, req.Round, req.Share); err != nil {
+			return err
+		}
+	default:
+		return errors.Errorf("unknown agreement message %s", req.Type)
+	}
+	a.Advance()
+	return nil
+}
+
+// subset returns whether all values in set are in bin.
+func subset(set [2]bool, bin [2]bool) bool {
+	return (!set[0] || bin[0]) && (!set[1] || bin[1])
+}
+
+// Advance moves through rounds as far as received messages and the coin allow. It's called by Input and Handle, and
+// must be called by the owner of the coin once a coin that wasn't known becomes known.
+func (a *Agreement) Advance() {
+	for a.started && !a.halted {
+		r := a.getRound(a.round)
+		count := 0
+		for _, value := range r.aux {
+			if r.bin[index(value)] {
+				count++
+			}
+		}
+		if count < a.n-a.f {
+			return
+		}
+		if !r.sentConf {
+			r.sentConf = true
+			a.broadcast(&pb.AgreementMessage{Type: pb.AgreementType_AT_CONF, Round: a.round, Values: values(r.bin)})
+		}
+		count = 0
+		var vals [2]bool
+		for _, set := range r.conf {
+			if subset(set, r.bin) {
+				count++
+				vals[0], vals[1] = vals[0] || set[0], vals[1] || set[1]
+			}
+		}
+		if count < a.n-a.f {
+			return
+		}
+		// The share of the coin is sent only now, so that the coin isn't known before vals of a correct node.
+		if shared, ok := a.coin.(SharedCoin); ok && !r.sentCoin {
+			r.sentCoin = true
+			a.broadcast(&pb.AgreementMessage{Type: pb.AgreementType_AT_COIN, Round: a.round, Share: shared.Share(a.round)})
+		}
+		coin, ok := a.coin.Toss(a.round)
+		if !ok {
+			return
+		}
+		if vals[0] != vals[1] {
+			a.estimate = vals[1]
+			if a.estimate == coin {
+				a.decide(coin)
+			}
+		} else {
+			a.estimate = coin
+		}
+		if shared, ok := a.coin.(SharedCoin); ok {
+			shared.Forget(a.round)
+		}
+		delete(a.rounds, a.round)
+		a.round++
+		a.enterRound()
+	}
+}
+
+func (a *Agreement) decide(value bool) {
+	if a.decided {
+		return
+	}
+	a.decided, a.decision = true, value
+	a.node.Infof("Agreement %s decides %t in round %d", a.instance, value, a.round)
+	if !a.sentTerm {
+		a.sentTerm = true
+		a.broadcast(&pb.AgreementMessage{Type: pb.AgreementType_AT_TERM, Round: a.round, Value: value})
+	}
+}
+
+// handleTerm decides value once f+1 peers decided it, since one of them is correct. Once 2f+1 peers decided, all
+// correct nodes will get f+1 TERM, so rounds are no longer needed.
+func (a *Agreement) handleTerm(peer string, value bool) {
+	a.term[index(value)][peer] = true
+	count := len(a.term[index(value)])
+	if count >= a.f+1 {
+		a.decide(value)
+	}
+	if count >= 2*a.f+1 {
+		a.halted = true
+		a.rounds = nil
+	}
+}
@@ -0,0 +1,195 @@
+package aba_test
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gopricy/mao-bft/pb"
+	"github.com/gopricy/mao-bft/rbc/aba"
+	"github.com/gopricy/mao-bft/rbc/adversary"
+	"github.com/gopricy/mao-bft/rbc/common"
+	"github.com/gopricy/mao-bft/rbc/sim"
+	"github.com/gopricy/mao-bft/rbc/thresholdsign"
+	"github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	logging.SetLevel(logging.WARNING, "RBC")
+}
+
+var instance = common.Instance{Broadcaster: sim.LeaderName, Epoch: 1}
+
+func config(seed int64, f int) sim.Config {
+	return sim.Config{
+		Seed:           seed,
+		ByzantineLimit: f,
+		MinLatency:     time.Millisecond,
+		MaxLatency:     50 * time.Millisecond,
+		Reorder:        true,
+	}
+}
+
+func localCoin(node *common.Common) aba.Coin {
+	return aba.NewLocalCoin(node, instance)
+}
+
+// thresholdCoins returns threshold coins of nodes of s, that f+1 nodes toss.
+func thresholdCoins(t *testing.T, s *sim.Simulation, f int) func(node *common.Common) aba.Coin {
+	names := s.Names()
+	public, shares, err := thresholdsign.DealFrom(rand.New(rand.NewSource(1)), len(names), f+1)
+	assert.Nil(t, err)
+	return func(node *common.Common) aba.Coin {
+		coin := thresholdsign.NewCoin(public, shares[node.ShardIndex(node.Name())])
+		return aba.NewThresholdCoin(node, instance, coin)
+	}
+}
+
+// agree runs an agreement on each node of s with coin of the node, node name gets input inputs[name] at a random
+// time.
+func agree(s *sim.Simulation, r *rand.Rand, inputs map[string]bool, coin func(node *common.Common) aba.Coin) map[string]*aba.Agreement {
+	res := make(map[string]*aba.Agreement)
+	for _, name := range s.Names() {
+		node := s.Node(name)
+		a := aba.New(node, instance, coin(node))
+		node.HandleAgreement(func(peer string, req *pb.AgreementMessage) error {
+			return a.Handle(peer, req)
+		})
+		input := inputs[name]
+		s.AfterFunc(time.Duration(r.Intn(50))*time.Millisecond, func() { a.Input(input) })
+		res[name] = a
+	}
+	return res
+}
+
+// assertAgreement checks that agreements of correct nodes decide the same value and halt, and returns the value.
+func assertAgreement(t *testing.T, agreements map[string]*aba.Agreement, correct []string, msgAndArgs ...interface{}) bool {
+	first, ok := agreements[correct[0]].Decision()
+	assert.True(t, ok, msgAndArgs...)
+	for _, name := range correct {
+		value, ok := agreements[name].Decision()
+		assert.True(t, ok, msgAndArgs...)
+		assert.Equal(t, first, value, msgAndArgs...)
+		assert.True(t, agreements[name].Halted(), msgAndArgs...)
+	}
+	return first
+}
+
+func TestAgreement_Validity(t *testing.T) {
+	for _, f := range []int{1, 2} {
+		for _, input := range []bool{false, true} {
+			s := sim.New(config(1, f))
+			inputs := make(map[string]bool)
+			for _, name := range s.Names() {
+				inputs[name] = input
+			}
+			agreements := agree(s, rand.New(rand.NewSource(1)), inputs, localCoin)
+			s.Run(0)
+			assert.Equal(t, input, assertAgreement(t, agreements, s.Names()), "f %d", f)
+		}
+	}
+}
+
+func TestAgreement_TerminatesWithFaults(t *testing.T) {
+	strategies := []string{sim.Crash, "drop", "delay", "duplicate", "mutate", "unsigned"}
+	for seed := int64(1); seed <= 30; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		f := 1 + r.Intn(2)
+		s := sim.New(config(seed, f))
+		names := s.Names()
+		inputs := make(map[string]bool)
+		for _, name := range names {
+			inputs[name] = r.Intn(2) == 0
+		}
+		// The first f nodes in a random order are faulty.
+		r.Shuffle(len(names), func(i, j int) { names[i], names[j] = names[j], names[i] })
+		strategy := strategies[r.Intn(len(strategies))]
+		for _, name := range names[:f] {
+			if strategy == sim.Crash {
+				s.Crash(name)
+				continue
+			}
+			a, err := adversary.New(strategy)
+			assert.Nil(t, err)
+			s.Node(name).SetAdversary(a)
+		}
+		agreements := agree(s, r, inputs, localCoin)
+		s.Run(0)
+
+		value := assertAgreement(t, agreements, names[f:], "seed %d, %d faulty nodes %s", seed, f, strategy)
+		// A value that no correct node has is never decided.
+		valid := false
+		for _, name := range names[f:] {
+			valid = valid || inputs[name] == value
+		}
+		assert.True(t, valid, "seed %d", seed)
+	}
+}
+
+func TestAgreement_RejectsForgedMessages(t *testing.T) {
+	s := sim.New(config(1, 1))
+	s.Node("f1").SetAdversary(&adversary.Unsigned{})
+	agreements := agree(s, rand.New(rand.NewSource(1)), map[string]bool{}, localCoin)
+	s.Run(0)
+
+	assertAgreement(t, agreements, []string{sim.LeaderName, "f2", "f3"})
+	rejected := 0
+	for _, e := range s.Trace() {
+		if e.From == "f1" && strings.Contains(e.Err, "invalid signature") {
+			rejected++
+		}
+	}
+	assert.NotZero(t, rejected)
+}
+
+func TestAgreement_ThresholdCoin(t *testing.T) {
+	// Pairings are slow, so only a few seeds are run.
+	seeds := int64(4)
+	if testing.Short() {
+		seeds = 2
+	}
+	for seed := int64(1); seed <= seeds; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		f := 1 + int(seed%2)
+		s := sim.New(config(seed, f))
+		names := s.Names()
+		inputs := make(map[string]bool)
+		for _, name := range names {
+			inputs[name] = r.Intn(2) == 0
+		}
+		// The last f nodes send invalid shares.
+		for _, name := range names[len(names)-f:] {
+			s.Node(name).SetAdversary(&adversary.Mutate{})
+		}
+		agreements := agree(s, r, inputs, thresholdCoins(t, s, f))
+		s.Run(0)
+
+		assertAgreement(t, agreements, names[:len(names)-f], "seed %d", seed)
+		rejected := 0
+		for _, e := range s.Trace() {
+			if strings.Contains(e.Err, "invalid coin share") {
+				rejected++
+			}
+		}
+		assert.NotZero(t, rejected, "seed %d", seed)
+	}
+}
+
+func TestLocalCoin(t *testing.T) {
+	s := sim.New(config(1, 1))
+	coin := aba.NewLocalCoin(s.Node("f1"), instance)
+	other := aba.NewLocalCoin(s.Node("f2"), instance)
+	heads := 0
+	for round := int64(0); round < 100; round++ {
+		value, ok := coin.Toss(round)
+		assert.True(t, ok)
+		otherValue, _ := other.Toss(round)
+		assert.Equal(t, value, otherValue)
+		if value {
+			heads++
+		}
+	}
+	assert.True(t, heads > 25 && heads < 75, "%d heads", heads)
+}
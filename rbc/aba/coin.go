@@ -0,0 +1,87 @@
+package aba
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/gopricy/mao-bft/rbc/common"
+	"github.com/gopricy/mao-bft/rbc/thresholdsign"
+)
+
+// Coin is the common coin of an agreement, all correct nodes must get the same value in each round.
+type Coin interface {
+	// Toss returns the coin of round, ok is false if it isn't known yet. The owner of a coin that's tossed with
+	// messages from peers calls Agreement.Advance once it's known.
+	Toss(round int64) (value bool, ok bool)
+}
+
+// LocalCoin is a coin that each node tosses on its own, as a hash of the cluster, the instance and the round. It needs
+// no messages, but it's known in advance, so a scheduler that sees it can delay termination.
+type LocalCoin struct {
+	Cluster  string
+	Instance common.Instance
+}
+
+// NewLocalCoin returns the local coin of instance in the cluster of node.
+func NewLocalCoin(node *common.Common, instance common.Instance) LocalCoin {
+	return LocalCoin{Cluster: node.ClusterID, Instance: instance}
+}
+
+func (c LocalCoin) Toss(round int64) (bool, bool) {
+	h := sha256.New()
+	h.Write([]byte(c.Cluster))
+	h.Write([]byte(c.Instance.String()))
+	binary.Write(h, binary.BigEndian, round)
+	return h.Sum(nil)[0]&1 == 1, true
+}
+
+// SharedCoin is a coin that nodes toss together, Agreement broadcasts the share of this node in COIN once it needs the
+// coin of a round, and hands shares from peers to the coin.
+type SharedCoin interface {
+	Coin
+	// Share returns the share of this node of the coin of round.
+	Share(round int64) []byte
+	// AddShare adds the share of peer of the coin of round, it returns error if the share is invalid.
+	AddShare(peer string, round int64, share []byte) error
+	// Forget drops the state of the coin of round, once the agreement moved past it.
+	Forget(round int64)
+}
+
+// ThresholdCoin is a shared coin on a thresholdsign.Coin that's dealt to peers of a cluster in order of their names.
+// With a threshold of f+1, it's unknown until a correct node shares it.
+type ThresholdCoin struct {
+	node     *common.Common
+	instance common.Instance
+	coin     *thresholdsign.Coin
+}
+
+// NewThresholdCoin returns the threshold coin of instance, coin can be shared by agreements of all instances of node.
+func NewThresholdCoin(node *common.Common, instance common.Instance, coin *thresholdsign.Coin) *ThresholdCoin {
+	return &ThresholdCoin{node: node, instance: instance, coin: coin}
+}
+
+// name returns what's signed for the coin of round.
+func (c *ThresholdCoin) name(round int64) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(c.node.ClusterID)
+	buf.WriteString(c.instance.String())
+	binary.Write(&buf, binary.BigEndian, round)
+	return buf.Bytes()
+}
+
+func (c *ThresholdCoin) Toss(round int64) (bool, bool) {
+	return c.coin.Toss(c.name(round))
+}
+
+func (c *ThresholdCoin) Share(round int64) []byte {
+	return c.coin.Share(c.name(round))
+}
+
+func (c *ThresholdCoin) AddShare(peer string, round int64, share []byte) error {
+	return c.coin.AddShare(c.node.ShardIndex(peer), c.name(round), share)
+}
+
+func (c *ThresholdCoin) Forget(round int64) {
+	c.coin.Forget(c.name(round))
+}
@@ -0,0 +1,290 @@
+// Package acs orders batches without a leader, it's the asynchronous common subset of HoneyBadgerBFT. In each epoch
+// every node broadcasts its own batch with RBC, and the nodes run one binary agreement per proposer on whether its
+// batch is in the common subset. Batches in the subset are handed to Application in order of their proposers.
+package acs
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/gopricy/mao-bft/pb"
+	"github.com/gopricy/mao-bft/rbc/aba"
+	"github.com/gopricy/mao-bft/rbc/common"
+	"github.com/gopricy/mao-bft/rbc/erasure"
+	"github.com/gopricy/mao-bft/rbc/merkle"
+	"github.com/gopricy/mao-bft/rbc/thresholdsign"
+	"github.com/pkg/errors"
+)
+
+// maxEpochsAhead bounds how far ahead of its current epoch a node takes RBC and agreement messages, so that a faulty
+// peer can't make it hold unbounded state.
+const maxEpochsAhead = 8
+
+// Node is a node in ACS mode, all nodes are equal and none of them leads.
+type Node struct {
+	common.Common
+	app common.Application
+	// coin is the share of this node of the threshold coin, agreements of all instances toss it.
+	coin *thresholdsign.Coin
+
+	// epoch is the first epoch that isn't output yet.
+	epoch  int64
+	epochs map[int64]*epoch
+	// pending are batches waiting for an epoch to be proposed in.
+	pending [][]byte
+	mu      sync.Mutex
+}
+
+// epoch is the state of ACS in an epoch.
+type epoch struct {
+	number   int64
+	proposed bool
+	// proposals maps each proposer to its batch delivered by RBC.
+	proposals  map[string][]byte
+	agreements map[string]*aba.Agreement
+}
+
+// NewNode creates a node in ACS mode, app receives batches in the common subset of each epoch. coin is the share of
+// this node of a threshold coin dealt to all peers in order of their names, with a threshold of f+1.
+func NewNode(name string, app common.Application, setting common.RBCSetting, privateKey *[64]byte,
+	coin *thresholdsign.Coin) *Node {
+	n := &Node{app: app, coin: coin, epochs: make(map[int64]*epoch)}
+	n.Common = common.NewCommon(name, setting, batchApp{app}, privateKey)
+	n.OnDeliver(n.deliver)
+	n.HandleAgreement(n.handleAgreement)
+	n.CheckEpochs(n.checkEpoch)
+	return n
+}
+
+// batchApp takes batches delivered by RBC from app, they're handed to app once they're in the common subset.
+type batchApp struct {
+	common.Application
+}
+
+func (batchApp) RBCReceive([]byte) (bool, error) { return false, nil }
+
+// Batches are only kept by ACS until their epoch is output.
+func (batchApp) IsCommitted([]byte) bool { return true }
+
+// Propose proposes batch in the next epoch this node hasn't proposed in, a node proposes one batch per epoch.
+func (n *Node) Propose(batch []byte) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.pending = append(n.pending, batch)
+	n.proposeIn(n.getEpoch(n.epoch))
+}
+
+// Epoch returns the first epoch that isn't output yet.
+func (n *Node) Epoch() int64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.epoch
+}
+
+// getEpoch must be called with n.mu held, it returns nil if number is already output.
+func (n *Node) getEpoch(number int64) *epoch {
+	if number < n.epoch {
+		return nil
+	}
+	e, ok := n.epochs[number]
+	if !ok {
+		e = &epoch{
+			number:     number,
+			proposals:  make(map[string][]byte),
+			agreements: make(map[string]*aba.Agreement),
+		}
+		for _, p := range n.Peers() {
+			instance := common.Instance{Broadcaster: p.Name, Epoch: number}
+			e.agreements[p.Name] = aba.New(&n.Common, instance, aba.NewThresholdCoin(&n.Common, instance, n.coin))
+		}
+		n.epochs[number] = e
+	}
+	return e
+}
+
+// proposeIn must be called with n.mu held. It broadcasts the next pending batch in e, or an empty batch if there's
+// none, since e can't complete without batches of N-f nodes.
+func (n *Node) proposeIn(e *epoch) {
+	if e == nil || e.proposed || e.number != n.epoch {
+		return
+	}
+	e.proposed = true
+	var batch []byte
+	if len(n.pending) > 0 {
+		batch, n.pending = n.pending[0], n.pending[1:]
+	}
+	if err := n.broadcast(common.Instance{Broadcaster: n.Name(), Epoch: e.number}, batch); err != nil {
+		n.Infof("Failed to propose in epoch %d: %s", e.number, err.Error())
+	}
+}
+
+// broadcast sends batch in instance with RBC.
+func (n *Node) broadcast(instance common.Instance, batch []byte) error {
+	if n.Replicated(batch) {
+		n.BroadcastReplicated(instance, nil, batch)
+		return nil
+	}
+	shards, err := erasure.Split(batch, n.ByzantineLimit, len(n.AllPeers))
+	if err != nil {
+		return err
+	}
+	var contents []merkle.Content
+	for _, s := range shards {
+		contents = append(contents, merkle.BytesContent(s))
+	}
+	tree := &merkle.MerkleTree{}
+	if err := tree.Init(contents); err != nil {
+		return err
+	}
+	for _, p := range n.Peers() {
+		i := n.ShardIndex(p.Name)
+		proof, err := merkle.GetProofAt(tree, i)
+		if err != nil {
+			return err
+		}
+		payload := &pb.Payload{MerkleProof: proof, Data: shards[i], Instance: instance.Pb()}
+		payload.Signature = n.SignEnvelope(common.PayloadEnvelope(pb.MessageType_MT_PREPARE, payload))
+		n.SendMessage(p, common.KindPrepare, payload)
+	}
+	return nil
+}
+
+// checkEpoch accepts RBC messages of epochs that aren't output yet, up to maxEpochsAhead.
+func (n *Node) checkEpoch(number int64) error {
+	current := n.Epoch()
+	switch {
+	case number < current:
+		return errors.Wrapf(common.ErrStale, "epoch %d is output", number)
+	case number >= current+maxEpochsAhead:
+		return errors.Errorf("epoch %d is too far ahead of %d", number, current)
+	}
+	return nil
+}
+
+// deliver takes a batch delivered by RBC, its proposer gets vote 1 in agreement. A proposer has one batch per epoch
+// in sequence 0, batches in other sequences are ignored, so a faulty proposer can't swap its batch by broadcasting
+// another one.
+func (n *Node) deliver(d common.Delivery) {
+	if d.Instance.Sequence != 0 {
+		n.Infof("Ignored batch of %s in sequence %d", d.Instance.Broadcaster, d.Instance.Sequence)
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if d.Instance.Epoch >= n.epoch+maxEpochsAhead {
+		return
+	}
+	e := n.getEpoch(d.Instance.Epoch)
+	if e == nil {
+		return
+	}
+	if _, ok := e.agreements[d.Instance.Broadcaster]; !ok {
+		return
+	}
+	if _, ok := e.proposals[d.Instance.Broadcaster]; ok {
+		return
+	}
+	e.proposals[d.Instance.Broadcaster] = d.Data
+	e.agreements[d.Instance.Broadcaster].Input(true)
+	n.proposeIn(e)
+	n.progress(e)
+}
+
+// handleAgreement serves binary agreement messages.
+func (n *Node) handleAgreement(peer string, req *pb.AgreementMessage) error {
+	instance, err := common.InstanceFromPb(req.Instance)
+	if err != nil {
+		return err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if instance.Epoch >= n.epoch+maxEpochsAhead {
+		return errors.Errorf("epoch %d is too far ahead of %d", instance.Epoch, n.epoch)
+	}
+	e := n.getEpoch(instance.Epoch)
+	if e == nil {
+		return nil
+	}
+	a, ok := e.agreements[instance.Broadcaster]
+	if !ok {
+		return errors.Errorf("%s is not a peer", instance.Broadcaster)
+	}
+	if err := a.Handle(peer, req); err != nil {
+		return err
+	}
+	n.proposeIn(e)
+	n.progress(e)
+	return nil
+}
+
+// progress must be called with n.mu held. Once N-f agreements decide 1, this node votes 0 for proposers that it
+// hasn't delivered batch of. Once all agreements decide and batches in the common subset are delivered, epochs
+// are output in order.
+func (n *Node) progress(e *epoch) {
+	ones := 0
+	for _, a := range e.agreements {
+		if value, ok := a.Decision(); ok && value {
+			ones++
+		}
+	}
+	if ones >= len(n.AllPeers)-n.ByzantineLimit {
+		for _, p := range n.Peers() {
+			e.agreements[p.Name].Input(false)
+		}
+	}
+	for {
+		current, ok := n.epochs[n.epoch]
+		if !ok || !n.complete(current) {
+			return
+		}
+		n.output(current)
+	}
+}
+
+// complete returns whether all agreements of e decided, and batches in the common subset are delivered.
+func (n *Node) complete(e *epoch) bool {
+	for proposer, a := range e.agreements {
+		value, ok := a.Decision()
+		if !ok {
+			return false
+		}
+		if _, delivered := e.proposals[proposer]; value && !delivered {
+			return false
+		}
+	}
+	return true
+}
+
+// output hands batches in the common subset of e to App in order of their proposers, and moves to the next epoch.
+func (n *Node) output(e *epoch) {
+	var subset []string
+	for proposer, a := range e.agreements {
+		if value, _ := a.Decision(); value {
+			subset = append(subset, proposer)
+		}
+	}
+	sort.Strings(subset)
+	n.Infof("Epoch %d outputs batches of %v", e.number, subset)
+	for _, proposer := range subset {
+		batch := e.proposals[proposer]
+		if len(batch) == 0 {
+			continue
+		}
+		if _, err := n.app.RBCReceive(batch); err != nil {
+			n.Infof("Failed to apply batch of %s in epoch %d: %s", proposer, e.number, err.Error())
+		}
+	}
+	// Agreements forget coins of rounds they moved past, but not of rounds after they halt.
+	for _, a := range e.agreements {
+		coin := aba.NewThresholdCoin(&n.Common, a.Instance(), n.coin)
+		for round := a.Round(); round < a.Round()+aba.MaxRoundsAhead; round++ {
+			coin.Forget(round)
+		}
+	}
+	delete(n.epochs, e.number)
+	n.epoch++
+	// Peers may have started the next epoch, or batches may be waiting for it.
+	if _, ok := n.epochs[n.epoch]; ok || len(n.pending) > 0 {
+		n.proposeIn(n.getEpoch(n.epoch))
+	}
+}
@@ -0,0 +1,172 @@
+package acs_test
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gopricy/mao-bft/rbc/adversary"
+	"github.com/gopricy/mao-bft/rbc/common"
+	"github.com/gopricy/mao-bft/rbc/sim"
+	"github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	logging.SetLevel(logging.WARNING, "RBC")
+}
+
+func config(seed int64) sim.Config {
+	return sim.Config{
+		Seed:           seed,
+		ByzantineLimit: 1,
+		MinLatency:     time.Millisecond,
+		MaxLatency:     50 * time.Millisecond,
+		Reorder:        true,
+		ACS:            true,
+	}
+}
+
+// propose makes each node in names propose n batches.
+func propose(s *sim.Simulation, names []string, n int) {
+	for _, name := range names {
+		for i := 0; i < n; i++ {
+			s.Propose(time.Duration(i)*10*time.Millisecond, name, []byte(fmt.Sprintf("batch %d of %s", i, name)))
+		}
+	}
+}
+
+// assertSameOutput checks that all nodes in names hand the same batches to their apps, and returns them.
+func assertSameOutput(t *testing.T, s *sim.Simulation, names []string) [][]byte {
+	res := s.App(names[0]).Delivered
+	for _, name := range names[1:] {
+		assert.Equal(t, res, s.App(name).Delivered, name)
+	}
+	return res
+}
+
+func TestACS_OutputsCommonSubset(t *testing.T) {
+	// Each coin toss takes pairings, so a few seeds of each mode are run.
+	for seed := int64(1); seed <= 4; seed++ {
+		c := config(seed)
+		// Batches of even seeds are broadcast in replicated mode.
+		if seed%2 == 0 {
+			c.ReplicatedLimit = 1024
+		}
+		s := sim.New(c)
+		propose(s, s.Names(), 1)
+		s.Run(0)
+
+		output := assertSameOutput(t, s, s.Names())
+		// The subset holds batches of at least N-f nodes, in order of their proposers.
+		assert.True(t, len(output) >= 3, "seed %d", seed)
+		assert.True(t, sort.SliceIsSorted(output, func(i, j int) bool {
+			return string(output[i][8:]) < string(output[j][8:])
+		}), "seed %d", seed)
+		for _, n := range s.ACSNodes {
+			assert.Equal(t, int64(1), n.Epoch())
+		}
+	}
+}
+
+func TestACS_Epochs(t *testing.T) {
+	s := sim.New(config(1))
+	propose(s, s.Names(), 3)
+	s.Run(0)
+
+	output := assertSameOutput(t, s, s.Names())
+	assert.True(t, len(output) >= 9)
+	for _, n := range s.ACSNodes {
+		assert.Equal(t, int64(3), n.Epoch())
+	}
+	// Batches of an epoch come before batches of the next.
+	assert.Equal(t, "batch 0", string(output[0][:7]))
+	assert.Equal(t, "batch 2", string(output[len(output)-1][:7]))
+}
+
+func TestACS_OneProposer(t *testing.T) {
+	s := sim.New(config(1))
+	// Other nodes join the epoch with empty batches, which aren't output.
+	s.Propose(0, "f2", []byte("batch 0 of f2"))
+	s.Run(0)
+
+	for _, name := range s.Names() {
+		assert.Equal(t, [][]byte{[]byte("batch 0 of f2")}, s.App(name).Delivered, name)
+	}
+}
+
+func TestACS_OtherSequence(t *testing.T) {
+	c := config(1)
+	c.ReplicatedLimit = 1024
+	s := sim.New(c)
+	// A batch broadcast in another sequence of the epoch doesn't take the place of the proposed one.
+	f2 := s.ACSNodes[indexOf(s.Names(), "f2")]
+	s.AfterFunc(0, func() {
+		f2.BroadcastReplicated(common.Instance{Broadcaster: "f2", Sequence: 1}, nil, []byte("swapped batch of f2"))
+	})
+	s.Propose(10*time.Millisecond, "f2", []byte("batch 0 of f2"))
+	s.Run(0)
+
+	for _, name := range s.Names() {
+		assert.Equal(t, [][]byte{[]byte("batch 0 of f2")}, s.App(name).Delivered, name)
+	}
+}
+
+func TestACS_Faults(t *testing.T) {
+	strategies := []string{sim.Crash, "drop", "equivocate", "mutate", "unsigned"}
+	if testing.Short() {
+		strategies = []string{sim.Crash, "equivocate"}
+	}
+	for _, strategy := range strategies {
+		s := sim.New(config(1))
+		if strategy == sim.Crash {
+			s.Crash("f1")
+		} else {
+			a, err := adversary.New(strategy)
+			assert.Nil(t, err)
+			s.Node("f1").SetAdversary(a)
+		}
+		correct := []string{"f2", "f3", sim.LeaderName}
+		propose(s, s.Names(), 2)
+		s.Run(0)
+
+		output := assertSameOutput(t, s, correct)
+		// Batches of all correct nodes are in each epoch.
+		assert.True(t, len(output) >= 6, strategy)
+		for _, name := range correct {
+			assert.Equal(t, int64(2), s.ACSNodes[indexOf(s.Names(), name)].Epoch(), strategy)
+		}
+	}
+}
+
+func TestACS_ClusterSizes(t *testing.T) {
+	for _, c := range []struct{ n, f int }{{5, 1}, {7, 2}} {
+		config := config(1)
+		config.Nodes, config.ByzantineLimit = c.n, c.f
+		s := sim.New(config)
+		// The last f nodes crash.
+		names := s.Names()
+		for _, name := range names[c.n-c.f:] {
+			s.Crash(name)
+		}
+		correct := names[:c.n-c.f]
+		propose(s, s.Names(), 1)
+		s.Run(0)
+
+		output := assertSameOutput(t, s, correct)
+		assert.True(t, len(output) >= c.n-c.f, "n %d, f %d", c.n, c.f)
+		for _, name := range correct {
+			assert.Equal(t, int64(1), s.ACSNodes[indexOf(names, name)].Epoch(), "n %d, f %d", c.n, c.f)
+		}
+	}
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,471 @@
+// Package adversary is a library of Byzantine strategies, a node is corrupted by common.Common.SetAdversary.
+package adversary
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/gopricy/mao-bft/pb"
+	"github.com/gopricy/mao-bft/rbc/common"
+	"github.com/gopricy/mao-bft/rbc/erasure"
+	"github.com/gopricy/mao-bft/rbc/merkle"
+	mao_utils "github.com/gopricy/mao-bft/utils"
+	"github.com/pkg/errors"
+)
+
+// DefaultDelay is how long Delay holds messages.
+const DefaultDelay = 500 * time.Millisecond
+
+// Targets is the set of peers a strategy acts on, it targets all peers if it's empty.
+type Targets map[string]bool
+
+func NewTargets(peers ...string) Targets {
+	res := make(Targets)
+	for _, p := range peers {
+		res[p] = true
+	}
+	return res
+}
+
+// Has returns whether peer is targeted.
+func (t Targets) Has(peer string) bool {
+	return len(t) == 0 || t[peer]
+}
+
+// Passive lets all messages through, strategies embed it to override only one hook.
+type Passive struct{}
+
+func (Passive) Outgoing(node *common.Common, m common.Message) []common.Message {
+	return []common.Message{m}
+}
+
+func (Passive) Incoming(node *common.Common, m common.Message) []common.Message {
+	return []common.Message{m}
+}
+
+// Drop drops all messages to targets.
+type Drop struct {
+	Passive
+	Targets Targets
+}
+
+func (d *Drop) Outgoing(node *common.Common, m common.Message) []common.Message {
+	if d.Targets.Has(m.Peer) {
+		return nil
+	}
+	return []common.Message{m}
+}
+
+// Withhold drops PREPAREs to targets, so they only learn a broadcast from ECHOs of others.
+type Withhold struct {
+	Passive
+	Targets Targets
+}
+
+func (w *Withhold) Outgoing(node *common.Common, m common.Message) []common.Message {
+	if m.Kind == common.KindPrepare && w.Targets.Has(m.Peer) {
+		return nil
+	}
+	return []common.Message{m}
+}
+
+// Ignore drops all messages from targets, as if they're down.
+type Ignore struct {
+	Passive
+	Targets Targets
+}
+
+func (i *Ignore) Incoming(node *common.Common, m common.Message) []common.Message {
+	if i.Targets.Has(m.Peer) {
+		return nil
+	}
+	return []common.Message{m}
+}
+
+// Delay holds messages to targets for Delay.
+type Delay struct {
+	Passive
+	Targets Targets
+	Delay   time.Duration
+}
+
+func (d *Delay) Outgoing(node *common.Common, m common.Message) []common.Message {
+	if d.Targets.Has(m.Peer) {
+		m.Delay += d.Delay
+	}
+	return []common.Message{m}
+}
+
+// Duplicate sends each message to targets Copies times.
+type Duplicate struct {
+	Passive
+	Targets Targets
+	Copies  int
+}
+
+func (d *Duplicate) Outgoing(node *common.Common, m common.Message) []common.Message {
+	if !d.Targets.Has(m.Peer) {
+		return []common.Message{m}
+	}
+	res := make([]common.Message, d.Copies)
+	for i := range res {
+		res[i] = m
+	}
+	return res
+}
+
+// Mutate corrupts data of PREPARE and ECHO, root of READY, and values of binary agreement messages to targets.
+// Messages are signed again, so they're only caught by checks beyond signature.
+type Mutate struct {
+	Passive
+	Targets Targets
+}
+
+func (mu *Mutate) Outgoing(node *common.Common, m common.Message) []common.Message {
+	if !mu.Targets.Has(m.Peer) {
+		return []common.Message{m}
+	}
+	switch body := m.Body.(type) {
+	case *pb.Payload:
+		payload := proto.Clone(body).(*pb.Payload)
+		payload.Data = flip(payload.Data)
+		payload.Signature = node.SignEnvelope(common.PayloadEnvelope(messageType(m.Kind), payload))
+		m.Body = payload
+	case *pb.ReadyRequest:
+		ready := proto.Clone(body).(*pb.ReadyRequest)
+		ready.MerkleRoot = flip(ready.MerkleRoot)
+		ready.Signature = node.SignEnvelope(common.ReadyEnvelope(ready))
+		m.Body = ready
+	case *pb.AgreementMessage:
+		agreement := proto.Clone(body).(*pb.AgreementMessage)
+		agreement.Value = !agreement.Value
+		for i, value := range agreement.Values {
+			agreement.Values[i] = !value
+		}
+		if len(agreement.Share) > 0 {
+			agreement.Share = append([]byte{}, agreement.Share...)
+			agreement.Share[len(agreement.Share)-1] ^= 1
+		}
+		node.SignAgreement(agreement)
+		m.Body = agreement
+	}
+	return []common.Message{m}
+}
+
+// Equivocate sends targets PREPAREs of another block than the one sent to other peers. In AVID mode the block exists
+// only in the shard of each target, so it can never be delivered. In replicated mode targets get the whole block, so
+// it's delivered instead of the original one if enough peers are targets.
+type Equivocate struct {
+	Passive
+	Targets Targets
+}
+
+func (e *Equivocate) Outgoing(node *common.Common, m common.Message) []common.Message {
+	if m.Kind != common.KindPrepare || !e.Targets.Has(m.Peer) {
+		return []common.Message{m}
+	}
+	prepare, err := conflictingPrepare(node, m.Peer, m.Body.(*pb.Payload))
+	if err != nil {
+		node.Infof("Can't equivocate to %s: %s", m.Peer, err.Error())
+		return []common.Message{m}
+	}
+	m.Body = prepare
+	return []common.Message{m}
+}
+
+// conflictingPrepare returns a PREPARE of another root than prepare for target.
+func conflictingPrepare(node *common.Common, target string, prepare *pb.Payload) (*pb.Payload, error) {
+	if prepare.Mode == pb.BroadcastMode_BM_REPLICATED {
+		res := proto.Clone(prepare).(*pb.Payload)
+		res.Data = flip(prepare.Data)
+		res.Signature = node.SignEnvelope(common.PayloadEnvelope(pb.MessageType_MT_PREPARE, res))
+		return res, nil
+	}
+	slot := node.ShardIndex(target)
+	if slot < 0 {
+		return nil, errors.Errorf("%s has no shard", target)
+	}
+	data := flip(prepare.Data)
+	contents := make([]merkle.Content, len(node.AllPeers))
+	for i := range contents {
+		contents[i] = merkle.BytesContent(fmt.Sprintf("shard %d of a block equivocated by %s", i, node.Name()))
+	}
+	contents[slot] = merkle.BytesContent(data)
+	tree := &merkle.MerkleTree{}
+	if err := tree.Init(contents); err != nil {
+		return nil, err
+	}
+	proof, err := merkle.GetProof(tree, contents[slot])
+	if err != nil {
+		return nil, err
+	}
+	res := proto.Clone(prepare).(*pb.Payload)
+	res.MerkleProof = proof
+	res.Data = data
+	res.Signature = node.SignEnvelope(common.PayloadEnvelope(pb.MessageType_MT_PREPARE, res))
+	return res, nil
+}
+
+// ForkOffset is how far ahead of the original instance Fork proposes its block in the same epoch, it's within the
+// default sequence window.
+const ForkOffset = common.DefaultSequenceWindow / 2
+
+// Fork proposes another block on the parent of each broadcast to targets DefaultDelay later, once the original one
+// may be delivered. It's in another instance: ForkOffset broadcasts ahead in the same epoch, or in the next epoch if
+// NextEpoch is set. Every target gets a shard of the same block, so it's delivered unless correct nodes refuse to
+// vote twice on one parent.
+type Fork struct {
+	Passive
+	Targets   Targets
+	NextEpoch bool
+}
+
+func (f *Fork) Outgoing(node *common.Common, m common.Message) []common.Message {
+	if m.Kind != common.KindPrepare || !f.Targets.Has(m.Peer) {
+		return []common.Message{m}
+	}
+	prepare := m.Body.(*pb.Payload)
+	instance, err := common.InstanceFromPb(prepare.Instance)
+	if err != nil || len(prepare.PrevHash) == 0 {
+		return []common.Message{m}
+	}
+	if f.NextEpoch {
+		instance.Epoch++
+	} else {
+		instance.Sequence += ForkOffset
+	}
+	forked, err := forkPrepare(node, m.Peer, prepare, instance)
+	if err != nil {
+		node.Infof("Can't fork to %s: %s", m.Peer, err.Error())
+		return []common.Message{m}
+	}
+	return []common.Message{m, {Kind: m.Kind, Peer: m.Peer, Body: forked, Delay: m.Delay + DefaultDelay}}
+}
+
+// forkPrepare returns the PREPARE for target of a block on the parent of prepare in instance. The block only depends
+// on instance, so all targets get the same one.
+func forkPrepare(node *common.Common, target string, prepare *pb.Payload, instance common.Instance) (*pb.Payload, error) {
+	txn := &pb.Transaction{
+		TransactionUuid: "fork " + instance.String(),
+		Message:         &pb.Transaction_DepositMsg{DepositMsg: &pb.DepositMessage{AccountId: node.Name(), Amount: 1}},
+	}
+	block, err := mao_utils.CreateBlockFromTxsAndPrevHash([]*pb.Transaction{txn}, prepare.PrevHash)
+	if err != nil {
+		return nil, err
+	}
+	data, err := mao_utils.EncodeBlock(block)
+	if err != nil {
+		return nil, err
+	}
+	res := &pb.Payload{Mode: prepare.Mode, PrevHash: prepare.PrevHash, Data: data, Instance: instance.Pb()}
+	if prepare.Mode != pb.BroadcastMode_BM_REPLICATED {
+		slot := node.ShardIndex(target)
+		if slot < 0 {
+			return nil, errors.Errorf("%s has no shard", target)
+		}
+		shards, err := erasure.Split(data, node.ByzantineLimit, len(node.AllPeers))
+		if err != nil {
+			return nil, err
+		}
+		contents := make([]merkle.Content, len(shards))
+		for i, shard := range shards {
+			contents[i] = merkle.BytesContent(shard)
+		}
+		tree := &merkle.MerkleTree{}
+		if err := tree.Init(contents); err != nil {
+			return nil, err
+		}
+		if res.MerkleProof, err = merkle.GetProofAt(tree, slot); err != nil {
+			return nil, err
+		}
+		res.Data = shards[slot]
+	}
+	res.Signature = node.SignEnvelope(common.PayloadEnvelope(pb.MessageType_MT_PREPARE, res))
+	return res, nil
+}
+
+// SameShard sends targets the shard of the first PREPARE of each broadcast, instead of their own shards.
+type SameShard struct {
+	Passive
+	Targets Targets
+	// first is the first PREPARE of the latest broadcast.
+	first *pb.Payload
+	mu    sync.Mutex
+}
+
+func (s *SameShard) Outgoing(node *common.Common, m common.Message) []common.Message {
+	if m.Kind != common.KindPrepare {
+		return []common.Message{m}
+	}
+	prepare := m.Body.(*pb.Payload)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.first == nil || !proto.Equal(s.first.Instance, prepare.Instance) {
+		s.first = prepare
+	}
+	if s.Targets.Has(m.Peer) {
+		m.Body = s.first
+	}
+	return []common.Message{m}
+}
+
+// Unsigned strips signatures of PREPARE, ECHO, READY and binary agreement messages to targets.
+type Unsigned struct {
+	Passive
+	Targets Targets
+}
+
+func (u *Unsigned) Outgoing(node *common.Common, m common.Message) []common.Message {
+	if !u.Targets.Has(m.Peer) {
+		return []common.Message{m}
+	}
+	switch body := m.Body.(type) {
+	case *pb.Payload:
+		payload := proto.Clone(body).(*pb.Payload)
+		payload.Signature = nil
+		m.Body = payload
+	case *pb.ReadyRequest:
+		ready := proto.Clone(body).(*pb.ReadyRequest)
+		ready.Signature = nil
+		m.Body = ready
+	case *pb.AgreementMessage:
+		agreement := proto.Clone(body).(*pb.AgreementMessage)
+		agreement.Signature = nil
+		m.Body = agreement
+	}
+	return []common.Message{m}
+}
+
+// PrematureReady sends READY along with each ECHO to targets, without waiting for ECHOs of others.
+type PrematureReady struct {
+	Passive
+	Targets Targets
+}
+
+func (p *PrematureReady) Outgoing(node *common.Common, m common.Message) []common.Message {
+	if m.Kind != common.KindEcho || !p.Targets.Has(m.Peer) {
+		return []common.Message{m}
+	}
+	echo := m.Body.(*pb.Payload)
+	root := common.PayloadRoot(echo)
+	if root == nil {
+		return []common.Message{m}
+	}
+	ready := &pb.ReadyRequest{MerkleRoot: root, Instance: echo.Instance}
+	ready.Signature = node.SignEnvelope(common.ReadyEnvelope(ready))
+	return []common.Message{m, {Kind: common.KindReady, Peer: m.Peer, Body: ready, Delay: m.Delay}}
+}
+
+// chain applies adversaries in order.
+type chain []common.Adversary
+
+// Chain returns an adversary that passes each message through adversaries in order.
+func Chain(adversaries ...common.Adversary) common.Adversary {
+	return chain(adversaries)
+}
+
+func (c chain) Outgoing(node *common.Common, m common.Message) []common.Message {
+	res := []common.Message{m}
+	for _, a := range c {
+		var next []common.Message
+		for _, m := range res {
+			next = append(next, a.Outgoing(node, m)...)
+		}
+		res = next
+	}
+	return res
+}
+
+func (c chain) Incoming(node *common.Common, m common.Message) []common.Message {
+	res := []common.Message{m}
+	for _, a := range c {
+		var next []common.Message
+		for _, m := range res {
+			next = append(next, a.Incoming(node, m)...)
+		}
+		res = next
+	}
+	return res
+}
+
+// strategy is a built-in strategy that can be selected by name.
+type strategy struct {
+	description string
+	build       func(targets Targets) common.Adversary
+}
+
+var strategies = map[string]strategy{
+	"honest": {"behave honestly", func(Targets) common.Adversary { return nil }},
+	"drop":   {"drop all messages to targets", func(t Targets) common.Adversary { return &Drop{Targets: t} }},
+	"withhold": {"withhold PREPARE from targets",
+		func(t Targets) common.Adversary { return &Withhold{Targets: t} }},
+	"ignore": {"ignore all messages from targets", func(t Targets) common.Adversary { return &Ignore{Targets: t} }},
+	"delay": {fmt.Sprintf("delay messages to targets by %s", DefaultDelay),
+		func(t Targets) common.Adversary { return &Delay{Targets: t, Delay: DefaultDelay} }},
+	"duplicate": {"send each message to targets twice",
+		func(t Targets) common.Adversary { return &Duplicate{Targets: t, Copies: 2} }},
+	"mutate": {"corrupt data of messages to targets",
+		func(t Targets) common.Adversary { return &Mutate{Targets: t} }},
+	"equivocate": {"propose another block to targets",
+		func(t Targets) common.Adversary { return &Equivocate{Targets: t} }},
+	"fork": {"propose another block on the same parent to targets in another instance",
+		func(t Targets) common.Adversary { return &Fork{Targets: t} }},
+	"fork-epoch": {"propose another block on the same parent to targets in the next epoch",
+		func(t Targets) common.Adversary { return &Fork{Targets: t, NextEpoch: true} }},
+	"same-shard": {"send the same data shard to targets",
+		func(t Targets) common.Adversary { return &SameShard{Targets: t} }},
+	"unsigned": {"send messages to targets without signature",
+		func(t Targets) common.Adversary { return &Unsigned{Targets: t} }},
+	"premature-ready": {"send READY to targets before getting enough ECHOs",
+		func(t Targets) common.Adversary { return &PrematureReady{Targets: t} }},
+}
+
+// New returns the built-in strategy called name against targets, all peers are targeted if there's none. The
+// honest strategy is nil.
+func New(name string, targets ...string) (common.Adversary, error) {
+	s, ok := strategies[name]
+	if !ok {
+		return nil, errors.Errorf("unknown strategy %q", name)
+	}
+	return s.build(NewTargets(targets...)), nil
+}
+
+// Names returns names of all built-in strategies in order.
+func Names() []string {
+	var res []string
+	for name := range strategies {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Describe returns what the built-in strategy called name does.
+func Describe(name string) string {
+	return strategies[name].description
+}
+
+func messageType(kind string) pb.MessageType {
+	switch kind {
+	case common.KindPrepare:
+		return pb.MessageType_MT_PREPARE
+	case common.KindEcho:
+		return pb.MessageType_MT_ECHO
+	case common.KindReady:
+		return pb.MessageType_MT_READY
+	}
+	return pb.MessageType_MT_UNKNOWN
+}
+
+// flip returns a copy of data with its first byte changed.
+func flip(data []byte) []byte {
+	if len(data) == 0 {
+		return []byte{0}
+	}
+	res := append([]byte(nil), data...)
+	res[0] ^= 0xff
+	return res
+}
@@ -0,0 +1,260 @@
+package adversary
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/gopricy/mao-bft/pb"
+	"github.com/gopricy/mao-bft/rbc/common"
+	"github.com/gopricy/mao-bft/rbc/erasure"
+	"github.com/gopricy/mao-bft/rbc/merkle"
+	"github.com/gopricy/mao-bft/rbc/sign"
+	"github.com/stretchr/testify/assert"
+)
+
+type testApp struct{}
+
+func (testApp) RBCReceive([]byte) (bool, error)                         { return true, nil }
+func (testApp) IsCommitted([]byte) bool                                 { return false }
+func (testApp) GetSyncQuestion() (*pb.SyncRequest, error)               { return nil, nil }
+func (testApp) GetSyncAnswer(*pb.SyncRequest) (*pb.SyncResponse, error) { return nil, nil }
+
+// newCluster returns node mao, and f1 that receives from it.
+func newCluster() (*common.Common, *common.Common) {
+	peers := make(map[string]*common.Peer)
+	keys := make(map[string]*[64]byte)
+	for _, name := range []string{"mao", "f1", "f2", "f3"} {
+		pub, priv := sign.GenerateKey()
+		peers[name] = &common.Peer{Name: name, PubKey: pub}
+		keys[name] = priv
+	}
+	rs := common.RBCSetting{AllPeers: peers, ByzantineLimit: 1, ClusterID: "test"}
+	node := common.NewCommon("mao", rs, testApp{}, keys["mao"])
+	receiver := common.NewCommon("f1", rs, testApp{}, keys["f1"])
+	return &node, &receiver
+}
+
+// prepares returns signed PREPAREs of data for each peer of node.
+func prepares(t *testing.T, node *common.Common, sequence int64, data string) map[string]*pb.Payload {
+	shards, err := erasure.Split([]byte(data), node.ByzantineLimit, len(node.AllPeers))
+	assert.Nil(t, err)
+	var contents []merkle.Content
+	for _, s := range shards {
+		contents = append(contents, merkle.BytesContent(s))
+	}
+	tree := &merkle.MerkleTree{}
+	assert.Nil(t, tree.Init(contents))
+	res := make(map[string]*pb.Payload)
+	for name := range node.AllPeers {
+		i := node.ShardIndex(name)
+		proof, err := merkle.GetProof(tree, contents[i])
+		assert.Nil(t, err)
+		payload := &pb.Payload{
+			MerkleProof: proof,
+			Data:        shards[i],
+			PrevHash:    []byte("parent"),
+			Instance:    common.Instance{Broadcaster: node.Name(), Sequence: sequence}.Pb(),
+		}
+		payload.Signature = node.SignEnvelope(common.PayloadEnvelope(pb.MessageType_MT_PREPARE, payload))
+		res[name] = payload
+	}
+	return res
+}
+
+func prepareTo(peer string, payload *pb.Payload) common.Message {
+	return common.Message{Kind: common.KindPrepare, Peer: peer, Body: payload}
+}
+
+// verified returns whether receiver accepts signature of m from node mao.
+func verified(receiver *common.Common, m common.Message) bool {
+	ctx := common.AuthenticatedContext(context.Background(), "mao")
+	switch body := m.Body.(type) {
+	case *pb.Payload:
+		_, ok := receiver.Verify(ctx, common.PayloadEnvelope(messageType(m.Kind), body), body.Signature)
+		return ok
+	case *pb.ReadyRequest:
+		_, ok := receiver.Verify(ctx, common.ReadyEnvelope(body), body.Signature)
+		return ok
+	case *pb.AgreementMessage:
+		_, ok := receiver.Verify(ctx, common.AgreementEnvelope(body), body.Signature)
+		return ok
+	}
+	return false
+}
+
+func TestNew(t *testing.T) {
+	for _, name := range Names() {
+		_, err := New(name, "f1")
+		assert.Nil(t, err)
+		assert.NotEmpty(t, Describe(name))
+	}
+	honest, err := New("honest")
+	assert.Nil(t, err)
+	assert.Nil(t, honest)
+	_, err = New("mode 1")
+	assert.NotNil(t, err)
+
+	a, err := New("drop", "f1", "f2")
+	assert.Nil(t, err)
+	assert.Equal(t, NewTargets("f1", "f2"), a.(*Drop).Targets)
+}
+
+func TestTargets(t *testing.T) {
+	assert.True(t, NewTargets().Has("f1"))
+	assert.True(t, NewTargets("f1").Has("f1"))
+	assert.False(t, NewTargets("f1").Has("f2"))
+}
+
+func TestDropDelayDuplicate(t *testing.T) {
+	node, _ := newCluster()
+	m := prepareTo("f1", prepares(t, node, 1, "block")["f1"])
+	other := m
+	other.Peer = "f2"
+
+	drop := &Drop{Targets: NewTargets("f1")}
+	assert.Empty(t, drop.Outgoing(node, m))
+	assert.Equal(t, []common.Message{other}, drop.Outgoing(node, other))
+	assert.Equal(t, []common.Message{m}, drop.Incoming(node, m))
+
+	ignore := &Ignore{Targets: NewTargets("f1")}
+	assert.Empty(t, ignore.Incoming(node, m))
+	assert.Equal(t, []common.Message{m}, ignore.Outgoing(node, m))
+
+	withhold := &Withhold{}
+	assert.Empty(t, withhold.Outgoing(node, m))
+	echo := m
+	echo.Kind = common.KindEcho
+	assert.Equal(t, []common.Message{echo}, withhold.Outgoing(node, echo))
+
+	delay := &Delay{Delay: time.Second}
+	assert.Equal(t, time.Second, delay.Outgoing(node, m)[0].Delay)
+
+	duplicate := &Duplicate{Targets: NewTargets("f2"), Copies: 3}
+	assert.Equal(t, []common.Message{other, other, other}, duplicate.Outgoing(node, other))
+	assert.Equal(t, []common.Message{m}, duplicate.Outgoing(node, m))
+
+	// Messages are duplicated before they're dropped.
+	chained := Chain(&Duplicate{Copies: 2}, &Drop{Targets: NewTargets("f2")})
+	assert.Equal(t, []common.Message{m, m}, chained.Outgoing(node, m))
+	assert.Empty(t, chained.Outgoing(node, other))
+}
+
+func TestMutate(t *testing.T) {
+	node, receiver := newCluster()
+	prepare := prepares(t, node, 1, "block")["f1"]
+	original := proto.Clone(prepare)
+
+	res := (&Mutate{}).Outgoing(node, prepareTo("f1", prepare))
+	assert.Equal(t, 1, len(res))
+	mutated := res[0].Body.(*pb.Payload)
+	assert.NotEqual(t, prepare.Data, mutated.Data)
+	assert.True(t, verified(receiver, res[0]))
+	// The shard no longer matches its proof.
+	assert.False(t, merkle.VerifyProof(mutated.MerkleProof, merkle.BytesContent(mutated.Data)))
+	assert.True(t, proto.Equal(original, prepare))
+
+	ready := &pb.ReadyRequest{MerkleRoot: []byte("root"), Instance: prepare.Instance}
+	res = (&Mutate{}).Outgoing(node, common.Message{Kind: common.KindReady, Peer: "f1", Body: ready})
+	assert.Equal(t, []byte("\x8doot"), res[0].Body.(*pb.ReadyRequest).MerkleRoot)
+	assert.True(t, verified(receiver, res[0]))
+
+	conf := &pb.AgreementMessage{Instance: prepare.Instance, Type: pb.AgreementType_AT_CONF, Values: []bool{true}}
+	node.SignAgreement(conf)
+	res = (&Mutate{}).Outgoing(node, common.Message{Kind: common.KindConf, Peer: "f1", Body: conf})
+	assert.Equal(t, []bool{false}, res[0].Body.(*pb.AgreementMessage).Values)
+	assert.True(t, verified(receiver, res[0]))
+	assert.Equal(t, []bool{true}, conf.Values)
+
+	coin := &pb.AgreementMessage{Instance: prepare.Instance, Type: pb.AgreementType_AT_COIN, Share: []byte("share")}
+	node.SignAgreement(coin)
+	res = (&Mutate{}).Outgoing(node, common.Message{Kind: common.KindCoin, Peer: "f1", Body: coin})
+	assert.Equal(t, []byte("shard"), res[0].Body.(*pb.AgreementMessage).Share)
+	assert.True(t, verified(receiver, res[0]))
+	assert.Equal(t, []byte("share"), coin.Share)
+}
+
+func TestEquivocate(t *testing.T) {
+	node, receiver := newCluster()
+	all := prepares(t, node, 1, "block")
+	equivocate := &Equivocate{Targets: NewTargets("f1")}
+
+	assert.Equal(t, []common.Message{prepareTo("f2", all["f2"])}, equivocate.Outgoing(node, prepareTo("f2", all["f2"])))
+	res := equivocate.Outgoing(node, prepareTo("f1", all["f1"]))
+	assert.Equal(t, 1, len(res))
+	conflicting := res[0].Body.(*pb.Payload)
+	// f1 gets a valid shard of its own slot, which belongs to another block.
+	assert.NotEqual(t, all["f1"].MerkleProof.Root, conflicting.MerkleProof.Root)
+	assert.True(t, merkle.VerifyProof(conflicting.MerkleProof, merkle.BytesContent(conflicting.Data)))
+	assert.Equal(t, node.ShardIndex("f1"), merkle.GetLeafIndex(conflicting.MerkleProof))
+	assert.Equal(t, all["f1"].PrevHash, conflicting.PrevHash)
+	assert.True(t, verified(receiver, res[0]))
+
+	// In replicated mode f1 gets another whole block.
+	replicated := &pb.Payload{Mode: pb.BroadcastMode_BM_REPLICATED, Data: []byte("block"), PrevHash: []byte("parent"),
+		Instance: all["f1"].Instance}
+	replicated.Signature = node.SignEnvelope(common.PayloadEnvelope(pb.MessageType_MT_PREPARE, replicated))
+	conflicting = equivocate.Outgoing(node, prepareTo("f1", replicated))[0].Body.(*pb.Payload)
+	assert.NotEqual(t, common.PayloadRoot(replicated), common.PayloadRoot(conflicting))
+	assert.Nil(t, conflicting.MerkleProof)
+	assert.True(t, verified(receiver, prepareTo("f1", conflicting)))
+}
+
+func TestFork(t *testing.T) {
+	node, receiver := newCluster()
+	all := prepares(t, node, 1, "block")
+	for _, fork := range []*Fork{{Targets: NewTargets("f1", "f2")}, {Targets: NewTargets("f1", "f2"), NextEpoch: true}} {
+		assert.Equal(t, []common.Message{prepareTo("f3", all["f3"])}, fork.Outgoing(node, prepareTo("f3", all["f3"])))
+		var roots [][]byte
+		for _, target := range []string{"f1", "f2"} {
+			res := fork.Outgoing(node, prepareTo(target, all[target]))
+			assert.Equal(t, 2, len(res))
+			assert.Equal(t, prepareTo(target, all[target]), res[0])
+			// The fork is a valid shard of another block on the same parent, in another instance.
+			forked := res[1].Body.(*pb.Payload)
+			assert.Equal(t, DefaultDelay, res[1].Delay)
+			assert.Equal(t, all[target].PrevHash, forked.PrevHash)
+			assert.NotEqual(t, all[target].MerkleProof.Root, forked.MerkleProof.Root)
+			assert.False(t, proto.Equal(all[target].Instance, forked.Instance))
+			assert.Equal(t, fork.NextEpoch, forked.Instance.Epoch == all[target].Instance.Epoch+1)
+			assert.Equal(t, node.ShardIndex(target), merkle.GetLeafIndex(forked.MerkleProof))
+			assert.True(t, merkle.VerifyProof(forked.MerkleProof, merkle.BytesContent(forked.Data)))
+			assert.True(t, verified(receiver, res[1]))
+			roots = append(roots, forked.MerkleProof.Root)
+		}
+		// All targets get shards of the same block.
+		assert.Equal(t, roots[0], roots[1])
+	}
+}
+
+func TestSameShardAndUnsigned(t *testing.T) {
+	node, receiver := newCluster()
+	first := prepares(t, node, 1, "block")
+	sameShard := &SameShard{}
+	assert.Equal(t, first["f1"], sameShard.Outgoing(node, prepareTo("f1", first["f1"]))[0].Body)
+	assert.Equal(t, first["f1"], sameShard.Outgoing(node, prepareTo("f2", first["f2"]))[0].Body)
+	// A new broadcast starts over.
+	second := prepares(t, node, 2, "another block")
+	assert.Equal(t, second["f3"], sameShard.Outgoing(node, prepareTo("f3", second["f3"]))[0].Body)
+	assert.Equal(t, second["f3"], sameShard.Outgoing(node, prepareTo("f1", second["f1"]))[0].Body)
+
+	res := (&Unsigned{}).Outgoing(node, prepareTo("f1", first["f1"]))
+	assert.Nil(t, res[0].Body.(*pb.Payload).Signature)
+	assert.False(t, verified(receiver, res[0]))
+	assert.NotNil(t, first["f1"].Signature)
+}
+
+func TestPrematureReady(t *testing.T) {
+	node, receiver := newCluster()
+	echo := common.Message{Kind: common.KindEcho, Peer: "f1", Body: prepares(t, node, 1, "block")["f1"]}
+	res := (&PrematureReady{}).Outgoing(node, echo)
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, echo, res[0])
+	assert.Equal(t, common.KindReady, res[1].Kind)
+	assert.Equal(t, "f1", res[1].Peer)
+	assert.True(t, verified(receiver, res[1]))
+
+	prepare := prepareTo("f1", echo.Body.(*pb.Payload))
+	assert.Equal(t, []common.Message{prepare}, (&PrematureReady{}).Outgoing(node, prepare))
+}
@@ -0,0 +1,36 @@
+package ca
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCA_Issue(t *testing.T) {
+	ca, err := New()
+	assert.Nil(t, err)
+	certPEM, keyPEM, err := ca.Issue("f1")
+	assert.Nil(t, err)
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	assert.Nil(t, err)
+
+	pool := x509.NewCertPool()
+	assert.True(t, pool.AppendCertsFromPEM(ca.CertPEM))
+	for _, usage := range []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth} {
+		_, err = cert.Verify(x509.VerifyOptions{DNSName: "f1", Roots: pool, KeyUsages: []x509.ExtKeyUsage{usage}})
+		assert.Nil(t, err)
+	}
+	_, err = cert.Verify(x509.VerifyOptions{DNSName: "f2", Roots: pool})
+	assert.NotNil(t, err)
+
+	other, err := New()
+	assert.Nil(t, err)
+	pool = x509.NewCertPool()
+	pool.AppendCertsFromPEM(other.CertPEM)
+	_, err = cert.Verify(x509.VerifyOptions{DNSName: "f1", Roots: pool})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,149 @@
+package common
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/gopricy/mao-bft/pb"
+	"github.com/pkg/errors"
+)
+
+// Kinds of messages exchanged between peers.
+const (
+	KindPrepare      = "PREPARE"
+	KindEcho         = "ECHO"
+	KindReady        = "READY"
+	KindViewChange   = "VIEW-CHANGE"
+	KindTransaction  = "TRANSACTION"
+	KindEquivocation = "EQUIVOCATION"
+	KindBval         = "BVAL"
+	KindAux          = "AUX"
+	KindConf         = "CONF"
+	KindTerm         = "TERM"
+	KindCoin         = "COIN"
+)
+
+// Message is a message between this node and Peer, as seen by Adversary.
+type Message struct {
+	Kind string
+	// Peer is the receiver of an outgoing message, or the sender of an incoming message.
+	Peer string
+	// Body is the request of Kind, like *pb.Payload of PREPARE. Adversary must not change Body in place, it's shared
+	// with the honest code path.
+	Body proto.Message
+	// Delay is how long the message is held before it's sent or handled.
+	Delay time.Duration
+}
+
+// Adversary corrupts a node by rewriting the messages it sends and receives. Each hook returns the messages that
+// take the place of m, so a message is dropped by returning none, duplicated by returning it twice, and sent to
+// other peers by changing Peer. Messages returned by Incoming are handled as sent by their Peer.
+// Sync and client requests don't pass through Adversary.
+type Adversary interface {
+	Outgoing(node *Common, m Message) []Message
+	Incoming(node *Common, m Message) []Message
+}
+
+// SetAdversary corrupts this node with adversary, nil makes it honest again.
+func (c *Common) SetAdversary(adversary Adversary) {
+	c.adversaryMu.Lock()
+	defer c.adversaryMu.Unlock()
+	c.adversary = adversary
+}
+
+func (c *Common) getAdversary() Adversary {
+	c.adversaryMu.RLock()
+	defer c.adversaryMu.RUnlock()
+	return c.adversary
+}
+
+// SendMessage queues body of kind to p, after it's passed through the adversary of this node.
+func (c *Common) SendMessage(p *Peer, kind string, body proto.Message) {
+	m := Message{Kind: kind, Peer: p.Name, Body: body}
+	adversary := c.getAdversary()
+	if adversary == nil {
+		c.enqueueMessage(p, m)
+		return
+	}
+	for _, m := range adversary.Outgoing(c, m) {
+		p, ok := c.AllPeers[m.Peer]
+		if !ok {
+			c.Infof("Drop %s to unknown peer %s", m.Kind, m.Peer)
+			continue
+		}
+		if m.Delay <= 0 {
+			c.enqueueMessage(p, m)
+			continue
+		}
+		m := m
+		c.Clock.AfterFunc(m.Delay, func() { c.enqueueMessage(p, m) })
+	}
+}
+
+func (c *Common) enqueueMessage(p *Peer, m Message) {
+	c.Enqueue(p, m.Kind, func(ctx context.Context) error {
+		return c.transmit(ctx, p, m)
+	})
+}
+
+// transmit sends m to p through Transport.
+func (c *Common) transmit(ctx context.Context, p *Peer, m Message) error {
+	switch body := m.Body.(type) {
+	case *pb.Payload:
+		if m.Kind == KindPrepare {
+			return c.Transport.Prepare(ctx, p, body)
+		}
+		return c.Transport.Echo(ctx, p, body)
+	case *pb.ReadyRequest:
+		return c.Transport.Ready(ctx, p, body)
+	case *pb.ViewChangeRequest:
+		return c.Transport.ViewChange(ctx, p, body)
+	case *pb.ProposeTransactionRequest:
+		return c.Transport.ProposeTransaction(ctx, p, body)
+	case *pb.EquivocationEvidence:
+		return c.Transport.ReportEquivocation(ctx, p, body)
+	case *pb.AgreementMessage:
+		return c.Transport.Agreement(ctx, p, body)
+	}
+	// Retrying can't fix the type, so the message is dropped right away.
+	return errors.Errorf("can't send %s of type %T", m.Kind, m.Body)
+}
+
+// receive passes m received from ctx through the adversary of this node, and handles the messages that take its
+// place. It returns the error of the first message handled right away.
+func (c *Common) receive(ctx context.Context, m Message, handle func(ctx context.Context, body proto.Message) error) error {
+	adversary := c.getAdversary()
+	if adversary == nil {
+		return handle(ctx, m.Body)
+	}
+	name, err := c.getNameFromContext(ctx)
+	if err != nil {
+		// Unauthenticated messages are rejected by handle anyway.
+		return handle(ctx, m.Body)
+	}
+	m.Peer = name
+	kind := proto.MessageName(m.Body)
+	var first error
+	for _, m := range adversary.Incoming(c, m) {
+		if m.Body == nil || proto.MessageName(m.Body) != kind {
+			c.Infof("Drop %s of type %T from %s", m.Kind, m.Body, m.Peer)
+			continue
+		}
+		ctx := AuthenticatedContext(ctx, m.Peer)
+		if m.Delay > 0 {
+			m := m
+			c.Clock.AfterFunc(m.Delay, func() {
+				ctx := AuthenticatedContext(context.Background(), m.Peer)
+				if err := handle(ctx, m.Body); err != nil {
+					c.Infof("Delayed %s from %s failed: %s", m.Kind, m.Peer, err.Error())
+				}
+			})
+			continue
+		}
+		if err := handle(ctx, m.Body); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
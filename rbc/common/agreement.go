@@ -0,0 +1,80 @@
+package common
+
+import (
+	"context"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/gopricy/mao-bft/pb"
+	"github.com/pkg/errors"
+)
+
+// AgreementHandler handles a binary agreement message sent by peer.
+type AgreementHandler func(peer string, req *pb.AgreementMessage) error
+
+// agreementHandler is set by the protocol that runs binary agreement on top of this node, like ACS.
+type agreementHandler struct {
+	handle AgreementHandler
+	mu     sync.RWMutex
+}
+
+// HandleAgreement makes handle serve binary agreement messages, they're rejected until it's set.
+func (c *Common) HandleAgreement(handle AgreementHandler) {
+	c.agreement.mu.Lock()
+	defer c.agreement.mu.Unlock()
+	c.agreement.handle = handle
+}
+
+// AgreementKind returns the kind of a binary agreement message of type t.
+func AgreementKind(t pb.AgreementType) string {
+	switch t {
+	case pb.AgreementType_AT_BVAL:
+		return KindBval
+	case pb.AgreementType_AT_AUX:
+		return KindAux
+	case pb.AgreementType_AT_CONF:
+		return KindConf
+	case pb.AgreementType_AT_TERM:
+		return KindTerm
+	case pb.AgreementType_AT_COIN:
+		return KindCoin
+	}
+	return t.String()
+}
+
+// SignAgreement signs a binary agreement message of this node.
+func (c *Common) SignAgreement(req *pb.AgreementMessage) {
+	req.Signature = c.SignEnvelope(AgreementEnvelope(req))
+}
+
+// SendAgreement queues a signed binary agreement message to p.
+func (c *Common) SendAgreement(p *Peer, req *pb.AgreementMessage) {
+	c.SendMessage(p, AgreementKind(req.Type), req)
+}
+
+// Agreement serves binary agreement messages from other nodes.
+func (c *Common) Agreement(ctx context.Context, req *pb.AgreementMessage) (*pb.AgreementResponse, error) {
+	err := c.receive(ctx, Message{Kind: AgreementKind(req.Type), Body: req}, func(ctx context.Context, m proto.Message) error {
+		return c.handleAgreement(ctx, m.(*pb.AgreementMessage))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.AgreementResponse{}, nil
+}
+
+// handleAgreement hands a message that the adversary of this node lets through to the agreement handler, once its
+// signature is verified.
+func (c *Common) handleAgreement(ctx context.Context, req *pb.AgreementMessage) error {
+	name, verified := c.Verify(ctx, AgreementEnvelope(req), req.Signature)
+	if !verified {
+		return ErrInvalidSignature
+	}
+	c.agreement.mu.RLock()
+	handle := c.agreement.handle
+	c.agreement.mu.RUnlock()
+	if handle == nil {
+		return errors.New("binary agreement is not enabled")
+	}
+	return handle(name, req)
+}
@@ -9,9 +9,46 @@ type Application interface {
 	// This function returns staged area's length
 	// TODO?: can change it to block *pb.Block when we finalize it
 	RBCReceive([]byte) (bool, error)
+	// IsCommitted returns whether data delivered by RBC has been committed.
+	// This function should be thread safe.
+	IsCommitted([]byte) bool
 
 	// GetSyncQuestion will return sync request constructed from App blockchain.
 	GetSyncQuestion() (*pb.SyncRequest, error)
 	// GetSyncAnswer will return sync answer for the corresponding sync question.
 	GetSyncAnswer(request *pb.SyncRequest) (*pb.SyncResponse, error)
 }
+
+// PersistentApplication is implemented by applications that keep their state on disk. RBC state that must survive
+// restarts is kept in StateDir.
+type PersistentApplication interface {
+	Application
+	// StateDir returns the directory for RBC state, state is only kept in memory if it's empty.
+	StateDir() string
+}
+
+// CertifiedApplication is implemented by applications that keep the quorum certificate of data they commit.
+type CertifiedApplication interface {
+	Application
+	// RBCReceiveCertified is RBCReceive of data along with its quorum certificate.
+	RBCReceiveCertified(data []byte, certificate *pb.QuorumCertificate) (bool, error)
+}
+
+// TransactionApplication is implemented by applications that serve client transactions through TransactionService.
+type TransactionApplication interface {
+	// ProposeTransaction proposes a client transaction and returns the uuid assigned to it.
+	ProposeTransaction(*pb.Transaction) (string, error)
+	// GetTransactionStatus returns status of a transaction by its uuid.
+	GetTransactionStatus(txUuid string) pb.TransactionStatus
+}
+
+// ViewChangeApplication is implemented by applications running in view change mode.
+type ViewChangeApplication interface {
+	TransactionApplication
+	// AcceptForwarded accepts a transaction that a peer received from client, its uuid is assigned by that peer.
+	AcceptForwarded(*pb.Transaction) error
+	// LastCommitted returns the last committed block and the number of committed blocks.
+	LastCommitted() (*pb.Block, int64)
+	// NewView is called once this node moves to a new view, isLeader tells whether this node leads the view.
+	NewView(view int64, leader string, isLeader bool)
+}
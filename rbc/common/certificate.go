@@ -0,0 +1,82 @@
+package common
+
+import (
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/gopricy/mao-bft/pb"
+	mao_utils "github.com/gopricy/mao-bft/utils"
+	"github.com/pkg/errors"
+)
+
+// certificate returns the quorum certificate of key, made of 2f+1 READYs this node received in order of their
+// senders.
+func (c *Common) certificate(key InstanceKey) *pb.QuorumCertificate {
+	votes := c.ReadiesReceived.Votes(key)
+	names := make([]string, 0, len(votes))
+	for name := range votes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	res := &pb.QuorumCertificate{Instance: key.Instance.Pb(), Mode: c.mode(key)}
+	for _, name := range names {
+		req, ok := votes[name].(*pb.ReadyRequest)
+		if !ok {
+			continue
+		}
+		res.MerkleRoot = req.MerkleRoot
+		res.Readies = append(res.Readies, &pb.ReadyVote{Peer: name, PrevHash: req.PrevHash, Signature: req.Signature})
+		if len(res.Readies) == c.ReadyQuorum() {
+			break
+		}
+	}
+	return res
+}
+
+// VerifyCertificate checks that certificate holds READYs of data signed by 2f+1 distinct peers of setting. It needs
+// nothing but public keys of peers, so anyone who knows setting can audit what the cluster delivered.
+func VerifyCertificate(setting *RBCSetting, certificate *pb.QuorumCertificate, data []byte) error {
+	if certificate == nil {
+		return errors.New("quorum certificate is missing")
+	}
+	root := ValueHash(data)
+	if certificate.Mode != pb.BroadcastMode_BM_REPLICATED {
+		var err error
+		if root, err = DataRoot(data, setting.ByzantineLimit, len(setting.AllPeers)); err != nil {
+			return err
+		}
+	}
+	if !mao_utils.IsSameBytes(root, certificate.MerkleRoot) {
+		return errors.New("quorum certificate is of other data")
+	}
+	signers := make(map[string]bool)
+	for _, vote := range certificate.Readies {
+		if signers[vote.Peer] {
+			return errors.Errorf("duplicate READY of %s", vote.Peer)
+		}
+		ready := &pb.ReadyRequest{MerkleRoot: certificate.MerkleRoot, PrevHash: vote.PrevHash, Instance: certificate.Instance}
+		if err := setting.verifyEnvelope(vote.Peer, ReadyEnvelope(ready), vote.Signature); err != nil {
+			return errors.Wrap(err, "invalid READY")
+		}
+		signers[vote.Peer] = true
+	}
+	if len(signers) < setting.ReadyQuorum() {
+		return errors.Errorf("quorum certificate has %d READYs, %d are needed", len(signers), setting.ReadyQuorum())
+	}
+	return nil
+}
+
+// VerifyBlockCertificate checks the quorum certificate of a committed block, the data that's certified is the block
+// without its certificate.
+func VerifyBlockCertificate(setting *RBCSetting, block *pb.Block) error {
+	if !mao_utils.IsValidBlockHash(block) {
+		return errors.New("block hash is invalid")
+	}
+	bare := proto.Clone(block).(*pb.Block)
+	bare.Certificate = nil
+	data, err := mao_utils.EncodeBlock(bare)
+	if err != nil {
+		return err
+	}
+	return VerifyCertificate(setting, block.Certificate, data)
+}
@@ -0,0 +1,16 @@
+package common
+
+import "time"
+
+// Clock tells time and runs delayed work. It's real time unless replaced, e.g. by a simulator with virtual time.
+type Clock interface {
+	Now() time.Time
+	// AfterFunc calls f after d.
+	AfterFunc(d time.Duration, f func())
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) AfterFunc(d time.Duration, f func()) { time.AfterFunc(d, f) }
@@ -2,106 +2,189 @@ package common
 
 import (
 	"context"
+	"crypto/tls"
+
 	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/gopricy/mao-bft/rbc/sign"
 
-	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/metadata"
 
 	"github.com/gopricy/mao-bft/pb"
 	"github.com/gopricy/mao-bft/rbc/erasure"
 	"github.com/gopricy/mao-bft/rbc/merkle"
+	mao_utils "github.com/gopricy/mao-bft/utils"
 	"github.com/op/go-logging"
 	"github.com/pkg/errors"
-	"google.golang.org/grpc"
 )
 
+// Received collects votes of each value in each RBC instance, a peer can only vote for one value per instance.
 type Received struct {
 	// TODO: improve the efficiency with better locking
-	Rec map[merkle.RootString]map[string]interface{}
-	mu  sync.Mutex
+	Rec map[InstanceKey]map[string]interface{}
+	// voted records the value each peer voted for in each instance.
+	voted map[Instance]map[string]merkle.RootString
+	mu    sync.Mutex
 }
 
-func (er *Received) Add(ip string, merkleRoot []byte, Rec interface{}) (int, error) {
+func (er *Received) Add(ip string, key InstanceKey, Rec interface{}) (int, error) {
 	er.mu.Lock()
 	defer er.mu.Unlock()
-	root := merkle.MerkleRootToString(merkleRoot)
 	if er.Rec == nil {
-		er.Rec = make(map[merkle.RootString]map[string]interface{})
+		er.Rec = make(map[InstanceKey]map[string]interface{})
+		er.voted = make(map[Instance]map[string]merkle.RootString)
+	}
+	if _, ok := er.voted[key.Instance]; !ok {
+		er.voted[key.Instance] = make(map[string]merkle.RootString)
+	}
+	if root, ok := er.voted[key.Instance][ip]; ok && root != key.Root {
+		return len(er.Rec[key]), errors.New("Same IP already voted another message in instance " + key.Instance.String())
 	}
-	if _, ok := er.Rec[root]; !ok {
+	if _, ok := er.Rec[key]; !ok {
 		// if this message hasn't been seen
-		er.Rec[root] = make(map[string]interface{})
+		er.Rec[key] = make(map[string]interface{})
 	}
-	if _, ok := er.Rec[root][ip]; ok {
-		return len(er.Rec[root]), errors.New("Duplicate ECHO from same IP carrying same message")
+	if _, ok := er.Rec[key][ip]; ok {
+		return len(er.Rec[key]), errors.New("Duplicate ECHO from same IP carrying same message")
+	}
+	er.voted[key.Instance][ip] = key.Root
+	er.Rec[key][ip] = Rec
+	return len(er.Rec[key]), nil
+}
+
+// Count returns the number of votes of key.
+func (er *Received) Count(key InstanceKey) int {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+	return len(er.Rec[key])
+}
+
+// Values returns all votes of key.
+func (er *Received) Values(key InstanceKey) []interface{} {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+	var res []interface{}
+	for _, v := range er.Rec[key] {
+		res = append(res, v)
+	}
+	return res
+}
+
+// Votes returns the vote of each peer of key.
+func (er *Received) Votes(key InstanceKey) map[string]interface{} {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+	res := make(map[string]interface{})
+	for name, v := range er.Rec[key] {
+		res[name] = v
+	}
+	return res
+}
+
+// Remove drops all votes of instance.
+func (er *Received) Remove(instance Instance) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+	for _, root := range er.voted[instance] {
+		delete(er.Rec, InstanceKey{Instance: instance, Root: root})
 	}
-	er.Rec[root][ip] = Rec
-	return len(er.Rec[root]), nil
+	delete(er.voted, instance)
 }
 
 type RBCSetting struct {
 	AllPeers       map[string]*Peer
 	ByzantineLimit int
+	// ViewChangeTimeout enables view change mode when it's positive, leader is then rotated among AllPeers.
+	// A transaction that isn't committed within this timeout makes a node suspect the leader.
+	ViewChangeTimeout time.Duration
+	// RetentionWindow is how long vote state of an instance is kept after its data is committed.
+	// DefaultRetentionWindow is used if it's not set.
+	RetentionWindow time.Duration
+	// OutboxSize is the number of messages that can wait for each peer, DefaultOutboxSize is used if it's not set.
+	OutboxSize int
+	// MaxRetries is how many times a message is resent before it's dropped, DefaultMaxRetries is used if it's not set.
+	MaxRetries int
+	// ClusterID identifies the cluster, messages signed in another cluster are rejected.
+	ClusterID string
+	// SequenceWindow is how far behind the latest broadcast of a broadcaster an instance can be, messages of older
+	// instances are rejected. DefaultSequenceWindow is used if it's not set.
+	SequenceWindow int64
+	// CACert is the PEM encoded certificate of the CA that issues certificates of all peers, it's required if the
+	// cluster uses TLS.
+	CACert []byte
+	// ReplicatedLimit is the size in bytes up to which data is broadcast in replicated mode, larger data is erasure
+	// coded. All data is erasure coded if it's not set.
+	ReplicatedLimit int
 }
 
 type Peer struct {
 	Name   string
 	IP     string
 	PORT   int
-	CONN   *grpc.ClientConn
 	PubKey sign.PublicKey
+	// Cert is the PEM encoded TLS certificate of this peer, it's required if the cluster uses TLS.
+	Cert []byte
+	// ClientPORT is the port that clients of TransactionService connect to if the cluster uses TLS, since they don't
+	// have certificates to connect to PORT.
+	ClientPORT int
 }
 
 func (p *Peer) GoString() string {
 	return fmt.Sprintf("%s", p.Name)
 }
 
-func (p *Peer) GetConn() *grpc.ClientConn {
-	// retry := 0
-	for p.CONN == nil || p.CONN.GetState() == connectivity.Shutdown {
-		conn, err := createConnection(p.IP, p.PORT)
-		if err == nil {
-			p.CONN = conn
-			break
-		}
-		// retry++
-		// if retry > 8 {
-		// 	retry = 8
-		// }
-		// waitTime := int(math.Pow(2, float64(retry)))
-		waitTime := 300
-		fmt.Println("Connection timeout, retry in ", waitTime, " seconds")
-		time.Sleep(time.Duration(waitTime) * time.Second)
-	}
-	return p.CONN
-}
-
 // Common is a building block of follower and leader
 type Common struct {
 	RBCSetting
 
 	EchosReceived   Received
 	ReadiesReceived Received
-	PrevHashVoted   map[string]merkle.RootString
-
-	NodeName    string
+	Retention       Retention
+	replay          replayGuard
+	deliverer       deliverer
+	outboxes        outboxes
+	// VoteLocks records the PREPARE this node echoed for each parent block.
+	VoteLocks *VoteLocks
+
+	NodeName string
+	// ReadiesSent maps each instance to the root this node sent READY for.
 	ReadiesSent sync.Map
 
 	// Below are related to transaction system.
 	App Application
 
+	// Transport sends messages to peers, it's gRPC unless replaced before this node starts.
+	Transport Transport
+	// Clock is real time unless replaced before this node starts.
+	Clock Clock
+	// serverTLS is set if this node uses TLS.
+	serverTLS *tls.Config
+	// certOwners maps certificate of each peer in DER to its name.
+	certOwners map[string]string
+
 	Logger           *logging.Logger
 	loggingColorLock sync.Mutex
 
 	// privatekey
 	privateKey *[64]byte
 
-	Mode int
+	// View is only used in view change mode.
+	View View
+
+	misbehaviours   []Misbehaviour
+	misbehaved      map[misbehaviourKey]bool
+	misbehavioursMu sync.Mutex
+	equivocations   equivocations
+	agreement       agreementHandler
+
+	adversary   Adversary
+	adversaryMu sync.RWMutex
 }
 
 func NewCommon(name string, setting RBCSetting, app Application, privateKey *[64]byte) Common {
@@ -109,20 +192,39 @@ func NewCommon(name string, setting RBCSetting, app Application, privateKey *[64
 	//	`%{time:15:05:05} %{module} %{message}`
 	//)
 	//log := logging.NewLogBackend(os.Stdout, "name", 0)
+	dir := ""
+	if p, ok := app.(PersistentApplication); ok {
+		dir = p.StateDir()
+	}
+	if dir != "" {
+		dir = filepath.Join(dir, "vote_locks")
+	}
+	voteLocks, err := OpenVoteLocks(dir)
+	if err != nil {
+		panic(err)
+	}
 	return Common{RBCSetting: setting,
 		NodeName:   name,
 		App:        app,
+		Transport:  &GRPCTransport{},
+		Clock:      realClock{},
+		VoteLocks:  voteLocks,
 		Logger:     logging.MustGetLogger("RBC"),
 		privateKey: privateKey,
 	}
 }
 
-func (c *Common) Verify(ctx context.Context, message []byte) ([]byte, bool, string) {
+// open verifies message signed by the sender of ctx with Sign, it returns the message without signature.
+func (c *Common) open(ctx context.Context, message []byte) ([]byte, bool, string) {
 	name, err := c.getNameFromContext(ctx)
 	if err != nil {
 		return nil, false, ""
 	}
-	data, verified := sign.Verify(c.AllPeers[name].PubKey, message)
+	p, ok := c.AllPeers[name]
+	if !ok {
+		return nil, false, name
+	}
+	data, verified := sign.Verify(p.PubKey, message)
 	if verified {
 		c.Debugf("signature verified, signed by %s", name)
 	} else {
@@ -131,39 +233,104 @@ func (c *Common) Verify(ctx context.Context, message []byte) ([]byte, bool, stri
 	return data, verified, name
 }
 
-func (c *Common) PrevHashValid(prevHash []byte, merkleRoot []byte) bool {
-	if root, ok := c.PrevHashVoted[string(prevHash)]; ok {
-		return merkle.MerkleRootToString(merkleRoot) == root
-	}
-	return true
-}
-
 func (c *Common) Sign(message []byte) []byte {
 	return sign.Sign(c.privateKey, message)
 }
 
-func (c *Common) reconstructData(root merkle.RootString) ([]byte, error) {
+// ErrRootMismatch means the shards committed by a Merkle root are not a consistent encoding of any data.
+var ErrRootMismatch = errors.New("reconstructed data doesn't match merkle root")
+
+// reconstructData decodes data from echoed shards of key. Data is re-split and its Merkle root is recomputed,
+// so that all correct nodes either deliver the same data or detect that the broadcaster is faulty.
+func (c *Common) reconstructData(key InstanceKey) ([]byte, error) {
 	payloads := []*pb.Payload{}
-	for _, m := range c.EchosReceived.Rec[root] {
-		payloads = append(payloads, m.(*pb.Payload))
+	var root []byte
+	for _, m := range c.EchosReceived.Values(key) {
+		payload := m.(*pb.Payload)
+		// A replicated ECHO carries the value that hashes to the root of key.
+		if payload.Mode == pb.BroadcastMode_BM_REPLICATED {
+			return payload.Data, nil
+		}
+		payloads = append(payloads, payload)
+		root = payload.MerkleProof.Root
+	}
+	data, err := erasure.Reconstruct(payloads, c.ByzantineLimit, len(c.AllPeers))
+	if err != nil {
+		c.Debugf("Failed to decode %s: %s", key, err.Error())
+		return nil, ErrRootMismatch
 	}
-	return erasure.Reconstruct(payloads, c.ByzantineLimit, len(c.AllPeers))
+	recomputed, err := DataRoot(data, c.ByzantineLimit, len(c.AllPeers))
+	if err != nil {
+		return nil, err
+	}
+	if !mao_utils.IsSameBytes(recomputed, root) {
+		return nil, ErrRootMismatch
+	}
+	return data, nil
 }
 
-func (c *Common) readyIsSent(merkleroot []byte) bool {
-	if _, ok := c.ReadiesSent.Load(merkle.MerkleRootToString(merkleroot)); !ok {
-		c.ReadiesSent.Store(merkle.MerkleRootToString(merkleroot), struct{}{})
-		return false
+// DataRoot returns the Merkle root that data is broadcast under among n peers with byzantine limit f.
+func DataRoot(data []byte, f, n int) ([]byte, error) {
+	shards, err := erasure.Split(data, f, n)
+	if err != nil {
+		return nil, err
 	}
-	return true
+	return merkle.RootOfBytes(shards)
 }
 
-func (c *Common) Name() string {
-	return c.NodeName
+// sortedPeers returns names of all peers in lexical order.
+func (c *Common) sortedPeers() []string {
+	names := make([]string, 0, len(c.AllPeers))
+	for name := range c.AllPeers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
-func (c *Common) SetMode(m int) {
-	c.Mode = m
+// Peers returns all peers in lexical order of their names, broadcasts go to peers in this order so that a run is
+// reproducible.
+func (c *Common) Peers() []*Peer {
+	res := make([]*Peer, 0, len(c.AllPeers))
+	for _, name := range c.sortedPeers() {
+		res = append(res, c.AllPeers[name])
+	}
+	return res
+}
+
+// ShardIndex returns the index of the shard that the broadcaster sends to peer, it's the position of peer in
+// lexical order of all peers, or -1 if peer is unknown.
+func (c *Common) ShardIndex(peer string) int {
+	for i, name := range c.sortedPeers() {
+		if name == peer {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkShardIndex checks that proof is for the shard of peer. A shard echoed by any other peer would overwrite the
+// one of its owner when decoding.
+func (c *Common) checkShardIndex(peer string, proof *pb.MerkleProof) error {
+	index := merkle.GetLeafIndex(proof)
+	if index == c.ShardIndex(peer) {
+		return nil
+	}
+	names := c.sortedPeers()
+	if index < 0 || index >= len(names) {
+		return errors.Errorf("shard index %d of %s is out of range", index, peer)
+	}
+	return errors.Errorf("%s sent shard %d which belongs to %s", peer, index, names[index])
+}
+
+// readyIsSent marks READY as sent for key, READY is sent at most once per instance.
+func (c *Common) readyIsSent(key InstanceKey) bool {
+	_, loaded := c.ReadiesSent.LoadOrStore(key.Instance, key.Root)
+	return loaded
+}
+
+func (c *Common) Name() string {
+	return c.NodeName
 }
 
 func (c *Common) Debugf(format string, args ...interface{}) {
@@ -174,33 +341,34 @@ func (c *Common) Infof(format string, args ...interface{}) {
 	c.Logger.Infof("%s:"+format, append([]interface{}{c.Name()}, args...)...)
 }
 
-func (c *Common) CreateContext() context.Context {
-	md := metadata.Pairs("name", c.Name())
-	return metadata.NewOutgoingContext(context.Background(), md)
+// CreateContext returns context of a request to p, see createContext.
+func (c *Common) CreateContext(p *Peer) context.Context {
+	return c.createContext(context.Background(), p)
 }
 
-func (c *Common) getNameFromContext(ctx context.Context) (string, error) {
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return "", errors.New("failed to decode context")
-	}
-	name, ok := md["name"]
-	if !ok {
-		return "", errors.New("context doesn't have name")
+// createContext attaches identity of this node to ctx of a request to p. Unless the cluster uses TLS, identity is
+// proved by a handshake signed by this node for p, so that no one else can claim it.
+func (c *Common) createContext(ctx context.Context, p *Peer) context.Context {
+	md := metadata.Pairs(handshakeName, c.Name())
+	if c.serverTLS == nil && c.privateKey != nil {
+		t := c.Clock.Now().UnixNano()
+		md.Set(handshakeTime, strconv.FormatInt(t, 10))
+		md.Set(handshakeSignature, string(sign.SignDetached(c.privateKey, handshake(c.ClusterID, c.Name(), p.Name, t))))
 	}
-	return name[0], nil
-}
-
-func (c *Common) ProposeTransaction(
-	ctx context.Context, in *pb.ProposeTransactionRequest) (*pb.ProposeTransactionResponse, error) {
-	// TODO(chenweilunster): IMPLEMENT ME
-	return nil, nil
+	return metadata.NewOutgoingContext(ctx, md)
 }
 
-func (c *Common) GetTransactionStatus(
-	ctx context.Context, in *pb.GetTransactionStatusRequest) (*pb.GetTransactionStatusResponse, error) {
-	// TODO(chenweilunster): IMPLEMENT ME
-	return nil, nil
+// getNameFromContext returns name of the peer that sent the request of ctx, it fails if sender isn't a peer.
+// See authenticatedName for how sender is identified.
+func (c *Common) getNameFromContext(ctx context.Context) (string, error) {
+	name, err := c.authenticatedName(ctx)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := c.AllPeers[name]; !ok {
+		return "", errors.Errorf("%s is not a peer", name)
+	}
+	return name, nil
 }
 
 func (c *Common) SetColor(p ...color.Attribute) {
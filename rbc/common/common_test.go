@@ -0,0 +1,1550 @@
+package common
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gopricy/mao-bft/pb"
+	"github.com/gopricy/mao-bft/rbc/ca"
+	"github.com/gopricy/mao-bft/rbc/erasure"
+	"github.com/gopricy/mao-bft/rbc/merkle"
+	"github.com/gopricy/mao-bft/rbc/sign"
+	mao_utils "github.com/gopricy/mao-bft/utils"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestReceived_AddIsScopedPerInstance(t *testing.T) {
+	var rec Received
+	root := merkle.MerkleRootToString([]byte("root"))
+	first := InstanceKey{Instance: Instance{Broadcaster: "mao", Sequence: 1}, Root: root}
+	second := InstanceKey{Instance: Instance{Broadcaster: "mao", Sequence: 2}, Root: root}
+
+	n, err := rec.Add("f1", first, struct{}{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+	// Same payload in another instance is tracked independently.
+	n, err = rec.Add("f1", second, struct{}{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+	n, err = rec.Add("f2", first, struct{}{})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+
+	// Duplicate vote is rejected.
+	_, err = rec.Add("f1", first, struct{}{})
+	assert.NotNil(t, err)
+	// A peer can't vote for another value in the same instance.
+	other := InstanceKey{Instance: first.Instance, Root: merkle.MerkleRootToString([]byte("other"))}
+	n, err = rec.Add("f1", other, struct{}{})
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, n)
+}
+
+// Defines an application that treats data as committed once it's in committed set.
+type testApp struct {
+	committed map[string]bool
+	received  [][]byte
+	mu        sync.Mutex
+}
+
+func (ta *testApp) RBCReceive(data []byte) (bool, error) {
+	ta.mu.Lock()
+	defer ta.mu.Unlock()
+	ta.received = append(ta.received, data)
+	return false, nil
+}
+
+func (ta *testApp) IsCommitted(data []byte) bool { return ta.committed[string(data)] }
+
+func (ta *testApp) GetSyncQuestion() (*pb.SyncRequest, error) { return nil, nil }
+
+func (ta *testApp) GetSyncAnswer(*pb.SyncRequest) (*pb.SyncResponse, error) { return nil, nil }
+
+func TestCommon_CollectGarbage(t *testing.T) {
+	app := &testApp{committed: map[string]bool{}}
+	c := NewCommon("f1", RBCSetting{RetentionWindow: time.Minute}, app, nil)
+	first := InstanceKey{Instance: Instance{Broadcaster: "mao", Sequence: 1}, Root: "a"}
+	second := InstanceKey{Instance: Instance{Broadcaster: "mao", Sequence: 2}, Root: "b"}
+	for _, key := range []InstanceKey{first, second} {
+		_, err := c.EchosReceived.Add("f2", key, struct{}{})
+		assert.Nil(t, err)
+		_, err = c.ReadiesReceived.Add("f2", key, struct{}{})
+		assert.Nil(t, err)
+		assert.False(t, c.readyIsSent(key))
+	}
+
+	c.markDelivered(first, []byte("first"))
+	c.markDelivered(second, []byte("second"))
+	assert.True(t, c.IsDelivered(first.Instance))
+	assert.False(t, c.IsDelivered(Instance{Broadcaster: "mao", Sequence: 3}))
+
+	now := time.Now()
+	// Nothing is dropped within retention window.
+	c.collectGarbage(now)
+	assert.Equal(t, 2, len(c.EchosReceived.Rec))
+
+	// Only committed data is dropped after retention window.
+	app.committed["first"] = true
+	c.collectGarbage(now.Add(2 * time.Minute))
+	assert.Equal(t, 1, len(c.EchosReceived.Rec))
+	assert.Equal(t, 1, len(c.ReadiesReceived.Rec))
+	_, ok := c.EchosReceived.Rec[second]
+	assert.True(t, ok)
+	_, ok = c.ReadiesSent.Load(first.Instance)
+	assert.False(t, ok)
+	// Late messages of delivered instance are still recognized.
+	assert.True(t, c.IsDelivered(first.Instance))
+
+	// Everything is dropped and forgotten eventually.
+	c.collectGarbage(now.Add(forgetAfter * 2 * time.Minute))
+	assert.Equal(t, 0, len(c.EchosReceived.Rec))
+	assert.Equal(t, 0, len(c.Retention.kept))
+	assert.False(t, c.IsDelivered(first.Instance))
+}
+
+// manualClock is a Clock that moves only by Advance, which runs timers that are due.
+type manualClock struct {
+	now    time.Time
+	timers []manualTimer
+}
+
+type manualTimer struct {
+	at time.Time
+	f  func()
+}
+
+func (m *manualClock) Now() time.Time { return m.now }
+
+func (m *manualClock) AfterFunc(d time.Duration, f func()) {
+	m.timers = append(m.timers, manualTimer{at: m.now.Add(d), f: f})
+}
+
+// Advance moves the clock by d, timers set by timers that run are only due later.
+func (m *manualClock) Advance(d time.Duration) {
+	m.now = m.now.Add(d)
+	var due []manualTimer
+	pending := m.timers[:0]
+	for _, t := range m.timers {
+		if t.at.After(m.now) {
+			pending = append(pending, t)
+		} else {
+			due = append(due, t)
+		}
+	}
+	m.timers = pending
+	for _, t := range due {
+		t.f()
+	}
+}
+
+func TestCommon_CollectGarbageOnTimer(t *testing.T) {
+	app := &testApp{committed: map[string]bool{"first": true}}
+	clock := &manualClock{now: time.Now()}
+	c := NewCommon("f1", RBCSetting{RetentionWindow: time.Minute}, app, nil)
+	c.Clock = clock
+	key := InstanceKey{Instance: Instance{Broadcaster: "mao", Sequence: 1}, Root: "a"}
+	_, err := c.EchosReceived.Add("f2", key, struct{}{})
+	assert.Nil(t, err)
+	c.markDelivered(key, []byte("first"))
+	// One timer is pending at a time.
+	c.markAborted(Instance{Broadcaster: "mao", Sequence: 2})
+	assert.Equal(t, 1, len(clock.timers))
+
+	// Vote state is dropped after retention window though nothing else is delivered.
+	clock.Advance(time.Minute)
+	assert.Equal(t, 0, len(c.EchosReceived.Rec))
+	assert.True(t, c.IsDelivered(key.Instance))
+	// Deliveries are forgotten in later windows, then the timer stops.
+	for i := 0; i < forgetAfter; i++ {
+		clock.Advance(time.Minute)
+	}
+	assert.False(t, c.IsDelivered(key.Instance))
+	assert.Equal(t, 0, len(clock.timers))
+
+	// A stopped node doesn't collect garbage anymore.
+	c.markDelivered(key, []byte("first"))
+	c.Stop()
+	clock.Advance(forgetAfter * time.Minute)
+	assert.True(t, c.IsDelivered(key.Instance))
+	assert.Equal(t, 0, len(clock.timers))
+}
+
+func TestCommon_MarkAborted(t *testing.T) {
+	c := NewCommon("f1", RBCSetting{}, &testApp{}, nil)
+	key := InstanceKey{Instance: Instance{Broadcaster: "mao", Sequence: 1}, Root: "a"}
+	_, err := c.EchosReceived.Add("f2", key, struct{}{})
+	assert.Nil(t, err)
+	_, err = c.ReadiesReceived.Add("f2", key, struct{}{})
+	assert.Nil(t, err)
+	assert.False(t, c.readyIsSent(key))
+
+	// All vote state of an aborted instance is dropped.
+	c.markAborted(key.Instance)
+	assert.True(t, c.IsDelivered(key.Instance))
+	assert.Equal(t, 0, len(c.EchosReceived.Rec))
+	assert.Equal(t, 0, len(c.ReadiesReceived.Rec))
+	assert.Equal(t, 0, len(c.EchosReceived.voted))
+	_, ok := c.ReadiesSent.Load(key.Instance)
+	assert.False(t, ok)
+}
+
+// echoShards adds ECHO of given shard indices to c, the shards are committed by their own Merkle tree.
+func echoShards(t *testing.T, c *Common, key InstanceKey, shards [][]byte, indices ...int) {
+	var contents []merkle.Content
+	for _, s := range shards {
+		contents = append(contents, merkle.BytesContent(s))
+	}
+	tree := &merkle.MerkleTree{}
+	assert.Nil(t, tree.Init(contents))
+	for _, i := range indices {
+		proof, err := merkle.GetProof(tree, contents[i])
+		assert.Nil(t, err)
+		_, err = c.EchosReceived.Add(fmt.Sprintf("f%d", i), key, &pb.Payload{MerkleProof: proof, Data: shards[i]})
+		assert.Nil(t, err)
+	}
+}
+
+func TestCommon_ReconstructDataVerifiesRoot(t *testing.T) {
+	peers := map[string]*Peer{"f0": {}, "f1": {}, "f2": {}, "f3": {}}
+	c := NewCommon("f0", RBCSetting{AllPeers: peers, ByzantineLimit: 1}, &testApp{}, nil)
+	data := []byte("a block that is broadcast")
+
+	shards, err := erasure.Split(data, 1, 4)
+	assert.Nil(t, err)
+	good := InstanceKey{Instance: Instance{Broadcaster: "mao", Sequence: 1}}
+	echoShards(t, &c, good, shards, 0, 3)
+	res, err := c.reconstructData(good)
+	assert.Nil(t, err)
+	assert.Equal(t, data, res)
+
+	// A faulty broadcaster commits to shards that are not an encoding of any data.
+	shards[3] = []byte(string(shards[3][:len(shards[3])-1]) + "x")
+	for i, indices := range [][]int{{0, 3}, {0, 1}} {
+		bad := InstanceKey{Instance: Instance{Broadcaster: "mao", Sequence: int64(i + 2)}}
+		echoShards(t, &c, bad, shards, indices...)
+		_, err = c.reconstructData(bad)
+		assert.Equal(t, ErrRootMismatch, err)
+	}
+}
+
+func TestCommon_TryDeliverOnce(t *testing.T) {
+	peers := map[string]*Peer{"f0": {}, "f1": {}, "f2": {}, "f3": {}}
+	app := &testApp{}
+	c := NewCommon("f0", RBCSetting{AllPeers: peers, ByzantineLimit: 1}, app, nil)
+	var deliveries []Delivery
+	c.OnDeliver(func(d Delivery) {
+		deliveries = append(deliveries, d)
+	})
+
+	for seq, data := range [][]byte{[]byte("first block"), []byte("second block")} {
+		shards, err := erasure.Split(data, 1, 4)
+		assert.Nil(t, err)
+		key := InstanceKey{Instance: Instance{Broadcaster: "mao", Sequence: int64(seq + 1)}}
+		// Not enough READY yet.
+		echoShards(t, &c, key, shards, 0, 1, 2, 3)
+		assert.Nil(t, c.tryDeliver(key))
+		assert.False(t, c.IsDelivered(key.Instance))
+		for _, p := range []string{"f0", "f1", "f2"} {
+			_, err = c.ReadiesReceived.Add(p, key, struct{}{})
+			assert.Nil(t, err)
+		}
+
+		// Every handler observing enough votes tries to deliver concurrently.
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.Nil(t, c.tryDeliver(key))
+			}()
+		}
+		wg.Wait()
+	}
+
+	assert.Equal(t, [][]byte{[]byte("first block"), []byte("second block")}, app.received)
+	assert.Equal(t, 2, len(deliveries))
+	for i, d := range deliveries {
+		assert.Equal(t, uint64(i+1), d.Index)
+		assert.Equal(t, int64(i+1), d.Instance.Sequence)
+		assert.Equal(t, app.received[i], d.Data)
+	}
+}
+
+func TestCommon_CheckShardIndex(t *testing.T) {
+	peers := map[string]*Peer{"f0": {}, "f1": {}, "f2": {}, "f3": {}}
+	c := NewCommon("f0", RBCSetting{AllPeers: peers, ByzantineLimit: 1}, &testApp{}, nil)
+	assert.Equal(t, 2, c.ShardIndex("f2"))
+	assert.Equal(t, -1, c.ShardIndex("mao"))
+
+	shards, err := erasure.Split([]byte("a block that is broadcast"), 1, 4)
+	assert.Nil(t, err)
+	var contents []merkle.Content
+	for _, s := range shards {
+		contents = append(contents, merkle.BytesContent(s))
+	}
+	tree := &merkle.MerkleTree{}
+	assert.Nil(t, tree.Init(contents))
+	for i := range shards {
+		proof, err := merkle.GetProof(tree, contents[i])
+		assert.Nil(t, err)
+		for j, peer := range []string{"f0", "f1", "f2", "f3"} {
+			err := c.checkShardIndex(peer, proof)
+			if i == j {
+				assert.Nil(t, err)
+				continue
+			}
+			// A replayed shard names both the sender and the owner of the shard.
+			assert.NotNil(t, err)
+			assert.Contains(t, err.Error(), peer)
+			assert.Contains(t, err.Error(), fmt.Sprintf("f%d", i))
+		}
+	}
+}
+
+func TestCommon_OutboxDropsWhenFullOrFailing(t *testing.T) {
+	peers := map[string]*Peer{"f0": {Name: "f0"}, "f1": {Name: "f1"}}
+	c := NewCommon("f0", RBCSetting{AllPeers: peers, OutboxSize: 1, MaxRetries: 1}, &testApp{}, nil)
+	defer c.Stop()
+
+	// f0 is blocked by its first message, the second one waits and the third one is dropped.
+	block := make(chan struct{})
+	started := make(chan struct{}, 3)
+	send := func(ctx context.Context) error {
+		started <- struct{}{}
+		<-block
+		return nil
+	}
+	c.Enqueue(peers["f0"], "BLOCK", send)
+	<-started
+	c.Enqueue(peers["f0"], "BLOCK", send)
+	c.Enqueue(peers["f0"], "BLOCK", send)
+	assert.Equal(t, OutboxStats{Depth: 1, DroppedFull: 1}, c.OutboxStats()["f0"])
+	close(block)
+	assert.Eventually(t, func() bool { return c.OutboxStats()["f0"].Sent == 2 }, time.Second, time.Millisecond)
+
+	// A failing message is retried once then dropped, it doesn't hold up f0.
+	attempts := int32(0)
+	c.Enqueue(peers["f1"], "FAIL", func(ctx context.Context) error {
+		atomic.AddInt32(&attempts, 1)
+		return status.Error(codes.Unavailable, "peer is down")
+	})
+	assert.Eventually(t, func() bool { return c.OutboxStats()["f1"].DroppedRetries == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+
+	// A message that the peer rejects is dropped right away.
+	for i, err := range []error{ErrInvalidSignature, errors.Wrap(ErrStale, "instance"), status.Error(codes.Unknown, "no")} {
+		err := err
+		c.Enqueue(peers["f1"], "REJECTED", func(ctx context.Context) error {
+			atomic.AddInt32(&attempts, 1)
+			return err
+		})
+		dropped := uint64(i + 1)
+		assert.Eventually(t, func() bool { return c.OutboxStats()["f1"].DroppedRejected == dropped }, time.Second,
+			time.Millisecond)
+	}
+	assert.Equal(t, int32(5), atomic.LoadInt32(&attempts))
+
+	// Nothing is sent after stop.
+	c.Stop()
+	c.Enqueue(peers["f1"], "STOPPED", func(ctx context.Context) error {
+		t.Error("message is sent after stop")
+		return nil
+	})
+	time.Sleep(10 * time.Millisecond)
+}
+
+func TestBackoff(t *testing.T) {
+	for retry := 0; retry < 64; retry++ {
+		d := retryBackoff << uint(retry)
+		if retry >= 16 || d > maxRetryBackoff {
+			d = maxRetryBackoff
+		}
+		for i := 0; i < 10; i++ {
+			b := backoff(retry)
+			assert.True(t, b >= d/2 && b < d, "backoff %s of retry %d", b, retry)
+		}
+	}
+}
+
+func TestCommon_VerifyEnvelope(t *testing.T) {
+	pub, priv := sign.GenerateKey()
+	peers := map[string]*Peer{"f1": {Name: "f1", PubKey: pub}}
+	sender := NewCommon("f1", RBCSetting{AllPeers: peers}, &testApp{}, priv)
+	receiver := NewCommon("f2", RBCSetting{AllPeers: peers}, &testApp{}, nil)
+	ctx := AuthenticatedContext(context.Background(), "f1")
+
+	payload := &pb.Payload{
+		MerkleProof: &pb.MerkleProof{Root: []byte("root")},
+		PrevHash:    []byte("prev"),
+		Data:        []byte("shard"),
+		Instance:    Instance{Broadcaster: "f1", Sequence: 1}.Pb(),
+	}
+	payload.Signature = sender.SignEnvelope(PayloadEnvelope(pb.MessageType_MT_ECHO, payload))
+	name, verified := receiver.Verify(ctx, PayloadEnvelope(pb.MessageType_MT_ECHO, payload), payload.Signature)
+	assert.True(t, verified)
+	assert.Equal(t, "f1", name)
+
+	// The signature doesn't hold for another message type.
+	_, verified = receiver.Verify(ctx, PayloadEnvelope(pb.MessageType_MT_PREPARE, payload), payload.Signature)
+	assert.False(t, verified)
+	// Every field is authenticated.
+	for _, tamper := range []func(p *pb.Payload){
+		func(p *pb.Payload) { p.PrevHash = []byte("poisoned") },
+		func(p *pb.Payload) { p.MerkleProof.Root = []byte("other") },
+		func(p *pb.Payload) { p.MerkleProof.ProofPairs = []*pb.ProofPair{{Primary: []byte("x")}} },
+		func(p *pb.Payload) { p.Data = []byte("other shard") },
+		func(p *pb.Payload) { p.Instance.Sequence = 2 },
+	} {
+		tampered := proto.Clone(payload).(*pb.Payload)
+		tamper(tampered)
+		_, verified = receiver.Verify(ctx, PayloadEnvelope(pb.MessageType_MT_ECHO, tampered), tampered.Signature)
+		assert.False(t, verified)
+	}
+
+	ready := &pb.ReadyRequest{MerkleRoot: []byte("root"), Instance: payload.Instance}
+	ready.Signature = sender.SignEnvelope(ReadyEnvelope(ready))
+	_, verified = receiver.Verify(ctx, ReadyEnvelope(ready), ready.Signature)
+	assert.True(t, verified)
+	ready.PrevHash = []byte("poisoned")
+	_, verified = receiver.Verify(ctx, ReadyEnvelope(ready), ready.Signature)
+	assert.False(t, verified)
+}
+
+// serveTLS starts TLS servers of a node named name with identity, they listen on random ports of p.
+func serveTLS(t *testing.T, name string, identity TLSIdentity, rs RBCSetting, p *Peer) func() {
+	c := NewCommon(name, rs, &testApp{}, nil)
+	assert.Nil(t, c.EnableTLS(identity))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	clientLis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	p.IP = "127.0.0.1"
+	p.PORT = lis.Addr().(*net.TCPAddr).Port
+	p.ClientPORT = clientLis.Addr().(*net.TCPAddr).Port
+	s := grpc.NewServer(c.ServerOptions()...)
+	RegisterNode(s, &c)
+	go s.Serve(lis)
+	clients := grpc.NewServer(c.ClientServerOptions()...)
+	RegisterClientService(clients, &c)
+	go clients.Serve(clientLis)
+	return func() {
+		s.Stop()
+		clients.Stop()
+	}
+}
+
+func TestCommon_MutualTLS(t *testing.T) {
+	authority, err := ca.New()
+	assert.Nil(t, err)
+	identities := map[string]TLSIdentity{}
+	for _, name := range []string{"f1", "f2", "f3"} {
+		cert, key, err := authority.Issue(name)
+		assert.Nil(t, err)
+		identities[name] = TLSIdentity{Cert: cert, Key: key}
+	}
+	rogue, err := ca.New()
+	assert.Nil(t, err)
+	cert, key, err := rogue.Issue("f2")
+	assert.Nil(t, err)
+	rogueIdentity := TLSIdentity{Cert: cert, Key: key}
+
+	peers := map[string]*Peer{
+		"f1": {Name: "f1", Cert: identities["f1"].Cert},
+		"f2": {Name: "f2", Cert: identities["f2"].Cert},
+	}
+	rs := RBCSetting{AllPeers: peers, CACert: authority.CertPEM}
+	send := func(name string, identity TLSIdentity, rs RBCSetting) error {
+		c := NewCommon(name, rs, &testApp{}, nil)
+		assert.Nil(t, c.EnableTLS(identity))
+		defer c.Stop()
+		return c.Transport.ViewChange(c.CreateContext(peers["f1"]), peers["f1"], &pb.ViewChangeRequest{})
+	}
+	// Handshake succeeds, the request reaches the handler.
+	handled := "view change is disabled"
+
+	stop := serveTLS(t, "f1", identities["f1"], rs, peers["f1"])
+	err = send("f2", identities["f2"], rs)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), handled)
+
+	// Clients without certificate are rejected at handshake on the port of peers, and served on the client port.
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(authority.CertPEM)
+	getStatus := func(port int) error {
+		conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", port),
+			grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: pool, ServerName: "f1"})))
+		assert.Nil(t, err)
+		defer conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_, err = pb.NewTransactionServiceClient(conn).GetTransactionStatus(ctx, &pb.GetTransactionStatusRequest{})
+		return err
+	}
+	err = getStatus(peers["f1"].PORT)
+	assert.NotNil(t, err)
+	assert.NotContains(t, err.Error(), "application doesn't serve transactions")
+	err = getStatus(peers["f1"].ClientPORT)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "application doesn't serve transactions")
+	// The client port serves no service of peers.
+	conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", peers["f1"].ClientPORT),
+		grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: pool, ServerName: "f1"})))
+	assert.Nil(t, err)
+	_, err = pb.NewViewChangeClient(conn).ViewChange(context.Background(), &pb.ViewChangeRequest{})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+	conn.Close()
+
+	// Certificate that isn't issued by CA is rejected.
+	err = send("f2", rogueIdentity, rs)
+	assert.NotNil(t, err)
+	assert.NotContains(t, err.Error(), handled)
+
+	// Certificate issued by CA is rejected if it doesn't belong to a peer of the cluster.
+	withF3 := RBCSetting{AllPeers: map[string]*Peer{"f1": peers["f1"], "f3": {Name: "f3", Cert: identities["f3"].Cert}},
+		CACert: authority.CertPEM}
+	err = send("f3", identities["f3"], withF3)
+	assert.NotNil(t, err)
+	assert.NotContains(t, err.Error(), handled)
+	stop()
+
+	// Server that presents certificate of another peer is rejected.
+	stop = serveTLS(t, "f2", identities["f2"], rs, peers["f1"])
+	defer stop()
+	err = send("f2", identities["f2"], rs)
+	assert.NotNil(t, err)
+	assert.NotContains(t, err.Error(), handled)
+}
+
+func TestCommon_AuthenticatedName(t *testing.T) {
+	authority, err := ca.New()
+	assert.Nil(t, err)
+	peers := map[string]*Peer{}
+	identities := map[string]TLSIdentity{}
+	keys := map[string]*[64]byte{}
+	for _, name := range []string{"f1", "f2", "f3"} {
+		cert, key, err := authority.Issue(name)
+		assert.Nil(t, err)
+		identities[name] = TLSIdentity{Cert: cert, Key: key}
+		pub, priv := sign.GenerateKey()
+		keys[name] = priv
+		peers[name] = &Peer{Name: name, Cert: cert, PubKey: pub}
+	}
+	rs := RBCSetting{AllPeers: peers, CACert: authority.CertPEM}
+	claim := func(ctx context.Context, name string) context.Context {
+		return metadata.NewIncomingContext(ctx, metadata.Pairs("name", name))
+	}
+	// received returns ctx of a request to to as its receiver sees it.
+	received := func(from *Common, to string) context.Context {
+		md, _ := metadata.FromOutgoingContext(from.CreateContext(peers[to]))
+		return metadata.NewIncomingContext(context.Background(), md)
+	}
+
+	// Without TLS, claimed name isn't trusted, even if it's a peer.
+	c := NewCommon("f1", rs, &testApp{}, keys["f1"])
+	_, err = c.getNameFromContext(claim(context.Background(), "f2"))
+	assert.NotNil(t, err)
+	_, err = c.getNameFromContext(claim(context.Background(), "mallory"))
+	assert.NotNil(t, err)
+	// Peer proves its name by handshake signed for this node.
+	f2 := NewCommon("f2", rs, &testApp{}, keys["f2"])
+	name, err := c.getNameFromContext(received(&f2, "f1"))
+	assert.Nil(t, err)
+	assert.Equal(t, "f2", name)
+	// Handshake for another node, of another cluster, or out of window is rejected.
+	_, err = c.getNameFromContext(received(&f2, "f3"))
+	assert.NotNil(t, err)
+	f2.ClusterID = "other"
+	_, err = c.getNameFromContext(received(&f2, "f1"))
+	assert.NotNil(t, err)
+	f2.ClusterID = ""
+	f2.Clock = fixedClock(time.Now().Add(-2 * HandshakeWindow))
+	_, err = c.getNameFromContext(received(&f2, "f1"))
+	assert.NotNil(t, err)
+	// Handshake signed by another key is rejected.
+	impostor := NewCommon("f2", rs, &testApp{}, keys["f3"])
+	_, err = c.getNameFromContext(received(&impostor, "f1"))
+	assert.NotNil(t, err)
+	// Transport authenticated name wins.
+	name, err = c.getNameFromContext(AuthenticatedContext(claim(context.Background(), "f1"), "f2"))
+	assert.Nil(t, err)
+	assert.Equal(t, "f2", name)
+
+	// With TLS, sender is identified by certificate only.
+	assert.Nil(t, c.EnableTLS(identities["f1"]))
+	block, _ := pem.Decode(identities["f2"].Cert)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	assert.Nil(t, err)
+	withCert := peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}},
+	})
+	name, err = c.getNameFromContext(claim(withCert, "f1"))
+	assert.Nil(t, err)
+	assert.Equal(t, "f2", name)
+	withoutCert := peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{}})
+	_, err = c.getNameFromContext(claim(withoutCert, "f2"))
+	assert.NotNil(t, err)
+	_, err = c.getNameFromContext(claim(context.Background(), "f2"))
+	assert.NotNil(t, err)
+}
+
+// forwardingApp records how transactions reach it in view change mode.
+type forwardingApp struct {
+	testApp
+	proposed, forwarded int
+}
+
+func (fa *forwardingApp) ProposeTransaction(*pb.Transaction) (string, error) {
+	fa.proposed++
+	return "id", nil
+}
+
+func (fa *forwardingApp) GetTransactionStatus(string) pb.TransactionStatus {
+	return pb.TransactionStatus_UNKNOWN
+}
+
+func (fa *forwardingApp) AcceptForwarded(*pb.Transaction) error {
+	fa.forwarded++
+	return nil
+}
+
+func (fa *forwardingApp) LastCommitted() (*pb.Block, int64) { return nil, 0 }
+
+func (fa *forwardingApp) NewView(int64, string, bool) {}
+
+// viewChangeVote returns the VIEW-CHANGE vote of signer for view, signer claims to have committed height blocks.
+func viewChangeVote(t *testing.T, signer *Common, view, height int64) *pb.ViewChangeRequest {
+	bytes, err := proto.Marshal(&pb.ViewChangeVote{NewView: view, Height: height, ClusterId: signer.ClusterID})
+	assert.Nil(t, err)
+	return &pb.ViewChangeRequest{Vote: signer.Sign(bytes)}
+}
+
+func TestCommon_ViewChangeVotesAhead(t *testing.T) {
+	rs, keys := signedPeers()
+	rs.ViewChangeTimeout = time.Second
+	c := NewCommon("f1", rs, &forwardingApp{}, keys["f1"])
+	c.Transport = NewMemoryNetwork().Endpoint("f1")
+	defer c.Stop()
+	f2 := NewCommon("f2", rs, &testApp{}, keys["f2"])
+	ctx := AuthenticatedContext(context.Background(), "f2")
+
+	// Votes are kept for up to N views ahead.
+	_, err := c.ViewChange(ctx, viewChangeVote(t, &f2, 4, 0))
+	assert.Nil(t, err)
+	for _, view := range []int64{5, 1 << 62} {
+		_, err = c.ViewChange(ctx, viewChangeVote(t, &f2, view, 0))
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 1, len(c.View.votes))
+}
+
+func TestCommon_ForwardedOnlyByPeers(t *testing.T) {
+	rs, keys := signedPeers()
+	rs.ViewChangeTimeout = time.Second
+	app := &forwardingApp{}
+	c := NewCommon("f1", rs, app, keys["f1"])
+	in := &pb.ProposeTransactionRequest{Transaction: &pb.Transaction{}}
+
+	// Client that claims name of a peer is still a client.
+	claimed := metadata.NewIncomingContext(context.Background(), metadata.Pairs("name", "f2"))
+	_, err := c.ProposeTransaction(claimed, in)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, app.proposed)
+	assert.Equal(t, 0, app.forwarded)
+
+	f2 := NewCommon("f2", rs, &testApp{}, keys["f2"])
+	md, _ := metadata.FromOutgoingContext(f2.CreateContext(rs.AllPeers["f1"]))
+	_, err = c.ProposeTransaction(metadata.NewIncomingContext(context.Background(), md), in)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, app.proposed)
+	assert.Equal(t, 1, app.forwarded)
+}
+
+func TestCommon_CheckFresh(t *testing.T) {
+	c := NewCommon("f1", RBCSetting{SequenceWindow: 10}, &testApp{}, nil)
+	at := func(epoch, sequence int64) Instance {
+		return Instance{Broadcaster: "mao", Epoch: epoch, Sequence: sequence}
+	}
+	// Other peers can't move the window.
+	assert.Nil(t, c.checkFresh(at(0, 1000), false))
+	assert.Nil(t, c.checkFresh(at(0, 100), true))
+	assert.NotNil(t, c.checkFresh(at(0, 1000), false))
+	assert.Nil(t, c.checkFresh(at(0, 110), false))
+
+	assert.Nil(t, c.checkFresh(at(0, 105), true))
+	assert.Nil(t, c.checkFresh(at(0, 96), false))
+	err := c.checkFresh(at(0, 95), false)
+	assert.Equal(t, ErrStale, errors.Cause(err))
+	// Broadcaster moves to a new epoch once this node is in its view.
+	c.View.Current = 1
+	assert.Nil(t, c.checkFresh(at(1, 1), true))
+	assert.Equal(t, ErrStale, errors.Cause(c.checkFresh(at(0, 105), false)))
+	assert.Nil(t, c.checkFresh(at(1, 2), false))
+
+	// Forgotten instances are stale even without window.
+	c.replay.forget(at(1, 5))
+	assert.Equal(t, ErrStale, errors.Cause(c.checkFresh(at(1, 3), false)))
+	assert.Nil(t, c.checkFresh(at(1, 6), false))
+	assert.Nil(t, c.checkFresh(Instance{Broadcaster: "f2", Epoch: 1, Sequence: 1}, false))
+}
+
+func TestCommon_CheckEpoch(t *testing.T) {
+	c := NewCommon("f1", RBCSetting{}, &testApp{}, nil)
+	at := func(epoch int64) Instance {
+		return Instance{Broadcaster: "mao", Epoch: epoch, Sequence: 1}
+	}
+	// In fixed leader mode, broadcaster can't pick another epoch.
+	assert.Nil(t, c.checkFresh(at(0), true))
+	err := c.checkFresh(at(5), true)
+	assert.NotNil(t, err)
+	assert.NotEqual(t, ErrStale, errors.Cause(err))
+
+	// The next view is accepted while this node votes to move there.
+	c.View.sent = 1
+	assert.Nil(t, c.checkFresh(at(1), true))
+	assert.NotNil(t, c.checkFresh(at(2), true))
+	c.View.Current, c.View.sent = 2, 2
+	assert.Equal(t, ErrStale, errors.Cause(c.checkFresh(at(1), false)))
+	assert.Nil(t, c.checkFresh(at(2), true))
+	assert.NotNil(t, c.checkFresh(at(3), true))
+
+	// Modes whose epochs aren't views check them on their own.
+	c.CheckEpochs(func(epoch int64) error { return nil })
+	assert.Nil(t, c.checkFresh(at(7), true))
+}
+
+func TestCommon_VerifyEnvelopeOfCluster(t *testing.T) {
+	pub, priv := sign.GenerateKey()
+	peers := map[string]*Peer{"f1": {Name: "f1", PubKey: pub}}
+	sender := NewCommon("f1", RBCSetting{AllPeers: peers, ClusterID: "a"}, &testApp{}, priv)
+	ctx := AuthenticatedContext(context.Background(), "f1")
+	ready := &pb.ReadyRequest{MerkleRoot: []byte("root"), Instance: Instance{Broadcaster: "f1", Sequence: 1}.Pb()}
+	ready.Signature = sender.SignEnvelope(ReadyEnvelope(ready))
+
+	same := NewCommon("f2", RBCSetting{AllPeers: peers, ClusterID: "a"}, &testApp{}, nil)
+	_, verified := same.Verify(ctx, ReadyEnvelope(ready), ready.Signature)
+	assert.True(t, verified)
+	other := NewCommon("f2", RBCSetting{AllPeers: peers, ClusterID: "b"}, &testApp{}, nil)
+	_, verified = other.Verify(ctx, ReadyEnvelope(ready), ready.Signature)
+	assert.False(t, verified)
+}
+
+// signedPrepare returns PREPARE of data on parent by leader in epoch 0, it carries the shard of the first peer.
+func signedPrepare(t *testing.T, leader *Common, sequence int64, parent, data string) *pb.Payload {
+	return signedPrepareIn(t, leader, 0, sequence, parent, data)
+}
+
+// signedPrepareIn is signedPrepare in epoch.
+func signedPrepareIn(t *testing.T, leader *Common, epoch, sequence int64, parent, data string) *pb.Payload {
+	shards, err := erasure.Split([]byte(data), 1, 4)
+	assert.Nil(t, err)
+	var contents []merkle.Content
+	for _, s := range shards {
+		contents = append(contents, merkle.BytesContent(s))
+	}
+	tree := &merkle.MerkleTree{}
+	assert.Nil(t, tree.Init(contents))
+	proof, err := merkle.GetProof(tree, contents[0])
+	assert.Nil(t, err)
+	req := &pb.Payload{
+		MerkleProof: proof,
+		Data:        shards[0],
+		PrevHash:    []byte(parent),
+		Instance:    Instance{Broadcaster: leader.Name(), Epoch: epoch, Sequence: sequence}.Pb(),
+	}
+	req.Signature = leader.SignEnvelope(PayloadEnvelope(pb.MessageType_MT_PREPARE, req))
+	return req
+}
+
+// fixedClock is a Clock that's stopped at its time.
+type fixedClock time.Time
+
+func (f fixedClock) Now() time.Time { return time.Time(f) }
+
+func (fixedClock) AfterFunc(time.Duration, func()) {}
+
+// signedPeers returns settings of peers f0 to f3 with their private keys.
+func signedPeers() (RBCSetting, map[string]*[64]byte) {
+	keys := make(map[string]*[64]byte)
+	peers := make(map[string]*Peer)
+	for _, name := range []string{"f0", "f1", "f2", "f3"} {
+		pub, priv := sign.GenerateKey()
+		keys[name] = priv
+		peers[name] = &Peer{Name: name, PubKey: pub}
+	}
+	return RBCSetting{AllPeers: peers, ByzantineLimit: 1}, keys
+}
+
+func TestVoteLocks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "*")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	rs, keys := signedPeers()
+	leader := NewCommon("f1", rs, &testApp{}, keys["f1"])
+	first := signedPrepare(t, &leader, 1, "parent", "first")
+	second := signedPrepare(t, &leader, 2, "parent", "second")
+
+	locks, err := OpenVoteLocks(dir)
+	assert.Nil(t, err)
+	locked, err := locks.Lock(0, first)
+	assert.Nil(t, err)
+	assert.Equal(t, first, locked)
+	// Parent stays locked to the first PREPARE.
+	locked, err = locks.Lock(0, second)
+	assert.Nil(t, err)
+	assert.Equal(t, first, locked)
+	// A new leader may propose another block on the same parent.
+	locked, err = locks.Lock(1, second)
+	assert.Nil(t, err)
+	assert.Equal(t, second, locked)
+
+	// Locks survive restart.
+	reopened, err := OpenVoteLocks(dir)
+	assert.Nil(t, err)
+	locked, ok := reopened.Locked(0, []byte("parent"))
+	assert.True(t, ok)
+	assert.True(t, proto.Equal(first, locked))
+	locked, err = reopened.Lock(0, second)
+	assert.Nil(t, err)
+	assert.True(t, proto.Equal(first, locked))
+
+	// Lock is released once its block is committed, other locks stay.
+	root := merkle.MerkleRootToString(PayloadRoot(first))
+	reopened.Release(InstanceKey{Instance: Instance{Broadcaster: "f1", Sequence: 1}, Root: root})
+	_, ok = reopened.Locked(0, []byte("parent"))
+	assert.False(t, ok)
+	_, ok = reopened.Locked(1, []byte("parent"))
+	assert.True(t, ok)
+	files, err := ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(files))
+}
+
+func TestCommon_PrepareLocksParentOnce(t *testing.T) {
+	rs, keys := signedPeers()
+	leader := NewCommon("f1", rs, &testApp{}, keys["f1"])
+	ctx := AuthenticatedContext(context.Background(), "f1")
+	app := &testApp{committed: map[string]bool{}}
+	c := NewCommon("f0", rs, app, keys["f0"])
+	c.Transport = NewMemoryNetwork().Endpoint("f0")
+	defer c.Stop()
+
+	first := signedPrepare(t, &leader, 1, "parent", "first block")
+	_, err := c.Prepare(ctx, first)
+	assert.Nil(t, err)
+	// Leader can't pick another epoch to vote again on the same parent.
+	_, err = c.Prepare(ctx, signedPrepareIn(t, &leader, 5, 2, "parent", "second block"))
+	assert.NotNil(t, err)
+	_, ok := c.VoteLocks.Locked(5, []byte("parent"))
+	assert.False(t, ok)
+	_, err = c.Prepare(ctx, signedPrepare(t, &leader, 3, "parent", "third block"))
+	assert.NotNil(t, err)
+
+	// Lock is released once the block is committed.
+	key := InstanceKey{Instance: Instance{Broadcaster: "f1", Sequence: 1}, Root: merkle.MerkleRootToString(PayloadRoot(first))}
+	c.markDelivered(key, []byte("first block"))
+	app.committed["first block"] = true
+	c.Retention.mu.Lock()
+	c.collectGarbage(time.Now().Add(2 * c.retentionWindow()))
+	c.Retention.mu.Unlock()
+	_, ok = c.VoteLocks.Locked(0, []byte("parent"))
+	assert.False(t, ok)
+}
+
+type persistentTestApp struct {
+	testApp
+	dir string
+}
+
+func (pa *persistentTestApp) StateDir() string { return pa.dir }
+
+func TestCommon_PrepareRefusesSecondRootAfterRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "*")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	rs, keys := signedPeers()
+	leader := NewCommon("f1", rs, &testApp{}, keys["f1"])
+	ctx := AuthenticatedContext(context.Background(), "f1")
+
+	c := NewCommon("f0", rs, &persistentTestApp{dir: dir}, keys["f0"])
+	c.Transport = NewMemoryNetwork().Endpoint("f0")
+	_, err = c.Prepare(ctx, signedPrepare(t, &leader, 1, "parent", "first block"))
+	assert.Nil(t, err)
+	c.Stop()
+
+	restarted := NewCommon("f0", rs, &persistentTestApp{dir: dir}, keys["f0"])
+	restarted.Transport = NewMemoryNetwork().Endpoint("f0")
+	defer restarted.Stop()
+	_, err = restarted.Prepare(ctx, signedPrepare(t, &leader, 2, "parent", "second block"))
+	assert.NotNil(t, err)
+	misbehaviours := restarted.Misbehaviours()
+	assert.Equal(t, 1, len(misbehaviours))
+	assert.Equal(t, "f1", misbehaviours[0].Peer)
+}
+
+func TestCommon_MisbehavioursBounded(t *testing.T) {
+	rs, keys := signedPeers()
+	c := NewCommon("f0", rs, &testApp{}, keys["f0"])
+	defer c.Stop()
+
+	// Repeated misbehaviour in an instance is kept once.
+	for i := 0; i < 3; i++ {
+		c.RecordMisbehaviour("f1", Instance{Broadcaster: "f1", Sequence: 1}, "equivocation")
+	}
+	assert.Equal(t, 1, len(c.Misbehaviours()))
+
+	for i := 2; i <= MaxMisbehaviours+1; i++ {
+		c.RecordMisbehaviour("f1", Instance{Broadcaster: "f1", Sequence: int64(i)}, "equivocation")
+	}
+	misbehaviours := c.Misbehaviours()
+	assert.Equal(t, MaxMisbehaviours, len(misbehaviours))
+	assert.Equal(t, int64(2), misbehaviours[0].Instance.Sequence)
+	assert.Equal(t, int64(MaxMisbehaviours+1), misbehaviours[MaxMisbehaviours-1].Instance.Sequence)
+}
+
+func TestCommon_EquivocationEvidence(t *testing.T) {
+	rs, keys := signedPeers()
+	network := NewMemoryNetwork()
+	network.DialTimeout = 0
+	nodes := make(map[string]*Common)
+	for _, name := range []string{"f0", "f2", "f3"} {
+		node := NewCommon(name, rs, &testApp{}, keys[name])
+		node.Transport = network.Endpoint(name)
+		defer node.Stop()
+		network.Attach(name, &node)
+		nodes[name] = &node
+	}
+	leader := NewCommon("f1", rs, &testApp{}, keys["f1"])
+	ctx := AuthenticatedContext(context.Background(), "f1")
+	first := signedPrepare(t, &leader, 1, "parent", "first block")
+	second := signedPrepare(t, &leader, 2, "parent", "second block")
+
+	// Forged evidence is rejected.
+	forged := proto.Clone(second).(*pb.Payload)
+	forged.Data = []byte("forged")
+	_, err := nodes["f2"].ReportEquivocation(ctx, &pb.EquivocationEvidence{First: first, Second: forged})
+	assert.NotNil(t, err)
+	_, err = nodes["f2"].ReportEquivocation(ctx, &pb.EquivocationEvidence{First: first, Second: first})
+	assert.NotNil(t, err)
+	assert.False(t, nodes["f2"].IsConvicted("f1"))
+
+	_, err = nodes["f0"].Prepare(ctx, first)
+	assert.Nil(t, err)
+	_, err = nodes["f0"].Prepare(ctx, second)
+	assert.NotNil(t, err)
+	assert.True(t, nodes["f0"].IsConvicted("f1"))
+
+	// Evidence is gossiped to other peers, which stop accepting proposals of the leader.
+	for _, name := range []string{"f2", "f3"} {
+		assert.Eventually(t, func() bool { return nodes[name].IsConvicted("f1") }, 5*time.Second, 10*time.Millisecond)
+		res, err := nodes[name].GetEquivocationEvidence(context.Background(), &pb.GetEquivocationEvidenceRequest{})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(res.Evidence))
+		assert.True(t, proto.Equal(first, res.Evidence[0].First))
+		_, err = nodes[name].Prepare(ctx, signedPrepare(t, &leader, 3, "other parent", "third block"))
+		assert.NotNil(t, err)
+	}
+	// Evidence is kept once per leader.
+	_, err = nodes["f2"].ReportEquivocation(ctx, &pb.EquivocationEvidence{First: second, Second: first})
+	assert.Nil(t, err)
+	res, err := nodes["f2"].GetEquivocationEvidence(context.Background(), &pb.GetEquivocationEvidenceRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(res.Evidence))
+}
+
+func TestCommon_EquivocationEvidenceAcrossEpochs(t *testing.T) {
+	rs, keys := signedPeers()
+	leader := NewCommon("f1", rs, &testApp{}, keys["f1"])
+	ctx := AuthenticatedContext(context.Background(), "f2")
+	evidence := &pb.EquivocationEvidence{
+		First:  signedPrepare(t, &leader, 1, "parent", "first block"),
+		Second: signedPrepareIn(t, &leader, 5, 2, "parent", "second block"),
+	}
+
+	// In fixed leader mode, a parent is locked once whatever the epoch.
+	c := NewCommon("f0", rs, &testApp{}, keys["f0"])
+	c.Transport = NewMemoryNetwork().Endpoint("f0")
+	defer c.Stop()
+	_, err := c.ReportEquivocation(ctx, evidence)
+	assert.Nil(t, err)
+	assert.True(t, c.IsConvicted("f1"))
+
+	// In view change mode, a leader may propose on the same parent again in another view.
+	rs.ViewChangeTimeout = time.Second
+	vc := NewCommon("f0", rs, &testApp{}, keys["f0"])
+	_, err = vc.ReportEquivocation(ctx, evidence)
+	assert.NotNil(t, err)
+	assert.False(t, vc.IsConvicted("f1"))
+}
+
+// redirectAdversary sends messages to from to to instead, and drops messages received from deaf.
+type redirectAdversary struct {
+	from, to, deaf string
+}
+
+func (r *redirectAdversary) Outgoing(node *Common, m Message) []Message {
+	if m.Peer == r.from {
+		m.Peer = r.to
+	}
+	return []Message{m}
+}
+
+func (r *redirectAdversary) Incoming(node *Common, m Message) []Message {
+	if m.Peer == r.deaf {
+		return nil
+	}
+	return []Message{m}
+}
+
+func TestCommon_Adversary(t *testing.T) {
+	rs, keys := signedPeers()
+	network := NewMemoryNetwork()
+	nodes := make(map[string]*Common)
+	for _, name := range []string{"f0", "f1", "f2"} {
+		node := NewCommon(name, rs, &testApp{}, keys[name])
+		node.Transport = network.Endpoint(name)
+		defer node.Stop()
+		network.Attach(name, &node)
+		nodes[name] = &node
+	}
+	nodes["f0"].SetAdversary(&redirectAdversary{from: "f1", to: "f2", deaf: "f2"})
+	instance := Instance{Broadcaster: "f3", Sequence: 1}
+	key := InstanceKey{Instance: instance, Root: merkle.MerkleRootToString([]byte("root"))}
+
+	// READY to f1 reaches f2.
+	nodes["f0"].SendReady(rs.AllPeers["f1"], instance, []byte("root"))
+	assert.Eventually(t, func() bool { return nodes["f2"].ReadiesReceived.Count(key) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, 0, nodes["f1"].ReadiesReceived.Count(key))
+	_, ok := nodes["f0"].OutboxStats()["f1"]
+	assert.False(t, ok)
+
+	// READY from f2 is dropped by f0, READY from f1 is handled.
+	for _, name := range []string{"f1", "f2"} {
+		ready := &pb.ReadyRequest{MerkleRoot: []byte("root"), Instance: instance.Pb()}
+		ready.Signature = nodes[name].SignEnvelope(ReadyEnvelope(ready))
+		_, err := nodes["f0"].Ready(AuthenticatedContext(context.Background(), name), ready)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 1, nodes["f0"].ReadiesReceived.Count(key))
+
+	// Honest again.
+	nodes["f0"].SetAdversary(nil)
+	nodes["f0"].SendReady(rs.AllPeers["f1"], instance, []byte("root"))
+	assert.Eventually(t, func() bool { return nodes["f1"].ReadiesReceived.Count(key) == 1 }, time.Second, time.Millisecond)
+}
+
+func TestCommon_QuorumCertificate(t *testing.T) {
+	setting, keys := signedPeers()
+	setting.ClusterID = "cluster"
+	c := NewCommon("f0", setting, &testApp{}, keys["f0"])
+	block, err := mao_utils.CreateBlockFromTxsAndPrevHash(nil, []byte("parent"))
+	assert.Nil(t, err)
+	data, err := mao_utils.EncodeBlock(block)
+	assert.Nil(t, err)
+	root, err := DataRoot(data, 1, 4)
+	assert.Nil(t, err)
+	key := InstanceKey{Instance: Instance{Broadcaster: "f0", Sequence: 1}, Root: merkle.MerkleRootToString(root)}
+	for _, name := range []string{"f3", "f2", "f1", "f0"} {
+		signer := NewCommon(name, setting, &testApp{}, keys[name])
+		ready := &pb.ReadyRequest{MerkleRoot: root, Instance: key.Instance.Pb()}
+		ready.Signature = signer.SignEnvelope(ReadyEnvelope(ready))
+		_, err := c.ReadiesReceived.Add(name, key, ready)
+		assert.Nil(t, err)
+	}
+
+	// 2f+1 READYs make the certificate, in order of their senders.
+	block.Certificate = c.certificate(key)
+	assert.Equal(t, 3, len(block.Certificate.Readies))
+	assert.Equal(t, "f0", block.Certificate.Readies[0].Peer)
+	assert.Nil(t, VerifyCertificate(&setting, block.Certificate, data))
+	assert.Nil(t, VerifyBlockCertificate(&setting, block))
+
+	other := setting
+	other.ClusterID = "other"
+	assert.Error(t, VerifyBlockCertificate(&other, block))
+	assert.Error(t, VerifyCertificate(&setting, block.Certificate, []byte("other data")))
+	assert.Error(t, VerifyCertificate(&setting, nil, data))
+	for _, tamper := range []func(qc *pb.QuorumCertificate){
+		func(qc *pb.QuorumCertificate) { qc.Readies = qc.Readies[:2] },
+		func(qc *pb.QuorumCertificate) { qc.Readies[1] = qc.Readies[0] },
+		func(qc *pb.QuorumCertificate) { qc.Readies[1].Peer = "f9" },
+		func(qc *pb.QuorumCertificate) { qc.Readies[1].PrevHash = []byte("poisoned") },
+		func(qc *pb.QuorumCertificate) { qc.Instance.Sequence = 2 },
+	} {
+		tampered := proto.Clone(block).(*pb.Block)
+		tamper(tampered.Certificate)
+		assert.Error(t, VerifyBlockCertificate(&setting, tampered))
+	}
+}
+
+// syncApp answers sync with answer, and keeps certificates of blocks it commits.
+type syncApp struct {
+	testApp
+	question     *pb.SyncRequest
+	answer       *pb.SyncResponse
+	certificates []*pb.QuorumCertificate
+}
+
+func (sa *syncApp) GetSyncQuestion() (*pb.SyncRequest, error) { return sa.question, nil }
+
+func (sa *syncApp) GetSyncAnswer(*pb.SyncRequest) (*pb.SyncResponse, error) {
+	if sa.answer == nil {
+		return nil, errors.New("No answer found.")
+	}
+	return sa.answer, nil
+}
+
+func (sa *syncApp) RBCReceiveCertified(data []byte, certificate *pb.QuorumCertificate) (bool, error) {
+	sa.certificates = append(sa.certificates, certificate)
+	return sa.RBCReceive(data)
+}
+
+// syncAnswerer returns f1 answering sync with a block on lastCommit, data is the block certified by READYs of 2f+1
+// peers.
+func syncAnswerer(t *testing.T, setting RBCSetting, keys map[string]*[64]byte) (f1 *Common, lastCommit, data []byte,
+	certificate *pb.QuorumCertificate) {
+	parent, err := mao_utils.CreateBlockFromTxsAndPrevHash(nil, []byte("genesis"))
+	assert.Nil(t, err)
+	lastCommit, err = mao_utils.EncodeBlock(parent)
+	assert.Nil(t, err)
+	block, err := mao_utils.CreateBlockFromTxsAndPrevHash(nil, parent.CurHash)
+	assert.Nil(t, err)
+	data, err = mao_utils.EncodeBlock(block)
+	assert.Nil(t, err)
+
+	root, err := DataRoot(data, 1, 4)
+	assert.Nil(t, err)
+	key := InstanceKey{Instance: Instance{Broadcaster: "f1", Sequence: 1}, Root: merkle.MerkleRootToString(root)}
+	answerer := &syncApp{}
+	c := NewCommon("f1", setting, answerer, keys["f1"])
+	for _, name := range []string{"f1", "f2", "f3"} {
+		signer := NewCommon(name, setting, &testApp{}, keys[name])
+		ready := &pb.ReadyRequest{MerkleRoot: root, Instance: key.Instance.Pb()}
+		ready.Signature = signer.SignEnvelope(ReadyEnvelope(ready))
+		_, err := c.ReadiesReceived.Add(name, key, ready)
+		assert.Nil(t, err)
+	}
+	certified := proto.Clone(block).(*pb.Block)
+	certified.Certificate = c.certificate(key)
+	answer, err := mao_utils.EncodeBlock(certified)
+	assert.Nil(t, err)
+	answerer.answer = &pb.SyncResponse{Response: [][]byte{answer}}
+	return &c, lastCommit, data, certified.Certificate
+}
+
+func TestCommon_SyncKeepsCertificates(t *testing.T) {
+	setting, keys := signedPeers()
+	setting.ClusterID = "cluster"
+	f1, lastCommit, data, certificate := syncAnswerer(t, setting, keys)
+	defer f1.Stop()
+	network := NewMemoryNetwork()
+	network.Attach("f1", f1)
+	app := &syncApp{question: &pb.SyncRequest{LastCommit: lastCommit}}
+	c := NewCommon("f0", setting, app, keys["f0"])
+	c.Transport = network.Endpoint("f0")
+	defer c.Stop()
+
+	// The synced block is committed along with its certificate.
+	c.Synchronize()
+	assert.Equal(t, [][]byte{data}, app.received)
+	assert.Equal(t, 1, len(app.certificates))
+	assert.True(t, proto.Equal(certificate, app.certificates[0]))
+	assert.Nil(t, VerifyCertificate(&setting, app.certificates[0], data))
+
+	// A sync request without a valid last commit is refused.
+	_, err := c.sendSync(setting.AllPeers["f1"], &pb.SyncRequest{LastCommit: []byte("not a block")})
+	assert.Error(t, err)
+}
+
+// catchUpApp is a syncApp in view change mode, it has committed lastCommit only until it receives blocks.
+type catchUpApp struct {
+	forwardingApp
+	syncApp
+	lastCommit *pb.Block
+}
+
+func (ca *catchUpApp) RBCReceive(data []byte) (bool, error) { return ca.syncApp.RBCReceive(data) }
+
+func (ca *catchUpApp) IsCommitted(data []byte) bool { return ca.syncApp.IsCommitted(data) }
+
+func (ca *catchUpApp) GetSyncQuestion() (*pb.SyncRequest, error) { return ca.syncApp.GetSyncQuestion() }
+
+func (ca *catchUpApp) GetSyncAnswer(req *pb.SyncRequest) (*pb.SyncResponse, error) {
+	return ca.syncApp.GetSyncAnswer(req)
+}
+
+func (ca *catchUpApp) LastCommitted() (*pb.Block, int64) {
+	return ca.lastCommit, int64(len(ca.syncApp.received))
+}
+
+func TestCommon_CatchUpSkipsFailedVoters(t *testing.T) {
+	setting, keys := signedPeers()
+	setting.ClusterID = "cluster"
+	setting.ViewChangeTimeout = time.Second
+	f1, lastCommit, data, _ := syncAnswerer(t, setting, keys)
+	defer f1.Stop()
+	network := NewMemoryNetwork()
+	network.DialTimeout = 0
+	network.Attach("f1", f1)
+	parent, err := mao_utils.DecodeBlock(lastCommit)
+	assert.Nil(t, err)
+	app := &catchUpApp{lastCommit: parent}
+	c := NewCommon("f0", setting, app, keys["f0"])
+	c.Transport = network.Endpoint("f0")
+	defer c.Stop()
+
+	// f3 claims the most blocks but can't be synced from, f1 is tried next.
+	for name, height := range map[string]int64{"f3": 100, "f1": 1, "f2": 0} {
+		signer := NewCommon(name, setting, &testApp{}, keys[name])
+		_, err := c.ViewChange(AuthenticatedContext(context.Background(), name), viewChangeVote(t, &signer, 1, height))
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, int64(1), c.CurrentView())
+	c.CatchUp()
+	assert.Equal(t, [][]byte{data}, app.syncApp.received)
+	assert.Equal(t, 1, len(app.certificates))
+}
+
+func TestCommon_ReplicatedDelivery(t *testing.T) {
+	setting, keys := signedPeers()
+	setting.ReplicatedLimit = 16
+	app := &testApp{}
+	c := NewCommon("f0", setting, app, keys["f0"])
+	sender := NewCommon("f1", setting, &testApp{}, keys["f1"])
+	data := []byte("small block")
+	assert.True(t, c.Replicated(data))
+	assert.False(t, c.Replicated(make([]byte, 17)))
+	instance := Instance{Broadcaster: "f1", Sequence: 1}
+
+	echo := &pb.Payload{Mode: pb.BroadcastMode_BM_REPLICATED, Data: data, Instance: instance.Pb()}
+	echo.Signature = sender.SignEnvelope(PayloadEnvelope(pb.MessageType_MT_ECHO, echo))
+	unknown := proto.Clone(echo).(*pb.Payload)
+	unknown.Mode = 7
+	unknown.Signature = sender.SignEnvelope(PayloadEnvelope(pb.MessageType_MT_ECHO, unknown))
+	assert.Error(t, c.echo(AuthenticatedContext(context.Background(), "f1"), unknown))
+	// The mode is signed.
+	tampered := proto.Clone(echo).(*pb.Payload)
+	tampered.Mode = pb.BroadcastMode_BM_AVID
+	assert.Error(t, c.echo(AuthenticatedContext(context.Background(), "f1"), tampered))
+	assert.Nil(t, c.echo(AuthenticatedContext(context.Background(), "f1"), echo))
+
+	// One copy of the value and 2f+1 READY of its hash deliver it.
+	key := InstanceKey{Instance: instance, Root: merkle.MerkleRootToString(ValueHash(data))}
+	assert.Equal(t, 1, c.EchosReceived.Count(key))
+	for _, name := range []string{"f0", "f1", "f2"} {
+		signer := NewCommon(name, setting, &testApp{}, keys[name])
+		ready := &pb.ReadyRequest{MerkleRoot: ValueHash(data), Instance: instance.Pb()}
+		ready.Signature = signer.SignEnvelope(ReadyEnvelope(ready))
+		_, err := c.ReadiesReceived.Add(name, key, ready)
+		assert.Nil(t, err)
+	}
+	certificate := c.certificate(key)
+	assert.Nil(t, c.tryDeliver(key))
+	assert.Equal(t, [][]byte{data}, app.received)
+
+	assert.Equal(t, pb.BroadcastMode_BM_REPLICATED, certificate.Mode)
+	assert.Nil(t, VerifyCertificate(&setting, certificate, data))
+	certificate.Mode = pb.BroadcastMode_BM_AVID
+	assert.Error(t, VerifyCertificate(&setting, certificate, data))
+}
+
+func TestRBCSetting_Quorums(t *testing.T) {
+	cluster := func(n, f int) *RBCSetting {
+		peers := make(map[string]*Peer)
+		for i := 0; i < n; i++ {
+			peers[fmt.Sprintf("f%d", i)] = &Peer{}
+		}
+		return &RBCSetting{AllPeers: peers, ByzantineLimit: f}
+	}
+	for _, c := range []struct{ n, f, echo, amplify, ready, shards int }{
+		{4, 1, 3, 2, 3, 2},
+		{5, 1, 4, 2, 3, 3},
+		{7, 2, 5, 3, 5, 3},
+		{10, 3, 7, 4, 7, 4},
+	} {
+		rs := cluster(c.n, c.f)
+		assert.Nil(t, rs.Validate())
+		assert.Equal(t, c.echo, rs.EchoQuorum(), "n %d, f %d", c.n, c.f)
+		assert.Equal(t, c.amplify, rs.ReadyAmplification(), "n %d, f %d", c.n, c.f)
+		assert.Equal(t, c.ready, rs.ReadyQuorum(), "n %d, f %d", c.n, c.f)
+		assert.Equal(t, c.shards, rs.DataShards(), "n %d, f %d", c.n, c.f)
+		// Two echo quorums share a correct peer.
+		assert.True(t, 2*rs.EchoQuorum()-c.n > c.f)
+	}
+	assert.Error(t, cluster(6, 2).Validate())
+	assert.Error(t, cluster(0, 0).Validate())
+	assert.Error(t, cluster(4, -1).Validate())
+}
+
+// recordingAdversary records messages this node receives, and drops them.
+type recordingAdversary struct {
+	received []Message
+	mu       sync.Mutex
+}
+
+func (r *recordingAdversary) Outgoing(node *Common, m Message) []Message { return []Message{m} }
+
+func (r *recordingAdversary) Incoming(node *Common, m Message) []Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.received = append(r.received, m)
+	return nil
+}
+
+func (r *recordingAdversary) Received() []Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Message(nil), r.received...)
+}
+
+// signedReady returns a READY in instance signed by c, prevHash tells READYs apart.
+func signedReady(c *Common, instance Instance, prevHash byte) *pb.ReadyRequest {
+	ready := &pb.ReadyRequest{Instance: instance.Pb(), PrevHash: []byte{prevHash}}
+	ready.Signature = c.SignEnvelope(ReadyEnvelope(ready))
+	return ready
+}
+
+// serveGRPC starts a server of c that serves services registered by register, it listens on a random port of p.
+func serveGRPC(t *testing.T, c *Common, p *Peer, register func(s *grpc.Server)) func() {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	p.IP = "127.0.0.1"
+	p.PORT = lis.Addr().(*net.TCPAddr).Port
+	s := grpc.NewServer()
+	register(s)
+	go s.Serve(lis)
+	return func() {
+		c.Stop()
+		s.GracefulStop()
+	}
+}
+
+func TestGRPCTransport_ConsensusStream(t *testing.T) {
+	rs, keys := signedPeers()
+	peers := rs.AllPeers
+	receiver := NewCommon("f1", rs, &testApp{}, keys["f1"])
+	recorder := &recordingAdversary{}
+	receiver.SetAdversary(recorder)
+	stop := serveGRPC(t, &receiver, peers["f1"], func(s *grpc.Server) { RegisterNode(s, &receiver) })
+	defer stop()
+
+	sender := NewCommon("f2", rs, &testApp{}, keys["f2"])
+	transport := &GRPCTransport{StreamWindow: 4}
+	defer transport.Close()
+	instance := Instance{Broadcaster: "f1", Sequence: 1}
+	// Messages of all types share one stream, and arrive in order although only 4 can be unacknowledged.
+	for i := 0; i < 20; i++ {
+		ctx, cancel := context.WithTimeout(sender.CreateContext(peers["f1"]), time.Second)
+		var err error
+		switch i % 4 {
+		case 0:
+			prepare := &pb.Payload{Instance: instance.Pb(), Data: []byte{byte(i)}}
+			prepare.Signature = sender.SignEnvelope(PayloadEnvelope(pb.MessageType_MT_PREPARE, prepare))
+			err = transport.Prepare(ctx, peers["f1"], prepare)
+		case 1:
+			echo := &pb.Payload{Instance: instance.Pb(), Data: []byte{byte(i)}}
+			echo.Signature = sender.SignEnvelope(PayloadEnvelope(pb.MessageType_MT_ECHO, echo))
+			err = transport.Echo(ctx, peers["f1"], echo)
+		case 2:
+			err = transport.Ready(ctx, peers["f1"], signedReady(&sender, instance, byte(i)))
+		case 3:
+			agreement := &pb.AgreementMessage{Instance: instance.Pb(), Type: pb.AgreementType_AT_BVAL, Round: int64(i)}
+			agreement.Signature = sender.SignEnvelope(AgreementEnvelope(agreement))
+			err = transport.Agreement(ctx, peers["f1"], agreement)
+		}
+		cancel()
+		assert.Nil(t, err)
+	}
+	assert.Eventually(t, func() bool { return len(recorder.Received()) == 20 }, time.Second, time.Millisecond)
+	for i, m := range recorder.Received() {
+		assert.Equal(t, "f2", m.Peer)
+		assert.Equal(t, []string{KindPrepare, KindEcho, KindReady, KindBval}[i%4], m.Kind)
+	}
+	ps := transport.peerStream(peers["f1"])
+	assert.Eventually(t, func() bool {
+		ps.mu.Lock()
+		defer ps.mu.Unlock()
+		return len(ps.pending) == 0
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, uint64(20), ps.sequence)
+	assert.False(t, ps.unsupported)
+}
+
+// skewedClock is real time moved forward by skew nanoseconds.
+type skewedClock struct {
+	skew int64
+}
+
+func (s *skewedClock) Now() time.Time {
+	return time.Now().Add(time.Duration(atomic.LoadInt64(&s.skew)))
+}
+
+func (s *skewedClock) AfterFunc(d time.Duration, f func()) { time.AfterFunc(d, f) }
+
+func TestGRPCTransport_StreamOutlivesHandshake(t *testing.T) {
+	rs, keys := signedPeers()
+	peers := rs.AllPeers
+	receiver := NewCommon("f1", rs, &testApp{}, keys["f1"])
+	clock := &skewedClock{}
+	receiver.Clock = clock
+	recorder := &recordingAdversary{}
+	receiver.SetAdversary(recorder)
+	stop := serveGRPC(t, &receiver, peers["f1"], func(s *grpc.Server) { RegisterNode(s, &receiver) })
+	defer stop()
+
+	sender := NewCommon("f2", rs, &testApp{}, keys["f2"])
+	transport := &GRPCTransport{}
+	defer transport.Close()
+	send := func() {
+		ctx, cancel := context.WithTimeout(sender.CreateContext(peers["f1"]), time.Second)
+		defer cancel()
+		assert.Nil(t, transport.Ready(ctx, peers["f1"], signedReady(&sender, Instance{Broadcaster: "f1"}, 0)))
+	}
+	send()
+	assert.Eventually(t, func() bool { return len(recorder.Received()) == 1 }, time.Second, time.Millisecond)
+
+	// Messages on the stream are still from the sender after its handshake is out of HandshakeWindow.
+	atomic.StoreInt64(&clock.skew, int64(2*HandshakeWindow))
+	send()
+	assert.Eventually(t, func() bool { return len(recorder.Received()) == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, "f2", recorder.Received()[1].Peer)
+	assert.Equal(t, uint64(2), transport.peerStream(peers["f1"]).sequence)
+}
+
+func TestGRPCTransport_UnaryFallback(t *testing.T) {
+	rs, keys := signedPeers()
+	peers := rs.AllPeers
+	receiver := NewCommon("f1", rs, &testApp{}, keys["f1"])
+	recorder := &recordingAdversary{}
+	receiver.SetAdversary(recorder)
+	// A peer that doesn't serve Consensus still gets messages by unary calls.
+	stop := serveGRPC(t, &receiver, peers["f1"], func(s *grpc.Server) { pb.RegisterReadyServer(s, &receiver) })
+	defer stop()
+
+	sender := NewCommon("f2", rs, &testApp{}, keys["f2"])
+	for _, transport := range []*GRPCTransport{{}, {Unary: true}} {
+		for i := 0; i < 2; i++ {
+			ctx, cancel := context.WithTimeout(sender.CreateContext(peers["f1"]), time.Second)
+			assert.Nil(t, transport.Ready(ctx, peers["f1"], &pb.ReadyRequest{PrevHash: []byte{byte(i)}}))
+			cancel()
+		}
+		assert.Equal(t, !transport.Unary, transport.peerStream(peers["f1"]).unsupported)
+		transport.Close()
+	}
+	assert.Equal(t, 4, len(recorder.Received()))
+}
+
+func TestGRPCTransport_StreamReconnects(t *testing.T) {
+	rs, keys := signedPeers()
+	peers := rs.AllPeers
+	sender := NewCommon("f2", rs, &testApp{}, keys["f2"])
+	transport := &GRPCTransport{}
+	defer transport.Close()
+	send := func() error {
+		ctx, cancel := context.WithTimeout(sender.CreateContext(peers["f1"]), time.Second)
+		defer cancel()
+		return transport.Ready(ctx, peers["f1"], signedReady(&sender, Instance{Broadcaster: "f1"}, 0))
+	}
+
+	first := NewCommon("f1", rs, &testApp{}, keys["f1"])
+	recorder := &recordingAdversary{}
+	first.SetAdversary(recorder)
+	stop := serveGRPC(t, &first, peers["f1"], func(s *grpc.Server) { RegisterNode(s, &first) })
+	assert.Nil(t, send())
+	assert.Eventually(t, func() bool { return len(recorder.Received()) == 1 }, time.Second, time.Millisecond)
+	// The stream ends when the peer stops, the next message opens a new one.
+	stop()
+	ps := transport.peerStream(peers["f1"])
+	assert.Eventually(t, func() bool {
+		ps.mu.Lock()
+		defer ps.mu.Unlock()
+		return ps.stream == nil
+	}, time.Second, time.Millisecond)
+
+	second := NewCommon("f1", rs, &testApp{}, keys["f1"])
+	second.SetAdversary(recorder)
+	port := peers["f1"].PORT
+	lis, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	assert.Nil(t, err)
+	s := grpc.NewServer()
+	RegisterNode(s, &second)
+	go s.Serve(lis)
+	defer s.Stop()
+	assert.Eventually(t, func() bool { return send() == nil }, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { return len(recorder.Received()) == 2 }, time.Second, time.Millisecond)
+}
+
+func TestCommon_ConsensusOutOfSequence(t *testing.T) {
+	rs, keys := signedPeers()
+	peers := rs.AllPeers
+	receiver := NewCommon("f1", rs, &testApp{}, keys["f1"])
+	recorder := &recordingAdversary{}
+	receiver.SetAdversary(recorder)
+	stop := serveGRPC(t, &receiver, peers["f1"], func(s *grpc.Server) { RegisterNode(s, &receiver) })
+	defer stop()
+
+	conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", peers["f1"].PORT), grpc.WithInsecure())
+	assert.Nil(t, err)
+	defer conn.Close()
+	sender := NewCommon("f2", rs, &testApp{}, keys["f2"])
+	ctx, cancel := context.WithTimeout(sender.CreateContext(peers["f1"]), time.Second)
+	defer cancel()
+	stream, err := pb.NewConsensusClient(conn).Consensus(ctx)
+	assert.Nil(t, err)
+	ready := &pb.ConsensusMessage_Ready{Ready: signedReady(&sender, Instance{Broadcaster: "f1"}, 0)}
+	assert.Nil(t, stream.Send(&pb.ConsensusMessage{Sequence: 1, Body: ready}))
+	ack, err := stream.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), ack.Ack)
+	// A message that skips a sequence ends the stream.
+	assert.Nil(t, stream.Send(&pb.ConsensusMessage{Sequence: 3, Body: ready}))
+	_, err = stream.Recv()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "out of sequence")
+	assert.Equal(t, 1, len(recorder.Received()))
+}
+
+func TestCommon_ConsensusUnsigned(t *testing.T) {
+	rs, keys := signedPeers()
+	peers := rs.AllPeers
+	receiver := NewCommon("f1", rs, &testApp{}, keys["f1"])
+	stop := serveGRPC(t, &receiver, peers["f1"], func(s *grpc.Server) { RegisterNode(s, &receiver) })
+	defer stop()
+
+	conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", peers["f1"].PORT), grpc.WithInsecure())
+	assert.Nil(t, err)
+	defer conn.Close()
+	sender := NewCommon("f2", rs, &testApp{}, keys["f2"])
+	ctx, cancel := context.WithTimeout(sender.CreateContext(peers["f1"]), time.Second)
+	defer cancel()
+	stream, err := pb.NewConsensusClient(conn).Consensus(ctx)
+	assert.Nil(t, err)
+	// A signed message is acknowledged even if it's rejected, like this READY of an unknown instance.
+	signed := signedReady(&sender, Instance{Broadcaster: "f9"}, 0)
+	assert.Nil(t, stream.Send(&pb.ConsensusMessage{Sequence: 1, Body: &pb.ConsensusMessage_Ready{Ready: signed}}))
+	ack, err := stream.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), ack.Ack)
+	// A message that isn't signed by the sender ends the stream without acknowledgement.
+	unsigned := &pb.ConsensusMessage_Ready{Ready: &pb.ReadyRequest{Instance: Instance{Broadcaster: "f1"}.Pb()}}
+	assert.Nil(t, stream.Send(&pb.ConsensusMessage{Sequence: 2, Body: unsigned}))
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,122 @@
+package common
+
+import (
+	"encoding/hex"
+	"sync"
+
+	"github.com/gopricy/mao-bft/pb"
+	"github.com/gopricy/mao-bft/rbc/merkle"
+	"github.com/pkg/errors"
+)
+
+// Delivery is fired once data of an instance is delivered to App.
+type Delivery struct {
+	// Index is the position of this delivery in this node's delivery order, starting from 1.
+	Index    uint64
+	Instance Instance
+	Root     merkle.RootString
+	Data     []byte
+}
+
+// deliverer makes sure each instance is decoded by one handler, and delivered at most once.
+type deliverer struct {
+	// decoding marks instances that a handler is decoding.
+	decoding map[Instance]bool
+	mu       sync.Mutex
+	// dispatchMu serializes deliveries, so App and callbacks observe deliveries in one order.
+	dispatchMu sync.Mutex
+	index      uint64
+	callbacks  []func(Delivery)
+}
+
+// OnDeliver registers a callback for deliveries. Callbacks are called after App.RBCReceive, one delivery at a time,
+// in the order of Delivery.Index.
+func (c *Common) OnDeliver(callback func(Delivery)) {
+	c.deliverer.dispatchMu.Lock()
+	defer c.deliverer.dispatchMu.Unlock()
+	c.deliverer.callbacks = append(c.deliverer.callbacks, callback)
+}
+
+// mode returns the broadcast mode of key, which ECHOs of key share since roots of both modes never collide.
+func (c *Common) mode(key InstanceKey) pb.BroadcastMode {
+	for _, m := range c.EchosReceived.Values(key) {
+		if payload, ok := m.(*pb.Payload); ok {
+			return payload.Mode
+		}
+	}
+	return pb.BroadcastMode_BM_AVID
+}
+
+// echoesToDecode returns the number of ECHOs that data of key is decoded from, it's N - 2f shards in AVID mode and
+// one copy of the value in replicated mode.
+func (c *Common) echoesToDecode(key InstanceKey) int {
+	if c.mode(key) == pb.BroadcastMode_BM_REPLICATED {
+		return 1
+	}
+	return c.DataShards()
+}
+
+// tryDeliver decodes and delivers key once it has 2f + 1 READY and ECHOs to decode it from. It's safe to call it
+// from concurrent handlers, only one of them decodes and the data is delivered at most once.
+func (c *Common) tryDeliver(key InstanceKey) error {
+	c.deliverer.mu.Lock()
+	if c.deliverer.decoding[key.Instance] || c.IsDelivered(key.Instance) ||
+		c.EchosReceived.Count(key) < c.echoesToDecode(key) ||
+		c.ReadiesReceived.Count(key) < c.ReadyQuorum() {
+		c.deliverer.mu.Unlock()
+		return nil
+	}
+	if c.deliverer.decoding == nil {
+		c.deliverer.decoding = make(map[Instance]bool)
+	}
+	c.deliverer.decoding[key.Instance] = true
+	c.deliverer.mu.Unlock()
+
+	c.Infof("Get enough READY and ECHO to decode %s", key)
+	data, err := c.reconstructData(key)
+
+	c.deliverer.mu.Lock()
+	delete(c.deliverer.decoding, key.Instance)
+	if err == ErrRootMismatch {
+		c.markAborted(key.Instance)
+		c.deliverer.mu.Unlock()
+		c.RecordMisbehaviour(key.Broadcaster, key.Instance, err.Error())
+		return err
+	}
+	if err != nil {
+		// Later messages will retry.
+		c.deliverer.mu.Unlock()
+		return err
+	}
+	c.markDelivered(key, data)
+	c.deliverer.mu.Unlock()
+
+	shouldSync, err := c.dispatch(key, data)
+	if err != nil {
+		return errors.Wrap(err, "failed to apply the transaction")
+	}
+	if shouldSync {
+		c.Synchronize()
+	}
+	return nil
+}
+
+// dispatch hands data to App and fires callbacks.
+func (c *Common) dispatch(key InstanceKey, data []byte) (bool, error) {
+	c.deliverer.dispatchMu.Lock()
+	defer c.deliverer.dispatchMu.Unlock()
+	c.Debugf("Data reconstructed %.6s", hex.EncodeToString(data))
+	var shouldSync bool
+	var err error
+	if app, ok := c.App.(CertifiedApplication); ok {
+		shouldSync, err = app.RBCReceiveCertified(data, c.certificate(key))
+	} else {
+		shouldSync, err = c.App.RBCReceive(data)
+	}
+	c.deliverer.index++
+	d := Delivery{Index: c.deliverer.index, Instance: key.Instance, Root: key.Root, Data: data}
+	for _, callback := range c.deliverer.callbacks {
+		callback(d)
+	}
+	return shouldSync, err
+}
@@ -3,111 +3,92 @@ package common
 import (
 	"context"
 	"encoding/hex"
-	"math"
-	"time"
 
 	"github.com/fatih/color"
+	"github.com/golang/protobuf/proto"
 	"github.com/gopricy/mao-bft/pb"
 	"github.com/gopricy/mao-bft/rbc/merkle"
-	"github.com/pkg/errors"
 )
 
 // Echo serves echo messages from other nodes
 func (c *Common) Echo(ctx context.Context, req *pb.Payload) (*pb.EchoResponse, error) {
+	err := c.receive(ctx, Message{Kind: KindEcho, Body: req}, func(ctx context.Context, m proto.Message) error {
+		return c.echo(ctx, m.(*pb.Payload))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.EchoResponse{}, nil
+}
+
+// echo handles an ECHO that the adversary of this node lets through.
+func (c *Common) echo(ctx context.Context, req *pb.Payload) error {
 	// Echo calls
 	c.SetColor(color.FgYellow)
 	defer c.UnsetColor()
 	c.Debugf(`------ECHO Server------`)
-	actualData, verified, name := c.Verify(ctx, req.Data)
+	instance, err := InstanceFromPb(req.Instance)
+	if err != nil {
+		return err
+	}
+	if c.IsDelivered(instance) {
+		c.Debugf(`Ignore ECHO of delivered %s`, instance)
+		return nil
+	}
+	name, verified := c.Verify(ctx, PayloadEnvelope(pb.MessageType_MT_ECHO, req), req.Signature)
 	if !verified {
-		return nil, errors.New("signature invalid")
+		return ErrInvalidSignature
 	}
-	if !c.PrevHashValid(req.PrevHash, req.MerkleProof.Root) {
-		return nil, errors.New("block with same prev_hash already voted")
+	if err := c.checkFresh(instance, false); err != nil {
+		return err
 	}
-	c.Debugf(`Get ECHO Message: "%.4s" from %s`, hex.EncodeToString(actualData), name)
-	valid := merkle.VerifyProof(req.MerkleProof, merkle.BytesContent(actualData))
-	if !valid {
-		return nil, merkle.InvalidProof{}
+	c.Debugf(`Get ECHO Message: "%.4s" of %s from %s`, hex.EncodeToString(req.Data), instance, name)
+	if err := c.checkMode(req); err != nil {
+		return err
+	}
+	// The value of a replicated ECHO is what's voted on, so there's nothing to check against.
+	if req.Mode == pb.BroadcastMode_BM_AVID {
+		valid := merkle.VerifyProof(req.MerkleProof, merkle.BytesContent(req.Data))
+		if !valid {
+			return merkle.InvalidProof{}
+		}
+		c.Debugf(`Validated by merkle tree`)
+		if err := c.checkShardIndex(name, req.MerkleProof); err != nil {
+			c.RecordMisbehaviour(name, instance, err.Error())
+			return err
+		}
 	}
-	c.Debugf(`Validated by merkle tree`)
 
-	req.Data = actualData
-	e, err := c.EchosReceived.Add(name, req.MerkleProof.Root, req)
+	root := PayloadRoot(req)
+	key := InstanceKey{Instance: instance, Root: merkle.MerkleRootToString(root)}
+	e, err := c.EchosReceived.Add(name, key, req)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if e == len(c.RBCSetting.AllPeers)-c.ByzantineLimit {
-		// TODO: interpolate {s'j} from any N-2f leaves received
-		// TODO: recompute Merkle root h' and if h'!=h then abort
-		// set := map[int]string{}
-		// for k, v := range c.EchosReceived.Rec[merkle.MerkleRootToString(req.MerkleProof.Root)] {
-		// 	idx := merkle.GetLeafIndex(v.(*pb.Payload).MerkleProof)
-		// 	if _, ok := set[idx]; ok {
-		// 		return nil, errors.New(fmt.Sprint("same shards from distinct parties: %s, %s", k, name))
-		// 	}
-		// }
-		if !c.readyIsSent(req.MerkleProof.Root) {
-			for _, p := range c.RBCSetting.AllPeers {
-				c.Debugf("Send READY to %#v", p)
-				c.SendReady(p, req.MerkleProof.Root)
+	if e == c.EchoQuorum() {
+		if !c.readyIsSent(key) {
+			for _, p := range c.Peers() {
+				c.Debugf("Send READY of %s to %#v", instance, p)
+				c.SendReady(p, instance, root)
 			}
 		}
 	}
-	rootString := merkle.MerkleRootToString(req.MerkleProof.Root)
 	// 2f + 1 Ready and N - 2f Echo, decode and apply
-	if e == len(c.RBCSetting.AllPeers)-2*c.ByzantineLimit {
-		if len(c.ReadiesReceived.Rec[rootString]) >= 2*c.ByzantineLimit+1 {
-			c.Infof("Get enough READY and ECHO to decode")
-			data, err := c.reconstructData(rootString)
-			if err != nil {
-				return nil, err
-			}
-			// TODO: add it back when block and app is ready
-			//block, err := maoUtils.FromBytesToBlock(data)
-			//if err != nil {
-			//	return nil, err
-			//}
-			c.Debugf("Data reconstructed %.6s", hex.EncodeToString(data))
-			shouldSync, err := c.App.RBCReceive(data)
-			if err != nil {
-				return nil, errors.Wrap(err, "Failed to apply the transaction")
-			}
-			if shouldSync {
-				c.Synchronize()
-			}
-		}
+	if err := c.tryDeliver(key); err != nil {
+		return err
 	}
 
-	return &pb.EchoResponse{}, nil
+	return nil
 }
 
 // Send Echo when a Prepare message is received
-func (c *Common) SendEcho(p *Peer, merkleProof *pb.MerkleProof, data []byte) {
+func (c *Common) SendEcho(p *Peer, instance Instance, merkleProof *pb.MerkleProof, data []byte) {
 	payload := &pb.Payload{
 		MerkleProof: merkleProof,
-		Data:        c.Sign(data),
+		Data:        data,
+		Instance:    instance.Pb(),
 	}
+	payload.Signature = c.SignEnvelope(PayloadEnvelope(pb.MessageType_MT_ECHO, payload))
 
-	go func() {
-		retry := 0
-		for {
-			_, err := pb.NewEchoClient(p.GetConn()).Echo(c.CreateContext(), payload)
-			if err == nil {
-				break
-			}
-			if c.Mode == 4 {
-				retry++
-				if retry > 4 {
-					retry = 4
-				}
-				c.Debugf("SendReady failed. Wait for %d to reconnect.", int(math.Pow(2, float64(retry))))
-				time.Sleep(time.Second * time.Duration(int(math.Pow(2, float64(retry)))))
-			}
-		}
-	}()
-	// _, err := pb.NewEchoClient(p.GetConn()).Echo(c.CreateContext(), payload)
-	// if err != nil {
-	// 	panic(err)
-	// }
+	c.SendMessage(p, KindEcho, payload)
 }
@@ -0,0 +1,100 @@
+package common
+
+import (
+	"context"
+
+	"github.com/gopricy/mao-bft/pb"
+	"github.com/gopricy/mao-bft/rbc/sign"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// PayloadEnvelope returns the envelope of a PREPARE or ECHO payload.
+func PayloadEnvelope(t pb.MessageType, req *pb.Payload) *pb.Envelope {
+	return &pb.Envelope{
+		Type:        t,
+		Instance:    req.Instance,
+		MerkleRoot:  PayloadRoot(req),
+		PrevHash:    req.PrevHash,
+		MerkleProof: req.MerkleProof,
+		Data:        req.Data,
+		Mode:        req.Mode,
+	}
+}
+
+// ReadyEnvelope returns the envelope of a READY.
+func ReadyEnvelope(req *pb.ReadyRequest) *pb.Envelope {
+	return &pb.Envelope{
+		Type:       pb.MessageType_MT_READY,
+		Instance:   req.Instance,
+		MerkleRoot: req.MerkleRoot,
+		PrevHash:   req.PrevHash,
+	}
+}
+
+// AgreementEnvelope returns the envelope of a binary agreement message.
+func AgreementEnvelope(req *pb.AgreementMessage) *pb.Envelope {
+	env := &pb.Envelope{Instance: req.Instance, Round: req.Round, Values: []bool{req.Value}}
+	switch req.Type {
+	case pb.AgreementType_AT_BVAL:
+		env.Type = pb.MessageType_MT_BVAL
+	case pb.AgreementType_AT_AUX:
+		env.Type = pb.MessageType_MT_AUX
+	case pb.AgreementType_AT_CONF:
+		env.Type, env.Values = pb.MessageType_MT_CONF, req.Values
+	case pb.AgreementType_AT_TERM:
+		env.Type = pb.MessageType_MT_TERM
+	case pb.AgreementType_AT_COIN:
+		env.Type, env.Values, env.Data = pb.MessageType_MT_COIN, nil, req.Share
+	}
+	return env
+}
+
+// encodeEnvelope returns the canonical encoding of env in cluster, which is what's signed.
+func encodeEnvelope(env *pb.Envelope, cluster string) ([]byte, error) {
+	bound := proto.Clone(env).(*pb.Envelope)
+	bound.ClusterId = cluster
+	return proto.MarshalOptions{Deterministic: true}.Marshal(bound)
+}
+
+// SignEnvelope returns signature of this node over env, the signature only holds in the cluster of this node.
+func (c *Common) SignEnvelope(env *pb.Envelope) []byte {
+	bytes, err := encodeEnvelope(env, c.ClusterID)
+	if err != nil {
+		// Only malformed messages, like an instance with invalid UTF-8 broadcaster, can't be encoded. They're sent
+		// without signature and rejected by peers.
+		c.Infof("Failed to encode envelope: %s", err.Error())
+		return nil
+	}
+	return sign.SignDetached(c.privateKey, bytes)
+}
+
+// ErrInvalidSignature means a message isn't signed by the peer that sent it.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// Verify checks that env is signed by the sender of ctx, it returns name of the sender.
+func (c *Common) Verify(ctx context.Context, env *pb.Envelope, signature []byte) (string, bool) {
+	name, err := c.getNameFromContext(ctx)
+	if err != nil {
+		return "", false
+	}
+	if err := c.RBCSetting.verifyEnvelope(name, env, signature); err != nil {
+		c.Debugf("%s from %s: %s", env.Type, name, err.Error())
+		return name, false
+	}
+	c.Debugf("signature verified, signed by %s", name)
+	return name, true
+}
+
+// verifyEnvelope checks that env is signed by peer name of this setting.
+func (rs *RBCSetting) verifyEnvelope(name string, env *pb.Envelope, signature []byte) error {
+	p, ok := rs.AllPeers[name]
+	if !ok {
+		return errors.Errorf("unknown peer %s", name)
+	}
+	bytes, err := encodeEnvelope(env, rs.ClusterID)
+	if err != nil || !sign.VerifyDetached(p.PubKey, bytes, signature) {
+		return errors.Errorf("invalid signature of %s", name)
+	}
+	return nil
+}
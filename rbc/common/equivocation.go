@@ -0,0 +1,171 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/golang/protobuf/proto"
+	"github.com/gopricy/mao-bft/pb"
+	"github.com/gopricy/mao-bft/rbc/sign"
+	"github.com/pkg/errors"
+)
+
+// equivocations holds evidence against leaders that equivocated, proposals of a convicted leader are refused.
+type equivocations struct {
+	// evidence maps each convicted leader to the first evidence against it.
+	evidence map[string]*pb.EquivocationEvidence
+	// order holds convicted leaders in the order they're convicted.
+	order []string
+	mu    sync.Mutex
+}
+
+// lockVote locks the parent of prepare in the current view before this node echoes it. If this node has echoed another
+// root on the same parent, the broadcaster of prepare is convicted of equivocation.
+func (c *Common) lockVote(instance Instance, prepare *pb.Payload) error {
+	if len(prepare.PrevHash) == 0 {
+		// Payloads that don't extend a chain have nothing to lock.
+		return nil
+	}
+	// Epoch is picked by the broadcaster, a lock of any other epoch would let it vote again on the same parent.
+	view := c.CurrentView()
+	if instance.Epoch != view {
+		return errors.Errorf("%s isn't in view %d", instance, view)
+	}
+	locked, err := c.VoteLocks.Lock(c.lockEpoch(view), prepare)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(PayloadRoot(locked), PayloadRoot(prepare)) {
+		return nil
+	}
+	evidence := &pb.EquivocationEvidence{First: locked, Second: prepare}
+	if leader, instance, err := c.verifyEvidence(evidence); err == nil {
+		c.convict(leader, instance, evidence)
+	} else {
+		// The lock may be taken in another cluster, it still can't be voted twice.
+		c.Infof("Can't build equivocation evidence: %s", err.Error())
+	}
+	return errors.Errorf("can't vote on two blocks with prevHash %.4s", hex.EncodeToString(prepare.PrevHash))
+}
+
+// lockEpoch returns the epoch that a lock of a PREPARE in epoch is taken in. It's the view in view change mode, where
+// a new leader may propose another block on the same parent. It's always 0 in fixed leader mode, where the only
+// leader proposes every block, so a parent is locked once for good.
+func (c *Common) lockEpoch(epoch int64) int64 {
+	if !c.ViewChangeEnabled() {
+		return 0
+	}
+	return epoch
+}
+
+// IsConvicted returns whether this node holds evidence that peer equivocated.
+func (c *Common) IsConvicted(peer string) bool {
+	c.equivocations.mu.Lock()
+	defer c.equivocations.mu.Unlock()
+	_, ok := c.equivocations.evidence[peer]
+	return ok
+}
+
+// verifyEvidence checks that evidence holds two PREPAREs signed by the same broadcaster, which extend the same parent
+// with different roots, and would take the same vote lock. In fixed leader mode that's regardless of their epochs,
+// so a leader can't escape by picking another epoch. It returns the broadcaster and the instance of the second PREPARE.
+func (c *Common) verifyEvidence(evidence *pb.EquivocationEvidence) (string, Instance, error) {
+	first, second := evidence.First, evidence.Second
+	if first == nil || second == nil || len(PayloadRoot(first)) == 0 || len(PayloadRoot(second)) == 0 {
+		return "", Instance{}, errors.New("incomplete evidence")
+	}
+	firstInstance, err := InstanceFromPb(first.Instance)
+	if err != nil {
+		return "", Instance{}, err
+	}
+	secondInstance, err := InstanceFromPb(second.Instance)
+	if err != nil {
+		return "", Instance{}, err
+	}
+	leader := firstInstance.Broadcaster
+	if secondInstance.Broadcaster != leader {
+		return "", Instance{}, errors.New("evidence of different broadcasters")
+	}
+	if c.lockEpoch(firstInstance.Epoch) != c.lockEpoch(secondInstance.Epoch) {
+		return "", Instance{}, errors.New("evidence of different views")
+	}
+	if len(first.PrevHash) == 0 || !bytes.Equal(first.PrevHash, second.PrevHash) {
+		return "", Instance{}, errors.New("evidence of different parents")
+	}
+	if bytes.Equal(PayloadRoot(first), PayloadRoot(second)) {
+		return "", Instance{}, errors.New("evidence of the same root")
+	}
+	p, ok := c.AllPeers[leader]
+	if !ok {
+		return "", Instance{}, errors.Errorf("evidence against unknown peer %s", leader)
+	}
+	for _, prepare := range []*pb.Payload{first, second} {
+		bytes, err := encodeEnvelope(PayloadEnvelope(pb.MessageType_MT_PREPARE, prepare), c.ClusterID)
+		if err != nil || !sign.VerifyDetached(p.PubKey, bytes, prepare.Signature) {
+			return "", Instance{}, errors.Errorf("evidence not signed by %s", leader)
+		}
+	}
+	return leader, secondInstance, nil
+}
+
+// convict stores verified evidence against leader and gossips it to all peers, unless leader is already convicted.
+func (c *Common) convict(leader string, instance Instance, evidence *pb.EquivocationEvidence) {
+	c.equivocations.mu.Lock()
+	if _, ok := c.equivocations.evidence[leader]; ok {
+		c.equivocations.mu.Unlock()
+		return
+	}
+	if c.equivocations.evidence == nil {
+		c.equivocations.evidence = make(map[string]*pb.EquivocationEvidence)
+	}
+	c.equivocations.evidence[leader] = evidence
+	c.equivocations.order = append(c.equivocations.order, leader)
+	c.equivocations.mu.Unlock()
+
+	c.RecordMisbehaviour(leader, instance, "equivocation")
+	for _, p := range c.Peers() {
+		if p.Name == c.Name() {
+			continue
+		}
+		c.SendMessage(p, KindEquivocation, evidence)
+	}
+}
+
+// ReportEquivocation serves evidence gossiped by other nodes. Evidence is verified by signatures of the leader, so
+// it's accepted from anyone.
+func (c *Common) ReportEquivocation(ctx context.Context, req *pb.EquivocationEvidence) (*pb.ReportEquivocationResponse, error) {
+	err := c.receive(ctx, Message{Kind: KindEquivocation, Body: req}, func(ctx context.Context, m proto.Message) error {
+		return c.reportEquivocation(ctx, m.(*pb.EquivocationEvidence))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ReportEquivocationResponse{}, nil
+}
+
+// reportEquivocation handles evidence that the adversary of this node lets through.
+func (c *Common) reportEquivocation(ctx context.Context, req *pb.EquivocationEvidence) error {
+	c.SetColor(color.FgRed)
+	defer c.UnsetColor()
+	c.Debugf(`------EQUIVOCATION Server------`)
+	leader, instance, err := c.verifyEvidence(req)
+	if err != nil {
+		return errors.Wrap(err, "invalid equivocation evidence")
+	}
+	c.convict(leader, instance, req)
+	return nil
+}
+
+// GetEquivocationEvidence returns evidence against each convicted leader, in the order they're convicted.
+func (c *Common) GetEquivocationEvidence(ctx context.Context, req *pb.GetEquivocationEvidenceRequest) (*pb.GetEquivocationEvidenceResponse, error) {
+	c.equivocations.mu.Lock()
+	defer c.equivocations.mu.Unlock()
+	res := &pb.GetEquivocationEvidenceResponse{}
+	for _, leader := range c.equivocations.order {
+		res.Evidence = append(res.Evidence, c.equivocations.evidence[leader])
+	}
+	return res, nil
+}
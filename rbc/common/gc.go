@@ -0,0 +1,143 @@
+package common
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultRetentionWindow is used when RBCSetting.RetentionWindow is not set.
+const DefaultRetentionWindow = 10 * time.Second
+
+// forgetAfter is how many retention windows a delivered instance is remembered. Vote state of a delivered instance
+// that App never commits is also dropped after it.
+const forgetAfter = 10
+
+// delivery records an instance whose data has been delivered to App.
+type delivery struct {
+	key  InstanceKey
+	data []byte
+	at   time.Time
+}
+
+// Retention tracks delivered instances. Vote state of an instance is dropped once App commits its data and the
+// retention window has passed, messages of delivered instances are ignored.
+type Retention struct {
+	// kept holds deliveries whose vote state is not dropped yet.
+	kept []*delivery
+	// delivered maps delivered instances to the time they were delivered.
+	delivered map[Instance]time.Time
+	// order holds delivered instances in delivery order, so they are forgotten in order.
+	order []Instance
+	// scheduled is whether a timer to collect garbage is pending.
+	scheduled bool
+	mu        sync.Mutex
+}
+
+func (c *Common) retentionWindow() time.Duration {
+	if c.RetentionWindow > 0 {
+		return c.RetentionWindow
+	}
+	return DefaultRetentionWindow
+}
+
+// IsDelivered returns whether an instance has been delivered, its messages can be ignored.
+func (c *Common) IsDelivered(instance Instance) bool {
+	c.Retention.mu.Lock()
+	defer c.Retention.mu.Unlock()
+	_, ok := c.Retention.delivered[instance]
+	return ok
+}
+
+// markDelivered records that data of key is delivered to App, and collects garbage. Garbage is also collected on a
+// timer, so that vote state is dropped even if nothing is delivered later.
+func (c *Common) markDelivered(key InstanceKey, data []byte) {
+	instance := key.Instance
+	c.Retention.mu.Lock()
+	defer c.Retention.mu.Unlock()
+	now := c.Clock.Now()
+	if c.Retention.delivered == nil {
+		c.Retention.delivered = make(map[Instance]time.Time)
+	}
+	c.Retention.delivered[instance] = now
+	c.Retention.order = append(c.Retention.order, instance)
+	c.Retention.kept = append(c.Retention.kept, &delivery{key: key, data: data, at: now})
+	c.collectGarbage(now)
+	c.scheduleGarbage()
+}
+
+// markAborted records that instance is finished without delivery, its vote state is dropped right away.
+func (c *Common) markAborted(instance Instance) {
+	c.Retention.mu.Lock()
+	defer c.Retention.mu.Unlock()
+	if c.Retention.delivered == nil {
+		c.Retention.delivered = make(map[Instance]time.Time)
+	}
+	c.Retention.delivered[instance] = c.Clock.Now()
+	c.Retention.order = append(c.Retention.order, instance)
+	c.dropVoteState(instance)
+	c.scheduleGarbage()
+}
+
+// dropVoteState drops ECHOs and READYs received in instance, and the READY this node sent.
+func (c *Common) dropVoteState(instance Instance) {
+	c.EchosReceived.Remove(instance)
+	c.ReadiesReceived.Remove(instance)
+	c.ReadiesSent.Delete(instance)
+}
+
+// scheduleGarbage collects garbage after a retention window on Clock, and again after each window until there's
+// nothing left to drop or forget, or this node is stopped. Retention.mu must be held.
+func (c *Common) scheduleGarbage() {
+	if c.Retention.scheduled || (len(c.Retention.kept) == 0 && len(c.Retention.order) == 0) {
+		return
+	}
+	c.Retention.scheduled = true
+	c.Clock.AfterFunc(c.retentionWindow(), func() {
+		c.Retention.mu.Lock()
+		defer c.Retention.mu.Unlock()
+		c.Retention.scheduled = false
+		select {
+		case <-c.stopped():
+			return
+		default:
+		}
+		c.collectGarbage(c.Clock.Now())
+		c.scheduleGarbage()
+	})
+}
+
+// collectGarbage drops vote state of deliveries that are committed and older than retention window, and forgets
+// deliveries older than forgetAfter windows. Vote lock on the parent of a committed delivery is released too.
+// Retention.mu must be held.
+func (c *Common) collectGarbage(now time.Time) {
+	window := c.retentionWindow()
+	kept := c.Retention.kept[:0]
+	for _, d := range c.Retention.kept {
+		age := now.Sub(d.at)
+		committed := age >= window && c.App.IsCommitted(d.data)
+		if age < window || (age < forgetAfter*window && !committed) {
+			kept = append(kept, d)
+			continue
+		}
+		c.dropVoteState(d.key.Instance)
+		if committed {
+			c.VoteLocks.Release(d.key)
+		}
+		c.Debugf("Drop vote state of %s", d.key.Instance)
+	}
+	for i := len(kept); i < len(c.Retention.kept); i++ {
+		c.Retention.kept[i] = nil
+	}
+	c.Retention.kept = kept
+
+	for len(c.Retention.order) > 0 {
+		instance := c.Retention.order[0]
+		if now.Sub(c.Retention.delivered[instance]) < forgetAfter*window {
+			break
+		}
+		delete(c.Retention.delivered, instance)
+		c.Retention.order = c.Retention.order[1:]
+		// Messages of instance can't be recognized as delivered anymore, they must be rejected as stale.
+		c.replay.forget(instance)
+	}
+}
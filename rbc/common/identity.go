@@ -0,0 +1,102 @@
+package common
+
+import (
+	"context"
+	"encoding/binary"
+	"strconv"
+	"time"
+
+	"github.com/gopricy/mao-bft/rbc/sign"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+type identityKey struct{}
+
+// Metadata keys of the handshake that proves identity of a peer when the cluster doesn't use TLS.
+const (
+	handshakeName = "name"
+	handshakeTime = "handshake-time"
+	// handshakeSignature is binary, gRPC requires such keys to end in -bin.
+	handshakeSignature = "handshake-signature-bin"
+)
+
+// HandshakeWindow is how far the time of a handshake can be from the clock of its receiver.
+const HandshakeWindow = time.Minute
+
+// handshake returns what from signs to prove its identity to to at time t, it only holds in cluster.
+func handshake(cluster, from, to string, t int64) []byte {
+	var b []byte
+	buf := make([]byte, binary.MaxVarintLen64)
+	for _, s := range []string{"mao-bft handshake", cluster, from, to} {
+		b = append(b, buf[:binary.PutUvarint(buf, uint64(len(s)))]...)
+		b = append(b, s...)
+	}
+	binary.BigEndian.PutUint64(buf, uint64(t))
+	return append(b, buf[:8]...)
+}
+
+// AuthenticatedContext returns ctx of a request sent by peer name. It's for transports that authenticate senders on
+// their own, like MemoryNetwork.
+func AuthenticatedContext(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, identityKey{}, name)
+}
+
+// authenticatedName identifies the sender of ctx:
+//   - By transport, if ctx is created by AuthenticatedContext.
+//   - By certificate of the connection, if this node uses TLS.
+//   - By handshake otherwise, see createContext.
+//
+// Name claimed by sender without proof is never trusted.
+func (c *Common) authenticatedName(ctx context.Context) (string, error) {
+	if name, ok := ctx.Value(identityKey{}).(string); ok {
+		return name, nil
+	}
+	if c.serverTLS != nil {
+		p, ok := peer.FromContext(ctx)
+		if !ok {
+			return "", errors.New("request doesn't come from a connection")
+		}
+		info, ok := p.AuthInfo.(credentials.TLSInfo)
+		if !ok || len(info.State.PeerCertificates) == 0 {
+			return "", errors.New("sender didn't present certificate")
+		}
+		name, ok := c.certOwners[string(info.State.PeerCertificates[0].Raw)]
+		if !ok {
+			return "", errors.New("certificate doesn't belong to any peer")
+		}
+		return name, nil
+	}
+	return c.verifyHandshake(ctx)
+}
+
+// verifyHandshake returns name of the peer whose handshake is in metadata of ctx. The handshake must be signed by
+// the peer for this node, in this cluster, within HandshakeWindow.
+func (c *Common) verifyHandshake(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", errors.New("failed to decode context")
+	}
+	names, times, signatures := md.Get(handshakeName), md.Get(handshakeTime), md.Get(handshakeSignature)
+	if len(names) != 1 || len(times) != 1 || len(signatures) != 1 {
+		return "", errors.New("context doesn't have handshake")
+	}
+	name := names[0]
+	p, ok := c.AllPeers[name]
+	if !ok || p.PubKey == nil {
+		return "", errors.Errorf("%s is not a peer", name)
+	}
+	t, err := strconv.ParseInt(times[0], 10, 64)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid handshake time")
+	}
+	if d := c.Clock.Now().Sub(time.Unix(0, t)); d > HandshakeWindow || d < -HandshakeWindow {
+		return "", errors.Errorf("handshake of %s is %s off", name, d)
+	}
+	if !sign.VerifyDetached(p.PubKey, handshake(c.ClusterID, name, c.Name(), t), []byte(signatures[0])) {
+		return "", errors.Errorf("handshake of %s isn't signed by it", name)
+	}
+	return name, nil
+}
@@ -0,0 +1,42 @@
+package common
+
+import (
+	"fmt"
+
+	"github.com/gopricy/mao-bft/pb"
+	"github.com/gopricy/mao-bft/rbc/merkle"
+	"github.com/pkg/errors"
+)
+
+// Instance identifies a RBC instance, it's the comparable form of pb.InstanceId.
+type Instance struct {
+	Broadcaster string
+	Epoch       int64
+	Sequence    int64
+}
+
+// InstanceFromPb converts a pb.InstanceId, it fails if id is missing.
+func InstanceFromPb(id *pb.InstanceId) (Instance, error) {
+	if id == nil || id.Broadcaster == "" {
+		return Instance{}, errors.New("message doesn't carry RBC instance")
+	}
+	return Instance{Broadcaster: id.Broadcaster, Epoch: id.Epoch, Sequence: id.Sequence}, nil
+}
+
+func (i Instance) Pb() *pb.InstanceId {
+	return &pb.InstanceId{Broadcaster: i.Broadcaster, Epoch: i.Epoch, Sequence: i.Sequence}
+}
+
+func (i Instance) String() string {
+	return fmt.Sprintf("%s/%d/%d", i.Broadcaster, i.Epoch, i.Sequence)
+}
+
+// InstanceKey scopes RBC state to one value broadcast in one instance.
+type InstanceKey struct {
+	Instance
+	Root merkle.RootString
+}
+
+func (k InstanceKey) String() string {
+	return fmt.Sprintf("%s:%.4s", k.Instance, k.Root)
+}
@@ -0,0 +1,54 @@
+package common
+
+import (
+	"time"
+)
+
+// MaxMisbehaviours is how many misbehaviours a node keeps, older ones are dropped.
+const MaxMisbehaviours = 1024
+
+// Misbehaviour records a protocol violation committed by a peer.
+type Misbehaviour struct {
+	Peer     string
+	Instance Instance
+	Reason   string
+	At       time.Time
+}
+
+// misbehaviourKey identifies misbehaviours of a peer in an instance, only the first one of them is kept.
+type misbehaviourKey struct {
+	peer     string
+	instance Instance
+}
+
+// RecordMisbehaviour records that peer violated the protocol in instance. In view change mode, misbehaviour of
+// current leader makes this node suspect it. One misbehaviour is kept per peer and instance, and at most
+// MaxMisbehaviours in all, so that a faulty peer can't make this node hold unbounded evidence.
+func (c *Common) RecordMisbehaviour(peer string, instance Instance, reason string) {
+	c.Infof("Misbehaviour of %s in %s: %s", peer, instance, reason)
+	c.misbehavioursMu.Lock()
+	key := misbehaviourKey{peer: peer, instance: instance}
+	if !c.misbehaved[key] {
+		if c.misbehaved == nil {
+			c.misbehaved = make(map[misbehaviourKey]bool)
+		}
+		c.misbehaved[key] = true
+		c.misbehaviours = append(c.misbehaviours, Misbehaviour{Peer: peer, Instance: instance, Reason: reason, At: c.Clock.Now()})
+		if len(c.misbehaviours) > MaxMisbehaviours {
+			oldest := c.misbehaviours[0]
+			delete(c.misbehaved, misbehaviourKey{peer: oldest.Peer, instance: oldest.Instance})
+			c.misbehaviours = c.misbehaviours[1:]
+		}
+	}
+	c.misbehavioursMu.Unlock()
+	if c.ViewChangeEnabled() && peer == c.Leader() {
+		c.SuspectLeader()
+	}
+}
+
+// Misbehaviours returns all misbehaviours recorded by this node.
+func (c *Common) Misbehaviours() []Misbehaviour {
+	c.misbehavioursMu.Lock()
+	defer c.misbehavioursMu.Unlock()
+	return append([]Misbehaviour{}, c.misbehaviours...)
+}
@@ -0,0 +1,209 @@
+package common
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultOutboxSize is the number of messages that can wait for a peer, later messages are dropped.
+	DefaultOutboxSize = 1024
+	// DefaultMaxRetries is how many times a failed message is resent before it's dropped.
+	DefaultMaxRetries = 8
+	// retryBackoff is the backoff after the first failure, it doubles after each failure up to maxRetryBackoff.
+	retryBackoff    = 100 * time.Millisecond
+	maxRetryBackoff = 10 * time.Second
+	// sendTimeout bounds each attempt to send a message.
+	sendTimeout = 10 * time.Second
+)
+
+// OutboxStats describes the outbound queue of a peer.
+type OutboxStats struct {
+	// Depth is the number of messages waiting to be sent.
+	Depth int
+	Sent  uint64
+	// DroppedFull is the number of messages dropped because the queue was full.
+	DroppedFull uint64
+	// DroppedRetries is the number of messages dropped because all retries failed.
+	DroppedRetries uint64
+	// DroppedRejected is the number of messages dropped because the peer rejected them, they're never retried.
+	DroppedRejected uint64
+}
+
+// outboundMessage is a message waiting in an outbox, send makes one attempt to deliver it.
+type outboundMessage struct {
+	kind string
+	send func(ctx context.Context) error
+}
+
+// outbox queues messages to one peer, they're sent in order by one worker.
+type outbox struct {
+	peer            *Peer
+	queue           chan outboundMessage
+	sent            uint64
+	droppedFull     uint64
+	droppedRetries  uint64
+	droppedRejected uint64
+}
+
+// outboxes holds outboxes of all peers, their workers stop when ctx is cancelled.
+type outboxes struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	boxes  map[string]*outbox
+	mu     sync.Mutex
+}
+
+func (c *Common) outboxSize() int {
+	if c.OutboxSize > 0 {
+		return c.OutboxSize
+	}
+	return DefaultOutboxSize
+}
+
+func (c *Common) maxRetries() int {
+	if c.MaxRetries > 0 {
+		return c.MaxRetries
+	}
+	return DefaultMaxRetries
+}
+
+// init must be called with o.mu held.
+func (o *outboxes) init() {
+	if o.ctx == nil {
+		o.ctx, o.cancel = context.WithCancel(context.Background())
+		o.boxes = make(map[string]*outbox)
+	}
+}
+
+// Enqueue queues a message to p, send is called by the worker of p until it succeeds or retries are exhausted.
+// ctx passed to send carries identity of this node. The message is dropped if the queue of p is full or this node
+// is stopped. A QueuedTransport is sent to right away.
+func (c *Common) Enqueue(p *Peer, kind string, send func(ctx context.Context) error) {
+	if _, ok := c.Transport.(QueuedTransport); ok {
+		if err := send(c.createContext(context.Background(), p)); err != nil {
+			c.Infof("Failed to send %s to %s: %s", kind, p.Name, err.Error())
+		}
+		return
+	}
+	c.outboxes.mu.Lock()
+	c.outboxes.init()
+	if c.outboxes.ctx.Err() != nil {
+		c.outboxes.mu.Unlock()
+		return
+	}
+	box, ok := c.outboxes.boxes[p.Name]
+	if !ok {
+		box = &outbox{peer: p, queue: make(chan outboundMessage, c.outboxSize())}
+		c.outboxes.boxes[p.Name] = box
+		go c.runOutbox(c.outboxes.ctx, box)
+	}
+	c.outboxes.mu.Unlock()
+
+	select {
+	case box.queue <- outboundMessage{kind: kind, send: send}:
+	default:
+		atomic.AddUint64(&box.droppedFull, 1)
+		c.Infof("Outbox of %s is full, drop %s", p.Name, kind)
+	}
+}
+
+// Stop stops workers of all outboxes, queued messages are discarded. Connections to peers are closed, and Consensus
+// streams served by this node end.
+func (c *Common) Stop() {
+	c.outboxes.mu.Lock()
+	defer c.outboxes.mu.Unlock()
+	c.outboxes.init()
+	c.outboxes.cancel()
+	if closer, ok := c.Transport.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// OutboxStats returns stats of the outbox of each peer that this node has sent to.
+func (c *Common) OutboxStats() map[string]OutboxStats {
+	c.outboxes.mu.Lock()
+	defer c.outboxes.mu.Unlock()
+	res := make(map[string]OutboxStats)
+	for name, box := range c.outboxes.boxes {
+		res[name] = OutboxStats{
+			Depth:           len(box.queue),
+			Sent:            atomic.LoadUint64(&box.sent),
+			DroppedFull:     atomic.LoadUint64(&box.droppedFull),
+			DroppedRetries:  atomic.LoadUint64(&box.droppedRetries),
+			DroppedRejected: atomic.LoadUint64(&box.droppedRejected),
+		}
+	}
+	return res
+}
+
+func (c *Common) runOutbox(ctx context.Context, box *outbox) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m := <-box.queue:
+			c.sendWithRetry(ctx, box, m)
+		}
+	}
+}
+
+func (c *Common) sendWithRetry(ctx context.Context, box *outbox, m outboundMessage) {
+	for retry := 0; ; retry++ {
+		attemptCtx, cancel := context.WithTimeout(c.createContext(ctx, box.peer), sendTimeout)
+		err := m.send(attemptCtx)
+		cancel()
+		if err == nil {
+			atomic.AddUint64(&box.sent, 1)
+			return
+		}
+		if !retryable(err) {
+			atomic.AddUint64(&box.droppedRejected, 1)
+			c.Infof("Drop %s to %s, it's rejected: %s", m.kind, box.peer.Name, err.Error())
+			return
+		}
+		if retry >= c.maxRetries() {
+			atomic.AddUint64(&box.droppedRetries, 1)
+			c.Infof("Drop %s to %s after %d retries: %s", m.kind, box.peer.Name, retry, err.Error())
+			return
+		}
+		wait := backoff(retry)
+		c.Debugf("Send %s to %s failed, retry in %s: %s", m.kind, box.peer.Name, wait, err.Error())
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// retryable returns whether err is a failure to reach the peer, which may succeed if it's sent again. Answers of the
+// peer, like an invalid signature or a stale instance, don't change however many times a message is sent.
+func retryable(err error) bool {
+	cause := errors.Cause(err)
+	switch cause {
+	case ErrUnreachable, errStreamBroken, io.EOF, context.DeadlineExceeded:
+		return true
+	}
+	if s, ok := status.FromError(cause); ok {
+		return s.Code() == codes.Unavailable || s.Code() == codes.DeadlineExceeded
+	}
+	return false
+}
+
+// backoff returns a random duration in [d/2, d), d doubles with each retry up to maxRetryBackoff.
+func backoff(retry int) time.Duration {
+	d := maxRetryBackoff
+	if retry < 16 && retryBackoff<<uint(retry) < maxRetryBackoff {
+		d = retryBackoff << uint(retry)
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)))
+}
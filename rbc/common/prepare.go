@@ -5,32 +5,77 @@ import (
 	"encoding/hex"
 
 	"github.com/fatih/color"
+	"github.com/golang/protobuf/proto"
 	"github.com/gopricy/mao-bft/pb"
 	"github.com/pkg/errors"
 )
 
 // Prepare serves Prepare messages sent from Leader
 func (c *Common) Prepare(ctx context.Context, req *pb.Payload) (*pb.PrepareResponse, error) {
+	err := c.receive(ctx, Message{Kind: KindPrepare, Body: req}, func(ctx context.Context, m proto.Message) error {
+		return c.prepare(ctx, m.(*pb.Payload))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.PrepareResponse{}, nil
+}
+
+// prepare handles a PREPARE that the adversary of this node lets through.
+func (c *Common) prepare(ctx context.Context, req *pb.Payload) error {
 	c.SetColor(color.FgBlue)
 	defer c.UnsetColor()
 	c.Debugf(`------PREPARE Server------`)
 
-	actualData, verified, name := c.Verify(ctx, req.Data)
+	instance, err := InstanceFromPb(req.Instance)
+	if err != nil {
+		return err
+	}
+	if c.IsDelivered(instance) {
+		c.Debugf(`Ignore PREPARE of delivered %s`, instance)
+		return nil
+	}
+	name, verified := c.Verify(ctx, PayloadEnvelope(pb.MessageType_MT_PREPARE, req), req.Signature)
 	if !verified {
-		return nil, errors.New("invalid signature")
-	}
-	if !c.PrevHashValid(req.PrevHash, req.MerkleProof.Root) {
-		return nil, errors.New("can't vote on two blocks with same prevHash")
-	}
-	c.Debugf(`Get PREPARE: "%.4s" from %s`, hex.EncodeToString(actualData), name)
-	for _, p := range c.AllPeers {
-		c.Debugf(`Send ECHO "%.4s" to %#v`, hex.EncodeToString(actualData), p)
-		c.SendEcho(p, req.MerkleProof, actualData)
-		if c.Mode == 3 {
-			c.Infof("Byzantine Mode 3(send ready when not): send ready to %s", p.Name)
-			c.SendReady(p, req.MerkleProof.Root)
+		return ErrInvalidSignature
+	}
+	if c.ViewChangeEnabled() && name != c.Leader() {
+		return errors.New(name + " is not leader of current view")
+	}
+	if c.IsConvicted(name) {
+		return errors.New(name + " is convicted of equivocation")
+	}
+	// RBC state is tied to the broadcaster, nobody can prepare on behalf of another node.
+	if instance.Broadcaster != name {
+		return errors.New(name + " can't prepare instance of " + instance.Broadcaster)
+	}
+	if err := c.checkFresh(instance, true); err != nil {
+		return err
+	}
+	if err := c.checkMode(req); err != nil {
+		return err
+	}
+	// Broadcaster must send this node its own shard, otherwise the shard can't be echoed.
+	if req.Mode == pb.BroadcastMode_BM_AVID {
+		if err := c.checkShardIndex(c.Name(), req.MerkleProof); err != nil {
+			c.RecordMisbehaviour(name, instance, err.Error())
+			return err
+		}
+	}
+	// The lock is persisted before ECHO is sent, so this node never echoes two blocks on the same parent, even
+	// across restarts.
+	if err := c.lockVote(instance, req); err != nil {
+		return err
+	}
+	c.Debugf(`Get PREPARE: "%.4s" of %s from %s`, hex.EncodeToString(req.Data), instance, name)
+	for _, p := range c.Peers() {
+		c.Debugf(`Send ECHO "%.4s" to %#v`, hex.EncodeToString(req.Data), p)
+		if req.Mode == pb.BroadcastMode_BM_REPLICATED {
+			c.SendReplicatedEcho(p, instance, req.Data)
+		} else {
+			c.SendEcho(p, instance, req.MerkleProof, req.Data)
 		}
 	}
 
-	return &pb.PrepareResponse{}, nil
+	return nil
 }
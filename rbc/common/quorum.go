@@ -0,0 +1,43 @@
+package common
+
+import (
+	"github.com/gopricy/mao-bft/rbc/erasure"
+	"github.com/pkg/errors"
+)
+
+// Quorums of RBC among N peers, f of which are byzantine. They hold for any N >= 3f + 1, N and f are set
+// independently by AllPeers and ByzantineLimit.
+
+// Validate checks that AllPeers can tolerate ByzantineLimit byzantine peers, and that data can be erasure coded into
+// a shard per peer with DataShards data shards.
+func (rs *RBCSetting) Validate() error {
+	if _, err := erasure.DataShards(rs.ByzantineLimit, len(rs.AllPeers)); err != nil {
+		return errors.Wrap(err, "invalid cluster size")
+	}
+	return nil
+}
+
+// EchoQuorum returns the number of ECHOs of a value after which a node sends READY, it's N - f. Two such quorums
+// share N - 2f >= f + 1 peers, so correct peers never send READY of different values in an instance, and at least
+// N - 2f of a quorum are correct peers whose shards decode the value.
+func (rs *RBCSetting) EchoQuorum() int {
+	return len(rs.AllPeers) - rs.ByzantineLimit
+}
+
+// ReadyAmplification returns the number of READYs of a value after which a node sends READY too, it's f + 1, so that
+// at least one of them is from a correct peer.
+func (rs *RBCSetting) ReadyAmplification() int {
+	return rs.ByzantineLimit + 1
+}
+
+// ReadyQuorum returns the number of READYs of a value that deliver it, it's 2f + 1. At least f + 1 of them are from
+// correct peers, so every correct node gets enough READYs to amplify and eventually delivers too.
+func (rs *RBCSetting) ReadyQuorum() int {
+	return 2*rs.ByzantineLimit + 1
+}
+
+// DataShards returns the number of shards that data is decoded from in AVID mode, it's N - 2f, which is what correct
+// peers of an echo quorum are sure to hold.
+func (rs *RBCSetting) DataShards() int {
+	return len(rs.AllPeers) - 2*rs.ByzantineLimit
+}
@@ -2,100 +2,80 @@ package common
 
 import (
 	"context"
-	"encoding/hex"
-	"math"
-	"time"
 
 	"github.com/fatih/color"
+	"github.com/golang/protobuf/proto"
 	"github.com/gopricy/mao-bft/pb"
 	"github.com/gopricy/mao-bft/rbc/merkle"
 	"github.com/pkg/errors"
 )
 
-func (c *Common) SendReady(p *Peer, root []byte) {
+func (c *Common) SendReady(p *Peer, instance Instance, root []byte) {
 	readyReq := &pb.ReadyRequest{
-		MerkleRoot: c.Sign(root),
+		MerkleRoot: root,
+		Instance:   instance.Pb(),
 	}
-	go func() {
-		retry := 0
-		for {
-			_, err := pb.NewReadyClient(p.GetConn()).Ready(c.CreateContext(), readyReq)
-			if err == nil {
-				break
-			}
-			if c.Mode == 4 {
-				retry++
-				if retry > 4 {
-					retry = 4
-				}
-				c.Debugf("SendReady failed. Wait for %d to reconnect.", int(math.Pow(2, float64(retry))))
-				time.Sleep(time.Second * time.Duration(int(math.Pow(2, float64(retry)))))
-			}
-		}
-	}()
-	// _, err := pb.NewReadyClient(p.GetConn()).Ready(c.CreateContext(), readyReq)
-	// if err != nil {
-	// 	panic(err)
-	// }
+	readyReq.Signature = c.SignEnvelope(ReadyEnvelope(readyReq))
+	c.SendMessage(p, KindReady, readyReq)
 }
 
 // Ready serves ready messages from other nodes
 func (c *Common) Ready(ctx context.Context, req *pb.ReadyRequest) (*pb.ReadyResponse, error) {
+	err := c.receive(ctx, Message{Kind: KindReady, Body: req}, func(ctx context.Context, m proto.Message) error {
+		return c.ready(ctx, m.(*pb.ReadyRequest))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ReadyResponse{}, nil
+}
+
+// ready handles a READY that the adversary of this node lets through.
+func (c *Common) ready(ctx context.Context, req *pb.ReadyRequest) error {
 	c.SetColor(color.FgGreen)
 	defer c.UnsetColor()
 	c.Debugf(`------Ready Server------`)
-	root, verified, name := c.Verify(ctx, req.MerkleRoot)
+	instance, err := InstanceFromPb(req.Instance)
+	if err != nil {
+		return err
+	}
+	if c.IsDelivered(instance) {
+		c.Debugf(`Ignore READY of delivered %s`, instance)
+		return nil
+	}
+	name, verified := c.Verify(ctx, ReadyEnvelope(req), req.Signature)
 	if !verified {
-		return nil, errors.New("invalid signature")
+		return ErrInvalidSignature
 	}
-
-	if !c.PrevHashValid(req.PrevHash, req.MerkleRoot) {
-		return nil, errors.New("block with same prevHash already voted")
+	if err := c.checkFresh(instance, false); err != nil {
+		return err
 	}
 
-	c.Debugf(`Get READY from "%s" with root "%.4s"`, name, merkle.MerkleRootToString(req.MerkleRoot))
+	root := req.MerkleRoot
+	key := InstanceKey{Instance: instance, Root: merkle.MerkleRootToString(root)}
+	c.Debugf(`Get READY from "%s" with %s`, name, key)
 
 	// TODO: after getting f+1 READY: Send Ready if not Sent
-	r, err := c.ReadiesReceived.Add(name, root, struct{}{})
+	// READYs are kept for the quorum certificate of the instance.
+	r, err := c.ReadiesReceived.Add(name, key, req)
 	if err != nil {
-		return nil, errors.Wrap(err, "Can't add this MerkleRoot to readiesReceived")
+		return errors.Wrap(err, "Can't add this MerkleRoot to readiesReceived")
 	}
 
-	if r == c.ByzantineLimit+1 {
-		if !c.readyIsSent(root) {
-			for _, p := range c.RBCSetting.AllPeers {
-				c.Debugf("Send READY (in Ready) to %#v", p)
+	if r == c.ReadyAmplification() {
+		if !c.readyIsSent(key) {
+			for _, p := range c.Peers() {
+				c.Debugf("Send READY (in Ready) of %s to %#v", instance, p)
 				// TODO: Don't understand why this SendReady always fail in GRPC
-				c.SendReady(p, root)
+				c.SendReady(p, instance, root)
 			}
 		}
 	}
 
-	rootString := merkle.MerkleRootToString(root)
-	if r == 2*c.ByzantineLimit+1 {
-		if len(c.EchosReceived.Rec[rootString]) >= len(c.RBCSetting.AllPeers)-2*c.ByzantineLimit {
-			c.Debugf("Get enough READY and ECHO to decode")
-			data, err := c.reconstructData(rootString)
-			if err != nil {
-				return nil, err
-			}
-			// c.Debugf("Data reconstructed")
-			// TODO: add it back when block and app is finished
-			//block, err := mao_utils.FromBytesToBlock(data)
-			//if err != nil {
-			//	return nil, err
-			//}
-
-			c.Debugf("Data reconstructed %.4s", hex.EncodeToString(data))
-			shouldSync, err := c.App.RBCReceive(data)
-			if err != nil {
-				return nil, errors.Wrap(err, "failed to apply the transaction")
-			}
-			if shouldSync {
-				c.Synchronize()
-			}
-		}
+	// 2f + 1 Ready and N - 2f Echo, decode and apply
+	if err := c.tryDeliver(key); err != nil {
+		return err
 	}
 
-	return &pb.ReadyResponse{}, nil
+	return nil
 }
@@ -0,0 +1,133 @@
+package common
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultSequenceWindow is used when RBCSetting.SequenceWindow is not set.
+const DefaultSequenceWindow = 1024
+
+// ErrStale means a message belongs to an instance that is too old to be accepted.
+var ErrStale = errors.New("stale message")
+
+// replayGuard rejects messages of old instances, which could be replayed after their state is dropped.
+type replayGuard struct {
+	// checkEpoch decides which epochs are accepted, epochs are views if it's nil.
+	checkEpoch EpochChecker
+	// latest maps each broadcaster to its latest instance, learned from PREPARE it signed.
+	latest map[string]Instance
+	// forgotten maps each broadcaster to its latest instance that has been forgotten after delivery.
+	forgotten map[string]Instance
+	mu        sync.Mutex
+}
+
+func (c *Common) sequenceWindow() int64 {
+	if c.SequenceWindow > 0 {
+		return c.SequenceWindow
+	}
+	return DefaultSequenceWindow
+}
+
+// EpochChecker returns an error if messages of instances in epoch aren't accepted, it's ErrStale if epoch is over.
+type EpochChecker func(epoch int64) error
+
+// CheckEpochs makes check decide which epochs messages are accepted from, instead of views. It's for modes whose
+// epochs aren't views, like ACS.
+func (c *Common) CheckEpochs(check EpochChecker) {
+	c.replay.mu.Lock()
+	defer c.replay.mu.Unlock()
+	c.replay.checkEpoch = check
+}
+
+// checkView accepts epoch if it's the current view, or the next view while this node votes to move there. Epoch is
+// picked by the broadcaster, so it can't open a lock or a window of a view that correct nodes aren't in. In fixed
+// leader mode, only view 0 is accepted.
+func (c *Common) checkView(epoch int64) error {
+	c.View.mu.Lock()
+	current, changing := c.View.Current, c.View.sent > c.View.Current
+	c.View.mu.Unlock()
+	switch {
+	case epoch < current:
+		return errors.Wrapf(ErrStale, "epoch %d is an old view", epoch)
+	case epoch == current, changing && epoch == current+1:
+		return nil
+	}
+	return errors.Errorf("epoch %d is ahead of view %d", epoch, current)
+}
+
+// checkEpoch checks epoch by the checker set by CheckEpochs, or by checkView.
+func (c *Common) checkEpoch(epoch int64) error {
+	c.replay.mu.Lock()
+	check := c.replay.checkEpoch
+	c.replay.mu.Unlock()
+	if check == nil {
+		check = c.checkView
+	}
+	return check(epoch)
+}
+
+// before returns whether instance a is broadcast before b by the same broadcaster.
+func before(a Instance, b Instance) bool {
+	return a.Epoch < b.Epoch || (a.Epoch == b.Epoch && a.Sequence < b.Sequence)
+}
+
+// checkFresh rejects messages of instance if it's stale or too far ahead:
+//   - Its epoch isn't accepted, see checkView, or it's before the latest epoch of broadcaster.
+//   - It's out of window, which is SequenceWindow instances around the latest broadcast.
+//   - It has been delivered and forgotten.
+//
+// fromBroadcaster is true if the message is signed by the broadcaster, only such a message moves the window forward,
+// so that other peers can't make this node reject messages of honest broadcasters.
+func (c *Common) checkFresh(instance Instance, fromBroadcaster bool) error {
+	if err := c.checkEpoch(instance.Epoch); err != nil {
+		return errors.Wrapf(err, "%s", instance)
+	}
+	g := &c.replay
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if forgotten, ok := g.forgotten[instance.Broadcaster]; ok && !before(forgotten, instance) {
+		return errors.Wrapf(ErrStale, "%s is forgotten", instance)
+	}
+	latest, ok := g.latest[instance.Broadcaster]
+	if !ok {
+		if fromBroadcaster {
+			g.setLatest(instance)
+		}
+		return nil
+	}
+	window := c.sequenceWindow()
+	switch {
+	case instance.Epoch < latest.Epoch:
+		return errors.Wrapf(ErrStale, "%s is from an old epoch", instance)
+	case instance.Epoch == latest.Epoch && instance.Sequence <= latest.Sequence-window:
+		return errors.Wrapf(ErrStale, "%s is out of window", instance)
+	case !fromBroadcaster && instance.Epoch == latest.Epoch && instance.Sequence > latest.Sequence+window:
+		return errors.Errorf("%s is too far ahead", instance)
+	}
+	if fromBroadcaster && before(latest, instance) {
+		g.setLatest(instance)
+	}
+	return nil
+}
+
+// setLatest must be called with g.mu held.
+func (g *replayGuard) setLatest(instance Instance) {
+	if g.latest == nil {
+		g.latest = make(map[string]Instance)
+	}
+	g.latest[instance.Broadcaster] = instance
+}
+
+// forget records that instance is forgotten, messages of it and earlier instances are rejected.
+func (g *replayGuard) forget(instance Instance) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.forgotten == nil {
+		g.forgotten = make(map[string]Instance)
+	}
+	if forgotten, ok := g.forgotten[instance.Broadcaster]; !ok || before(forgotten, instance) {
+		g.forgotten[instance.Broadcaster] = instance
+	}
+}
@@ -0,0 +1,77 @@
+package common
+
+import (
+	"crypto/sha256"
+
+	"github.com/gopricy/mao-bft/pb"
+	"github.com/pkg/errors"
+)
+
+// valueHashPrefix separates hashes of values from Merkle nodes, which hash concatenated hashes of their children.
+const valueHashPrefix = "mao-bft replicated value:"
+
+// ValueHash returns the hash that a value broadcast in replicated mode is voted on as.
+func ValueHash(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte(valueHashPrefix))
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// PayloadRoot returns the root that ECHOs and READYs of a payload vote on, it's the Merkle root in AVID mode and
+// hash of the value in replicated mode.
+func PayloadRoot(req *pb.Payload) []byte {
+	if req.Mode == pb.BroadcastMode_BM_REPLICATED {
+		return ValueHash(req.Data)
+	}
+	if req.MerkleProof == nil {
+		return nil
+	}
+	return req.MerkleProof.Root
+}
+
+// Replicated returns whether data is small enough to be broadcast in replicated mode, where each peer gets the whole
+// value instead of an erasure coded shard and its Merkle proof.
+func (c *Common) Replicated(data []byte) bool {
+	return c.ReplicatedLimit > 0 && len(data) <= c.ReplicatedLimit
+}
+
+// checkMode checks that req is a PREPARE or ECHO of a known mode, the mode is authenticated by the signature.
+func (c *Common) checkMode(req *pb.Payload) error {
+	switch req.Mode {
+	case pb.BroadcastMode_BM_AVID:
+		if req.MerkleProof == nil {
+			return errors.New("Merkle proof is missing")
+		}
+	case pb.BroadcastMode_BM_REPLICATED:
+	default:
+		return errors.Errorf("unknown broadcast mode %s", req.Mode)
+	}
+	return nil
+}
+
+// SendReplicatedEcho sends ECHO of a value broadcast in replicated mode. It carries the value, so that nodes that
+// missed PREPARE of a faulty broadcaster still deliver it.
+func (c *Common) SendReplicatedEcho(p *Peer, instance Instance, data []byte) {
+	payload := &pb.Payload{
+		Mode:     pb.BroadcastMode_BM_REPLICATED,
+		Data:     data,
+		Instance: instance.Pb(),
+	}
+	payload.Signature = c.SignEnvelope(PayloadEnvelope(pb.MessageType_MT_ECHO, payload))
+	c.SendMessage(p, KindEcho, payload)
+}
+
+// BroadcastReplicated sends PREPARE of data on prevHash in replicated mode to all peers.
+func (c *Common) BroadcastReplicated(instance Instance, prevHash []byte, data []byte) {
+	payload := &pb.Payload{
+		Mode:     pb.BroadcastMode_BM_REPLICATED,
+		PrevHash: prevHash,
+		Data:     data,
+		Instance: instance.Pb(),
+	}
+	payload.Signature = c.SignEnvelope(PayloadEnvelope(pb.MessageType_MT_PREPARE, payload))
+	for _, p := range c.Peers() {
+		c.SendMessage(p, KindPrepare, payload)
+	}
+}
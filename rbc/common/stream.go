@@ -0,0 +1,344 @@
+package common
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/gopricy/mao-bft/pb"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultStreamWindow is the number of messages that can be unacknowledged on the Consensus stream to a peer.
+const DefaultStreamWindow = 64
+
+// streamHeader is the header that a node sends when it accepts a Consensus stream.
+const streamHeader = "consensus-stream"
+
+// Consensus serves the Consensus stream of a peer. Messages are handled in order of sequence like unary calls of
+// their service, and acknowledged once they're handled. A message that's rejected is acknowledged too, it's only
+// logged, since resending it wouldn't change the outcome. A message that isn't signed by the peer ends the stream
+// instead, it's never acknowledged. The stream ends when this node stops.
+func (c *Common) Consensus(stream pb.Consensus_ConsensusServer) error {
+	ctx := stream.Context()
+	name, err := c.getNameFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	// The sender is authenticated once for the stream, a handshake is only fresh for HandshakeWindow after it opens.
+	authenticated := AuthenticatedContext(ctx, name)
+	// Header tells the caller that this node serves Consensus, before anything is sent.
+	if err := stream.SendHeader(metadata.Pairs(streamHeader, "1")); err != nil {
+		return err
+	}
+	received := make(chan *pb.ConsensusMessage)
+	failed := make(chan error, 1)
+	go func() {
+		for {
+			m, err := stream.Recv()
+			if err != nil {
+				failed <- err
+				return
+			}
+			select {
+			case received <- m:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	stopped := c.stopped()
+	var last uint64
+	for {
+		var m *pb.ConsensusMessage
+		select {
+		case m = <-received:
+		case err := <-failed:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case <-stopped:
+			return status.Error(codes.Unavailable, "node is stopped")
+		}
+		if m.Sequence != last+1 {
+			return errors.Errorf("message %d of %s is out of sequence, %d is expected", m.Sequence, name, last+1)
+		}
+		last = m.Sequence
+		if err := c.handleConsensus(authenticated, m); err != nil {
+			c.Infof("Message %d of Consensus stream of %s is rejected: %s", m.Sequence, name, err.Error())
+			if errors.Cause(err) == ErrInvalidSignature {
+				return status.Errorf(codes.Unauthenticated, "message %d of %s: %s", m.Sequence, name, err.Error())
+			}
+		}
+		if err := stream.Send(&pb.ConsensusMessage{Ack: last}); err != nil {
+			return err
+		}
+	}
+}
+
+// stopped returns a channel that's closed once this node stops.
+func (c *Common) stopped() <-chan struct{} {
+	c.outboxes.mu.Lock()
+	defer c.outboxes.mu.Unlock()
+	c.outboxes.init()
+	return c.outboxes.ctx.Done()
+}
+
+// handleConsensus handles a message of a Consensus stream by its service.
+func (c *Common) handleConsensus(ctx context.Context, m *pb.ConsensusMessage) error {
+	var err error
+	switch body := m.Body.(type) {
+	case *pb.ConsensusMessage_Prepare:
+		_, err = c.Prepare(ctx, body.Prepare)
+	case *pb.ConsensusMessage_Echo:
+		_, err = c.Echo(ctx, body.Echo)
+	case *pb.ConsensusMessage_Ready:
+		_, err = c.Ready(ctx, body.Ready)
+	case *pb.ConsensusMessage_Agreement:
+		_, err = c.Agreement(ctx, body.Agreement)
+	default:
+		err = errors.Errorf("message of type %T can't be handled", m.Body)
+	}
+	return err
+}
+
+// sendUnary sends m by a unary call of its service, like to a peer that doesn't serve Consensus.
+func sendUnary(ctx context.Context, conn *grpc.ClientConn, m *pb.ConsensusMessage) error {
+	var err error
+	switch body := m.Body.(type) {
+	case *pb.ConsensusMessage_Prepare:
+		_, err = pb.NewPrepareClient(conn).Prepare(ctx, body.Prepare)
+	case *pb.ConsensusMessage_Echo:
+		_, err = pb.NewEchoClient(conn).Echo(ctx, body.Echo)
+	case *pb.ConsensusMessage_Ready:
+		_, err = pb.NewReadyClient(conn).Ready(ctx, body.Ready)
+	case *pb.ConsensusMessage_Agreement:
+		_, err = pb.NewAgreementClient(conn).Agreement(ctx, body.Agreement)
+	default:
+		err = errors.Errorf("message of type %T can't be sent", m.Body)
+	}
+	return err
+}
+
+// errStreamBroken means a message is written to a Consensus stream that broke, it's sent again on the next stream.
+var errStreamBroken = errors.New("Consensus stream is broken")
+
+// peerStream is the Consensus stream to a peer. A message is pending from the time it's written until the peer
+// acknowledges it, pending messages are resent on the next stream if the stream breaks before that.
+type peerStream struct {
+	// send is held while messages are numbered and written, so that they're written in order of sequence.
+	send sync.Mutex
+	mu   sync.Mutex
+	// stream is nil until it's opened, and again once it breaks.
+	stream   pb.Consensus_ConsensusClient
+	cancel   context.CancelFunc
+	sequence uint64
+	pending  []*pb.ConsensusMessage
+	// progress is signalled when pending messages are acknowledged or the stream breaks.
+	progress chan struct{}
+	// unsupported is set once the peer turns out not to serve Consensus.
+	unsupported bool
+}
+
+func (t *GRPCTransport) streamWindow() int {
+	if t.StreamWindow > 0 {
+		return t.StreamWindow
+	}
+	return DefaultStreamWindow
+}
+
+// peerStream returns the Consensus stream to p, it isn't opened until a message is sent.
+func (t *GRPCTransport) peerStream(p *Peer) *peerStream {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.streams == nil {
+		t.streams = make(map[string]*peerStream)
+	}
+	ps, ok := t.streams[p.Name]
+	if !ok {
+		ps = &peerStream{progress: make(chan struct{}, 1)}
+		t.streams[p.Name] = ps
+	}
+	return ps
+}
+
+// send sends m to p on the Consensus stream, it waits while StreamWindow messages are unacknowledged. The identity
+// of ctx is the identity of the stream, which is opened by the first message.
+func (t *GRPCTransport) send(ctx context.Context, p *Peer, m *pb.ConsensusMessage) error {
+	conn, err := t.conn(p)
+	if err != nil {
+		return err
+	}
+	if t.Unary {
+		return sendUnary(ctx, conn, m)
+	}
+	ps := t.peerStream(p)
+	ps.send.Lock()
+	defer ps.send.Unlock()
+	for {
+		ps.mu.Lock()
+		unsupported, open, full := ps.unsupported, ps.stream != nil, len(ps.pending) >= t.streamWindow()
+		ps.mu.Unlock()
+		if unsupported {
+			return sendUnary(ctx, conn, m)
+		}
+		if !open {
+			if err := ps.open(ctx, conn); err != nil {
+				return errors.Wrapf(err, "failed to open Consensus stream to %s", p.Name)
+			}
+			continue
+		}
+		if !full {
+			break
+		}
+		select {
+		case <-ps.progress:
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "%d messages to %s are unacknowledged", t.streamWindow(), p.Name)
+		}
+	}
+	if err := ps.write(m); err != nil {
+		// m is resent by the caller, not with pending messages.
+		ps.mu.Lock()
+		if n := len(ps.pending); n > 0 && ps.pending[n-1] == m {
+			ps.pending = ps.pending[:n-1]
+		}
+		ps.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// open opens a Consensus stream on conn and resends pending messages on it. ps.send must be held. If the peer
+// doesn't serve Consensus, pending messages are sent by unary calls instead.
+func (ps *peerStream) open(ctx context.Context, conn *grpc.ClientConn) error {
+	// The stream outlives ctx, only identity of ctx is kept.
+	md, _ := metadata.FromOutgoingContext(ctx)
+	streamCtx, cancel := context.WithCancel(metadata.NewOutgoingContext(context.Background(), md))
+	var stream pb.Consensus_ConsensusClient
+	opened := make(chan error, 1)
+	go func() {
+		var err error
+		stream, err = pb.NewConsensusClient(conn).Consensus(streamCtx)
+		var header metadata.MD
+		if err == nil {
+			header, err = stream.Header()
+		}
+		if err == nil && len(header.Get(streamHeader)) == 0 {
+			// A peer that doesn't serve Consensus ends the stream with its status instead.
+			if _, err = stream.Recv(); err == nil {
+				err = errors.New("Consensus stream isn't accepted")
+			}
+		}
+		opened <- err
+	}()
+	var err error
+	select {
+	case err = <-opened:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		cancel()
+		if status.Code(err) != codes.Unimplemented {
+			return err
+		}
+		ps.mu.Lock()
+		ps.unsupported = true
+		pending := ps.pending
+		ps.pending = nil
+		ps.mu.Unlock()
+		for _, m := range pending {
+			if err := sendUnary(ctx, conn, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	ps.mu.Lock()
+	ps.stream, ps.cancel, ps.sequence = stream, cancel, 0
+	pending := ps.pending
+	ps.pending = nil
+	ps.mu.Unlock()
+	go ps.receive(stream)
+	for _, m := range pending {
+		if err := ps.write(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write numbers m and writes it to the stream, m is pending until it's acknowledged. ps.send must be held.
+func (ps *peerStream) write(m *pb.ConsensusMessage) error {
+	ps.mu.Lock()
+	stream := ps.stream
+	if stream == nil {
+		ps.mu.Unlock()
+		return errStreamBroken
+	}
+	ps.sequence++
+	m.Sequence = ps.sequence
+	ps.pending = append(ps.pending, m)
+	ps.mu.Unlock()
+	if err := stream.Send(m); err != nil {
+		ps.broken(stream)
+		return err
+	}
+	return nil
+}
+
+// receive drops pending messages that the peer acknowledges on stream, until stream breaks.
+func (ps *peerStream) receive(stream pb.Consensus_ConsensusClient) {
+	for {
+		m, err := stream.Recv()
+		if err != nil {
+			ps.broken(stream)
+			return
+		}
+		ps.mu.Lock()
+		if ps.stream == stream {
+			for len(ps.pending) > 0 && ps.pending[0].Sequence <= m.Ack {
+				ps.pending = ps.pending[1:]
+			}
+		}
+		ps.mu.Unlock()
+		ps.signal()
+	}
+}
+
+// broken drops stream if it's still the stream of ps, the next message opens a new one.
+func (ps *peerStream) broken(stream pb.Consensus_ConsensusClient) {
+	ps.mu.Lock()
+	if ps.stream == stream {
+		ps.cancel()
+		ps.stream = nil
+	}
+	ps.mu.Unlock()
+	ps.signal()
+}
+
+func (ps *peerStream) signal() {
+	select {
+	case ps.progress <- struct{}{}:
+	default:
+	}
+}
+
+// close closes the stream, pending messages are dropped.
+func (ps *peerStream) close() {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.stream != nil {
+		ps.cancel()
+		ps.stream = nil
+	}
+	ps.pending = nil
+	ps.sequence = 0
+}
@@ -27,19 +27,37 @@ func (c *Common) SendSync(p *Peer) (*pb.SyncResponse, error) {
 	if err != nil {
 		log.Fatalln("GetSyncQuestion fails: " + err.Error())
 	}
-	res, err := pb.NewSyncClient(p.GetConn()).Sync(c.CreateContext(), req)
+	return c.sendSync(p, req)
+}
+
+// sendSync sends req to given peer. If req has no LatestStaged, peer answers all blocks committed after LastCommit.
+func (c *Common) sendSync(p *Peer, req *pb.SyncRequest) (*pb.SyncResponse, error) {
+	res, err := c.Transport.Sync(c.CreateContext(p), p, req)
 	if err != nil {
 		return nil, err
 	}
 	// Validate the response is actually valid. This is very important because no one can fake answer.
+	blocks := res.Response
+	if len(req.LatestStaged) != 0 {
+		blocks = append(blocks, req.LatestStaged)
+	}
 	begin, err := mao_utils.DecodeBlock(req.LastCommit)
-	for _, blockBytes := range append(res.Response, req.LatestStaged) {
+	if err != nil {
+		return nil, errors.New("Last commit of sync request is not valid: " + err.Error())
+	}
+	for i, blockBytes := range blocks {
 		next, err := mao_utils.DecodeBlock(blockBytes)
 		if err != nil ||
 			!mao_utils.IsValidBlockHash(next) ||
 			!mao_utils.IsSameBytes(begin.CurHash, next.Content.PrevHash) {
 			return nil, errors.New("Peer's answer is not valid. Skip this peer: " + p.Name)
 		}
+		// Blocks of peer must be certified, so that peer can't make up a chain that was never agreed.
+		if i < len(res.Response) {
+			if err := VerifyBlockCertificate(&c.RBCSetting, next); err != nil {
+				return nil, errors.New("Peer's answer is not certified: " + err.Error() + ". Skip this peer: " + p.Name)
+			}
+		}
 		begin = next
 	}
 	// The result is valid, we return the response to Sync issuer.
@@ -48,7 +66,7 @@ func (c *Common) SendSync(p *Peer) (*pb.SyncResponse, error) {
 
 func (c *Common) Synchronize() {
 	// We do best effort round robin sync request to each peer.
-	for _, peer := range c.AllPeers {
+	for _, peer := range c.Peers() {
 		res, err := c.SendSync(peer)
 		if err != nil {
 			c.Infof("Skip since no valid answer or RPC is rejected for peer: " +
@@ -56,12 +74,34 @@ func (c *Common) Synchronize() {
 			continue
 		}
 		for _, bytes := range res.Response {
-			_, err := c.App.RBCReceive(bytes)
-			if err != nil {
-				log.Fatalln("Fail to apply sync's response.")
+			if err := c.applySynced(bytes); err != nil {
+				log.Fatalln("Fail to apply sync's response: " + err.Error())
 			}
 		}
 		c.Debugf(color.RedString("Successfully Synced with %s", peer.Name))
 		return
 	}
 }
+
+// applySynced commits a block answered to sync. The block carries its certificate, which sendSync verified, so it's
+// handed to applications implementing CertifiedApplication along with the block, and kept like one of a delivery.
+func (c *Common) applySynced(bytes []byte) error {
+	app, ok := c.App.(CertifiedApplication)
+	if !ok {
+		_, err := c.App.RBCReceive(bytes)
+		return err
+	}
+	block, err := mao_utils.DecodeBlock(bytes)
+	if err != nil {
+		return err
+	}
+	// The certified data is the block without its certificate.
+	certificate := block.Certificate
+	block.Certificate = nil
+	data, err := mao_utils.EncodeBlock(block)
+	if err != nil {
+		return err
+	}
+	_, err = app.RBCReceiveCertified(data, certificate)
+	return err
+}
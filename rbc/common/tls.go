@@ -0,0 +1,127 @@
+package common
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// TLSIdentity is the PEM encoded certificate and private key of a node.
+type TLSIdentity struct {
+	Cert []byte
+	Key  []byte
+}
+
+// EnableTLS makes this node use mutual TLS with identity, both for its server and for connections to peers.
+// CACert and certificates of all peers must be set in RBCSetting. A peer is only accepted if it presents exactly the
+// certificate listed for it.
+func (c *Common) EnableTLS(identity TLSIdentity) error {
+	config, err := c.tlsConfig(identity)
+	if err != nil {
+		return err
+	}
+	c.serverTLS = config
+	c.certOwners = make(map[string]string)
+	for name, p := range c.AllPeers {
+		der, _ := certDER(p.Cert)
+		c.certOwners[string(der)] = name
+	}
+	c.Transport = &GRPCTransport{TLS: config}
+	return nil
+}
+
+// ServerOptions returns options of the gRPC server of this node. With TLS, only peers that present their certificate
+// complete the handshake, clients are served by a server with ClientServerOptions.
+func (c *Common) ServerOptions() []grpc.ServerOption {
+	if c.serverTLS == nil {
+		return nil
+	}
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(c.serverTLS))}
+}
+
+// ClientServerOptions returns options of the gRPC server that clients of TransactionService connect to on ClientPORT,
+// see RegisterClientService. With TLS, this node presents its certificate but clients don't need one, so none of them
+// can pass as a peer.
+func (c *Common) ClientServerOptions() []grpc.ServerOption {
+	if c.serverTLS == nil {
+		return nil
+	}
+	config := c.serverTLS.Clone()
+	config.ClientAuth = tls.NoClientCert
+	config.ClientCAs = nil
+	config.VerifyPeerCertificate = nil
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(config))}
+}
+
+func (c *Common) tlsConfig(identity TLSIdentity) (*tls.Config, error) {
+	if len(c.CACert) == 0 {
+		return nil, errors.New("CA certificate is not set")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(c.CACert) {
+		return nil, errors.New("invalid CA certificate")
+	}
+	pair, err := tls.X509KeyPair(identity.Cert, identity.Key)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid TLS identity")
+	}
+	known := make(map[string][]byte)
+	for name, p := range c.AllPeers {
+		der, err := certDER(p.Cert)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid certificate of %s", name)
+		}
+		known[name] = der
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{pair},
+		RootCAs:      pool,
+		ClientCAs:    pool,
+		// Unknown peers are rejected at handshake, clients of TransactionService are served on another listener.
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		MinVersion: tls.VersionTLS12,
+		// Certificates are verified against CA before this, a certificate must also belong to a member of the cluster.
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errors.New("no certificate")
+			}
+			for _, der := range known {
+				if bytes.Equal(rawCerts[0], der) {
+					return nil
+				}
+			}
+			return errors.New("certificate doesn't belong to any peer")
+		},
+	}, nil
+}
+
+// peerTLSConfig returns the config to dial p, p must present its own certificate.
+func peerTLSConfig(config *tls.Config, p *Peer) (*tls.Config, error) {
+	der, err := certDER(p.Cert)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid certificate of %s", p.Name)
+	}
+	res := config.Clone()
+	res.ServerName = p.Name
+	res.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 || !bytes.Equal(rawCerts[0], der) {
+			return errors.Errorf("certificate doesn't belong to %s", p.Name)
+		}
+		return nil
+	}
+	return res, nil
+}
+
+// certDER decodes a PEM encoded certificate.
+func certDER(certPEM []byte) ([]byte, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no PEM encoded certificate")
+	}
+	return block.Bytes, nil
+}
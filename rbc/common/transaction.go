@@ -0,0 +1,46 @@
+package common
+
+import (
+	"context"
+
+	"github.com/gopricy/mao-bft/pb"
+	"github.com/pkg/errors"
+)
+
+// ProposeTransaction serves transactions proposed by clients, the transaction is handed to App.
+func (c *Common) ProposeTransaction(
+	ctx context.Context, in *pb.ProposeTransactionRequest) (*pb.ProposeTransactionResponse, error) {
+	app, ok := c.App.(TransactionApplication)
+	if !ok {
+		return nil, errors.New("application doesn't serve transactions")
+	}
+	if in.Transaction == nil {
+		return nil, errors.New("transaction is required")
+	}
+	// In view change mode, peers forward transactions they received from clients to each other.
+	if _, err := c.getNameFromContext(ctx); err == nil && c.ViewChangeEnabled() {
+		vcApp, ok := c.App.(ViewChangeApplication)
+		if !ok {
+			return nil, errors.New("application doesn't support view change")
+		}
+		if err := vcApp.AcceptForwarded(in.Transaction); err != nil {
+			return nil, errors.Wrap(err, "failed to accept the forwarded transaction")
+		}
+		return &pb.ProposeTransactionResponse{TransactionUuid: in.Transaction.TransactionUuid}, nil
+	}
+	id, err := app.ProposeTransaction(in.Transaction)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to propose the transaction")
+	}
+	return &pb.ProposeTransactionResponse{TransactionUuid: id}, nil
+}
+
+// GetTransactionStatus serves status queries from clients.
+func (c *Common) GetTransactionStatus(
+	ctx context.Context, in *pb.GetTransactionStatusRequest) (*pb.GetTransactionStatusResponse, error) {
+	app, ok := c.App.(TransactionApplication)
+	if !ok {
+		return nil, errors.New("application doesn't serve transactions")
+	}
+	return &pb.GetTransactionStatusResponse{Status: app.GetTransactionStatus(in.TransactionUuid)}, nil
+}
@@ -0,0 +1,319 @@
+package common
+
+import (
+	"context"
+	"crypto/tls"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/gopricy/mao-bft/pb"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+)
+
+// Transport delivers messages to peers. Identity of the sender is carried by ctx, see Common.CreateContext.
+type Transport interface {
+	Prepare(ctx context.Context, p *Peer, req *pb.Payload) error
+	Echo(ctx context.Context, p *Peer, req *pb.Payload) error
+	Ready(ctx context.Context, p *Peer, req *pb.ReadyRequest) error
+	Sync(ctx context.Context, p *Peer, req *pb.SyncRequest) (*pb.SyncResponse, error)
+	ViewChange(ctx context.Context, p *Peer, req *pb.ViewChangeRequest) error
+	ProposeTransaction(ctx context.Context, p *Peer, req *pb.ProposeTransactionRequest) error
+	ReportEquivocation(ctx context.Context, p *Peer, req *pb.EquivocationEvidence) error
+	Agreement(ctx context.Context, p *Peer, req *pb.AgreementMessage) error
+}
+
+// QueuedTransport is a Transport that queues messages by itself and never fails, like a simulated network. Messages
+// to it bypass outboxes and are handed over right away, in the order they're sent.
+type QueuedTransport interface {
+	Transport
+	// Queued marks a Transport as QueuedTransport.
+	Queued()
+}
+
+// Node serves all messages a peer can receive.
+type Node interface {
+	pb.PrepareServer
+	pb.EchoServer
+	pb.ReadyServer
+	pb.SyncServer
+	pb.ViewChangeServer
+	pb.TransactionServiceServer
+	pb.EquivocationServer
+	pb.AdminServer
+	pb.AgreementServer
+	pb.ConsensusServer
+}
+
+var _ Node = &Common{}
+
+// RegisterNode registers all services of node to s.
+func RegisterNode(s *grpc.Server, node Node) {
+	pb.RegisterPrepareServer(s, node)
+	pb.RegisterEchoServer(s, node)
+	pb.RegisterReadyServer(s, node)
+	pb.RegisterSyncServer(s, node)
+	pb.RegisterViewChangeServer(s, node)
+	pb.RegisterTransactionServiceServer(s, node)
+	pb.RegisterEquivocationServer(s, node)
+	pb.RegisterAdminServer(s, node)
+	pb.RegisterAgreementServer(s, node)
+	pb.RegisterConsensusServer(s, node)
+}
+
+// RegisterClientService registers services of node for clients to s, it's for the server on ClientPORT.
+func RegisterClientService(s *grpc.Server, node Node) {
+	pb.RegisterTransactionServiceServer(s, node)
+}
+
+// GRPCTransport sends messages through gRPC, it keeps one connection to each peer. PREPARE, ECHO, READY and binary
+// agreement messages to a peer share one Consensus stream, other messages are unary calls.
+type GRPCTransport struct {
+	// TLS enables mutual TLS if it's set, each peer must present its own certificate.
+	TLS *tls.Config
+	// Unary sends every message by a unary call of its service, like to peers that don't serve Consensus.
+	Unary bool
+	// StreamWindow is the number of messages that can be unacknowledged on the Consensus stream to a peer,
+	// DefaultStreamWindow is used if it's not set.
+	StreamWindow int
+	conns        map[string]*peerConn
+	streams      map[string]*peerStream
+	mu           sync.Mutex
+}
+
+var _ Transport = &GRPCTransport{}
+
+// peerConn is the connection to a peer, mu is held while dialing so that other peers aren't held up.
+type peerConn struct {
+	conn *grpc.ClientConn
+	mu   sync.Mutex
+}
+
+// conn returns the connection to p, it dials p if there's no connection yet.
+func (t *GRPCTransport) conn(p *Peer) (*grpc.ClientConn, error) {
+	t.mu.Lock()
+	if t.conns == nil {
+		t.conns = make(map[string]*peerConn)
+	}
+	pc, ok := t.conns[p.Name]
+	if !ok {
+		pc = &peerConn{}
+		t.conns[p.Name] = pc
+	}
+	t.mu.Unlock()
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.conn != nil && pc.conn.GetState() != connectivity.Shutdown {
+		return pc.conn, nil
+	}
+	creds := grpc.WithInsecure()
+	if t.TLS != nil {
+		config, err := peerTLSConfig(t.TLS, p)
+		if err != nil {
+			return nil, err
+		}
+		creds = grpc.WithTransportCredentials(credentials.NewTLS(config))
+	}
+	conn, err := createConnection(p.IP, p.PORT, creds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect %s", p.Name)
+	}
+	pc.conn = conn
+	return conn, nil
+}
+
+// Close closes streams and connections to all peers.
+func (t *GRPCTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, ps := range t.streams {
+		ps.close()
+	}
+	for _, pc := range t.conns {
+		pc.mu.Lock()
+		if pc.conn != nil {
+			pc.conn.Close()
+			pc.conn = nil
+		}
+		pc.mu.Unlock()
+	}
+	return nil
+}
+
+func (t *GRPCTransport) Prepare(ctx context.Context, p *Peer, req *pb.Payload) error {
+	return t.send(ctx, p, &pb.ConsensusMessage{Body: &pb.ConsensusMessage_Prepare{Prepare: req}})
+}
+
+func (t *GRPCTransport) Echo(ctx context.Context, p *Peer, req *pb.Payload) error {
+	return t.send(ctx, p, &pb.ConsensusMessage{Body: &pb.ConsensusMessage_Echo{Echo: req}})
+}
+
+func (t *GRPCTransport) Ready(ctx context.Context, p *Peer, req *pb.ReadyRequest) error {
+	return t.send(ctx, p, &pb.ConsensusMessage{Body: &pb.ConsensusMessage_Ready{Ready: req}})
+}
+
+func (t *GRPCTransport) Sync(ctx context.Context, p *Peer, req *pb.SyncRequest) (*pb.SyncResponse, error) {
+	conn, err := t.conn(p)
+	if err != nil {
+		return nil, err
+	}
+	return pb.NewSyncClient(conn).Sync(ctx, req)
+}
+
+func (t *GRPCTransport) ViewChange(ctx context.Context, p *Peer, req *pb.ViewChangeRequest) error {
+	conn, err := t.conn(p)
+	if err != nil {
+		return err
+	}
+	_, err = pb.NewViewChangeClient(conn).ViewChange(ctx, req)
+	return err
+}
+
+func (t *GRPCTransport) ProposeTransaction(ctx context.Context, p *Peer, req *pb.ProposeTransactionRequest) error {
+	conn, err := t.conn(p)
+	if err != nil {
+		return err
+	}
+	_, err = pb.NewTransactionServiceClient(conn).ProposeTransaction(ctx, req)
+	return err
+}
+
+func (t *GRPCTransport) ReportEquivocation(ctx context.Context, p *Peer, req *pb.EquivocationEvidence) error {
+	conn, err := t.conn(p)
+	if err != nil {
+		return err
+	}
+	_, err = pb.NewEquivocationClient(conn).ReportEquivocation(ctx, req)
+	return err
+}
+
+func (t *GRPCTransport) Agreement(ctx context.Context, p *Peer, req *pb.AgreementMessage) error {
+	return t.send(ctx, p, &pb.ConsensusMessage{Body: &pb.ConsensusMessage_Agreement{Agreement: req}})
+}
+
+// ErrUnreachable is returned by MemoryNetwork when peer is not attached.
+var ErrUnreachable = errors.New("peer is unreachable")
+
+// MemoryNetwork connects nodes in the same process, messages are handed to nodes directly without any socket.
+// Requests are copied, so that nodes never share messages like they don't over gRPC.
+type MemoryNetwork struct {
+	// DialTimeout is how long sending to an unreachable peer takes to fail, like dialing a peer that is down.
+	DialTimeout time.Duration
+	nodes       map[string]Node
+	mu          sync.RWMutex
+}
+
+func NewMemoryNetwork() *MemoryNetwork {
+	return &MemoryNetwork{DialTimeout: time.Second, nodes: make(map[string]Node)}
+}
+
+// Attach makes node reachable as peer name.
+func (n *MemoryNetwork) Attach(name string, node Node) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.nodes[name] = node
+}
+
+// Detach makes peer name unreachable, as if it's down.
+func (n *MemoryNetwork) Detach(name string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.nodes, name)
+}
+
+// Endpoint returns the transport of node name, peers receive its messages as sent by name.
+func (n *MemoryNetwork) Endpoint(name string) Transport {
+	return &memoryEndpoint{network: n, name: name}
+}
+
+// memoryEndpoint sends messages of node name through network.
+type memoryEndpoint struct {
+	network *MemoryNetwork
+	name    string
+}
+
+// dial returns node of p, and ctx as it's received by the node.
+func (e *memoryEndpoint) dial(ctx context.Context, p *Peer) (Node, context.Context, error) {
+	e.network.mu.RLock()
+	node, ok := e.network.nodes[p.Name]
+	e.network.mu.RUnlock()
+	if !ok {
+		time.Sleep(e.network.DialTimeout)
+		return nil, nil, errors.Wrap(ErrUnreachable, p.Name)
+	}
+	return node, AuthenticatedContext(ctx, e.name), nil
+}
+func (e *memoryEndpoint) Prepare(ctx context.Context, p *Peer, req *pb.Payload) error {
+	node, ctx, err := e.dial(ctx, p)
+	if err != nil {
+		return err
+	}
+	_, err = node.Prepare(ctx, proto.Clone(req).(*pb.Payload))
+	return err
+}
+
+func (e *memoryEndpoint) Echo(ctx context.Context, p *Peer, req *pb.Payload) error {
+	node, ctx, err := e.dial(ctx, p)
+	if err != nil {
+		return err
+	}
+	_, err = node.Echo(ctx, proto.Clone(req).(*pb.Payload))
+	return err
+}
+
+func (e *memoryEndpoint) Ready(ctx context.Context, p *Peer, req *pb.ReadyRequest) error {
+	node, ctx, err := e.dial(ctx, p)
+	if err != nil {
+		return err
+	}
+	_, err = node.Ready(ctx, proto.Clone(req).(*pb.ReadyRequest))
+	return err
+}
+
+func (e *memoryEndpoint) Sync(ctx context.Context, p *Peer, req *pb.SyncRequest) (*pb.SyncResponse, error) {
+	node, ctx, err := e.dial(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	return node.Sync(ctx, proto.Clone(req).(*pb.SyncRequest))
+}
+
+func (e *memoryEndpoint) ViewChange(ctx context.Context, p *Peer, req *pb.ViewChangeRequest) error {
+	node, ctx, err := e.dial(ctx, p)
+	if err != nil {
+		return err
+	}
+	_, err = node.ViewChange(ctx, proto.Clone(req).(*pb.ViewChangeRequest))
+	return err
+}
+
+func (e *memoryEndpoint) ProposeTransaction(ctx context.Context, p *Peer, req *pb.ProposeTransactionRequest) error {
+	node, ctx, err := e.dial(ctx, p)
+	if err != nil {
+		return err
+	}
+	_, err = node.ProposeTransaction(ctx, proto.Clone(req).(*pb.ProposeTransactionRequest))
+	return err
+}
+
+func (e *memoryEndpoint) ReportEquivocation(ctx context.Context, p *Peer, req *pb.EquivocationEvidence) error {
+	node, ctx, err := e.dial(ctx, p)
+	if err != nil {
+		return err
+	}
+	_, err = node.ReportEquivocation(ctx, proto.Clone(req).(*pb.EquivocationEvidence))
+	return err
+}
+
+func (e *memoryEndpoint) Agreement(ctx context.Context, p *Peer, req *pb.AgreementMessage) error {
+	node, ctx, err := e.dial(ctx, p)
+	if err != nil {
+		return err
+	}
+	_, err = node.Agreement(ctx, proto.Clone(req).(*pb.AgreementMessage))
+	return err
+}
@@ -7,8 +7,7 @@ import (
 	"google.golang.org/grpc"
 )
 
-// TODO: add TLS
-func createConnection(ip string, port int) (*grpc.ClientConn, error) {
+func createConnection(ip string, port int, creds grpc.DialOption) (*grpc.ClientConn, error) {
 	//TODO: PERFORMANCE WithBlock is a blocking call, probably need unblocking call for performance
-	return grpc.Dial(fmt.Sprintf("%s:%d", ip, port), grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(time.Second))
+	return grpc.Dial(fmt.Sprintf("%s:%d", ip, port), creds, grpc.WithBlock(), grpc.WithTimeout(time.Second))
 }
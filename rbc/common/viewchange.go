@@ -0,0 +1,275 @@
+package common
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/golang/protobuf/proto"
+	"github.com/gopricy/mao-bft/pb"
+	mao_utils "github.com/gopricy/mao-bft/utils"
+	"github.com/pkg/errors"
+)
+
+// View tracks the view a node is in. The leader of view v is the (v mod N)-th peer of AllPeers ordered by name.
+type View struct {
+	// Current is the view this node is in.
+	Current int64
+	// sent is the highest view this node has voted for.
+	sent int64
+	// votes collects VIEW-CHANGE votes of each view from each peer.
+	votes map[int64]map[string]*pb.ViewChangeVote
+	// sources are the voters that installed Current, most committed blocks first, CatchUp syncs from them in order.
+	sources []voter
+	mu      sync.Mutex
+	// notifyMu makes sure App sees new views in order.
+	notifyMu sync.Mutex
+}
+
+// voter is a peer that voted for a view, along with the number of blocks it claims to have committed.
+type voter struct {
+	name   string
+	height int64
+}
+
+// ViewChangeEnabled returns whether leader is rotated among peers.
+func (c *Common) ViewChangeEnabled() bool {
+	return c.ViewChangeTimeout > 0
+}
+
+// LeaderOf returns name of the leader in view.
+func (c *Common) LeaderOf(view int64) string {
+	names := c.sortedPeers()
+	return names[view%int64(len(names))]
+}
+
+// Leader returns name of the leader in current view.
+func (c *Common) Leader() string {
+	c.View.mu.Lock()
+	defer c.View.mu.Unlock()
+	return c.LeaderOf(c.View.Current)
+}
+
+// CurrentView returns the view this node is in, it's always 0 in fixed leader mode.
+func (c *Common) CurrentView() int64 {
+	c.View.mu.Lock()
+	defer c.View.mu.Unlock()
+	return c.View.Current
+}
+
+// IsLeader returns whether this node leads current view.
+func (c *Common) IsLeader() bool {
+	return c.Leader() == c.Name()
+}
+
+// SuspectLeader votes to move to the next view, it's a no-op if this node has already voted for that view.
+func (c *Common) SuspectLeader() {
+	c.View.mu.Lock()
+	defer c.View.mu.Unlock()
+	next := c.View.Current + 1
+	if c.View.sent >= next {
+		return
+	}
+	c.Infof("Suspect leader %s of view %d", c.LeaderOf(c.View.Current), c.View.Current)
+	c.voteForView(next)
+}
+
+// voteForView broadcasts VIEW-CHANGE for view, View.mu must be held.
+func (c *Common) voteForView(view int64) {
+	app, ok := c.App.(ViewChangeApplication)
+	if !ok {
+		c.Infof("Application doesn't support view change")
+		return
+	}
+	lastCommit, height := app.LastCommitted()
+	bytes, err := proto.Marshal(&pb.ViewChangeVote{
+		NewView:    view,
+		LastCommit: lastCommit,
+		Height:     height,
+		ClusterId:  c.ClusterID,
+	})
+	if err != nil {
+		panic(err)
+	}
+	req := &pb.ViewChangeRequest{Vote: c.Sign(bytes)}
+	c.View.sent = view
+	for _, p := range c.Peers() {
+		c.Debugf("Send VIEW-CHANGE for view %d to %#v", view, p)
+		c.SendViewChange(p, req)
+	}
+}
+
+// SendViewChange sends a VIEW-CHANGE vote to given peer.
+func (c *Common) SendViewChange(p *Peer, req *pb.ViewChangeRequest) {
+	c.SendMessage(p, KindViewChange, req)
+}
+
+// ViewChange serves VIEW-CHANGE votes from other nodes. A node joins a view change once f + 1 nodes vote for it,
+// and moves to the new view once 2f + 1 nodes vote for it.
+func (c *Common) ViewChange(ctx context.Context, req *pb.ViewChangeRequest) (*pb.ViewChangeResponse, error) {
+	err := c.receive(ctx, Message{Kind: KindViewChange, Body: req}, func(ctx context.Context, m proto.Message) error {
+		return c.viewChange(ctx, m.(*pb.ViewChangeRequest))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ViewChangeResponse{}, nil
+}
+
+// viewChange handles a VIEW-CHANGE vote that the adversary of this node lets through.
+func (c *Common) viewChange(ctx context.Context, req *pb.ViewChangeRequest) error {
+	if !c.ViewChangeEnabled() {
+		return errors.New("view change is disabled")
+	}
+	c.SetColor(color.FgMagenta)
+	defer c.UnsetColor()
+	c.Debugf(`------VIEW-CHANGE Server------`)
+	data, verified, name := c.open(ctx, req.Vote)
+	if !verified {
+		return ErrInvalidSignature
+	}
+	vote := &pb.ViewChangeVote{}
+	if err := proto.Unmarshal(data, vote); err != nil {
+		return errors.Wrap(err, "can't decode VIEW-CHANGE vote")
+	}
+	if vote.ClusterId != c.ClusterID {
+		return errors.Errorf("VIEW-CHANGE of cluster %q", vote.ClusterId)
+	}
+	c.Debugf(`Get VIEW-CHANGE for view %d from %s`, vote.NewView, name)
+
+	c.View.mu.Lock()
+	v := vote.NewView
+	if v <= c.View.Current {
+		// Stale vote, this node has already moved on.
+		c.View.mu.Unlock()
+		return nil
+	}
+	// Views are voted for one after another, votes of views further ahead are refused so that a faulty peer can't
+	// make this node keep votes of every view.
+	if v > c.View.Current+int64(len(c.AllPeers)) {
+		current := c.View.Current
+		c.View.mu.Unlock()
+		return errors.Errorf("view %d is too far ahead of %d", v, current)
+	}
+	if c.View.votes == nil {
+		c.View.votes = make(map[int64]map[string]*pb.ViewChangeVote)
+	}
+	if _, ok := c.View.votes[v]; !ok {
+		c.View.votes[v] = make(map[string]*pb.ViewChangeVote)
+	}
+	c.View.votes[v][name] = vote
+	n := len(c.View.votes[v])
+	// f + 1 votes contain at least one from a correct node, so it's safe to join.
+	if n >= c.ByzantineLimit+1 && c.View.sent < v {
+		c.voteForView(v)
+	}
+	installed := n >= 2*c.ByzantineLimit+1
+	if installed {
+		c.installView(v)
+	}
+	c.View.mu.Unlock()
+
+	if installed {
+		c.notifyNewView(v)
+	}
+	return nil
+}
+
+// installView moves this node to view, View.mu must be held.
+func (c *Common) installView(view int64) {
+	c.View.sources = nil
+	for name, vote := range c.View.votes[view] {
+		c.View.sources = append(c.View.sources, voter{name: name, height: vote.Height})
+	}
+	sort.Slice(c.View.sources, func(i, j int) bool {
+		a, b := c.View.sources[i], c.View.sources[j]
+		return a.height > b.height || (a.height == b.height && a.name < b.name)
+	})
+	c.View.Current = view
+	if c.View.sent < view {
+		c.View.sent = view
+	}
+	for v := range c.View.votes {
+		if v <= view {
+			delete(c.View.votes, v)
+		}
+	}
+	c.Infof("Move to view %d, leader is %s", view, c.LeaderOf(view))
+}
+
+// notifyNewView tells App that this node is in view, unless it has already moved further.
+func (c *Common) notifyNewView(view int64) {
+	c.View.notifyMu.Lock()
+	defer c.View.notifyMu.Unlock()
+	c.View.mu.Lock()
+	current := c.View.Current
+	c.View.mu.Unlock()
+	if current != view {
+		return
+	}
+	app, ok := c.App.(ViewChangeApplication)
+	if !ok {
+		return
+	}
+	leader := c.LeaderOf(view)
+	app.NewView(view, leader, leader == c.Name())
+}
+
+// CatchUp synchronizes committed blocks from the voters of current view change, in order of the number of blocks
+// they claim to have committed. Heights in votes aren't proven, so a voter whose sync fails is skipped, until this
+// node has as many blocks as the next voter claims.
+func (c *Common) CatchUp() {
+	c.View.mu.Lock()
+	sources := c.View.sources
+	c.View.mu.Unlock()
+	app, ok := c.App.(ViewChangeApplication)
+	if !ok {
+		return
+	}
+	for _, source := range sources {
+		lastCommit, committed := app.LastCommitted()
+		if committed >= source.height {
+			return
+		}
+		if source.name == c.Name() {
+			continue
+		}
+		bytes, err := mao_utils.EncodeBlock(lastCommit)
+		if err != nil {
+			panic(err)
+		}
+		res, err := c.sendSync(c.AllPeers[source.name], &pb.SyncRequest{LastCommit: bytes})
+		if err != nil {
+			c.Infof("Failed to catch up with %s: %s", source.name, err.Error())
+			continue
+		}
+		if err := c.applyAll(res.Response); err != nil {
+			c.Infof("Failed to apply block from %s: %s", source.name, err.Error())
+			continue
+		}
+		c.Debugf(color.RedString("Successfully caught up with %s", source.name))
+		return
+	}
+}
+
+// applyAll commits blocks answered to sync in order, it stops at the first block that fails.
+func (c *Common) applyAll(blocks [][]byte) error {
+	for _, bytes := range blocks {
+		if err := c.applySynced(bytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BroadcastTransaction forwards a transaction received from client to all other peers.
+func (c *Common) BroadcastTransaction(txn *pb.Transaction) {
+	req := &pb.ProposeTransactionRequest{Transaction: txn}
+	for _, p := range c.Peers() {
+		if p.Name == c.Name() {
+			continue
+		}
+		c.SendMessage(p, KindTransaction, req)
+	}
+}
@@ -0,0 +1,170 @@
+package common
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gopricy/mao-bft/pb"
+	"github.com/gopricy/mao-bft/rbc/merkle"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// voteLockKey identifies the parent that a vote lock is taken on. Locks are scoped by view in view change mode, since
+// a new leader may propose another block on the same parent, and all locks are in epoch 0 in fixed leader mode.
+type voteLockKey struct {
+	epoch    int64
+	prevHash string
+}
+
+// fileName returns name of the file that lock of key is stored in.
+func (k voteLockKey) fileName() string {
+	return fmt.Sprintf("%d_%s", k.epoch, hex.EncodeToString([]byte(k.prevHash)))
+}
+
+func parseVoteLockFileName(name string) (voteLockKey, error) {
+	parts := strings.SplitN(name, "_", 2)
+	if len(parts) != 2 {
+		return voteLockKey{}, errors.Errorf("invalid vote lock file %s", name)
+	}
+	epoch, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return voteLockKey{}, errors.Wrapf(err, "invalid vote lock file %s", name)
+	}
+	prevHash, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return voteLockKey{}, errors.Wrapf(err, "invalid vote lock file %s", name)
+	}
+	return voteLockKey{epoch: epoch, prevHash: string(prevHash)}, nil
+}
+
+// VoteLocks records the PREPARE this node echoed for each parent block, so that it never echoes two blocks on the same
+// parent. Locks are written to dir before they take effect, so they survive restarts. The signed PREPARE is kept
+// rather than its root, so that it can serve as evidence if the leader equivocates.
+type VoteLocks struct {
+	// dir is where locks are stored, locks are only kept in memory if it's empty.
+	dir   string
+	locks map[voteLockKey]*pb.Payload
+	mu    sync.Mutex
+}
+
+// OpenVoteLocks loads vote locks stored in dir, dir is created if it doesn't exist.
+func OpenVoteLocks(dir string) (*VoteLocks, error) {
+	res := &VoteLocks{dir: dir, locks: make(map[voteLockKey]*pb.Payload)}
+	if dir == "" {
+		return res, nil
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, errors.Wrap(err, "can't create vote lock directory")
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".tmp") {
+			// A lock that wasn't completely written never took effect.
+			continue
+		}
+		key, err := parseVoteLockFileName(file.Name())
+		if err != nil {
+			return nil, err
+		}
+		bytes, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		prepare := &pb.Payload{}
+		if err := proto.Unmarshal(bytes, prepare); err != nil {
+			return nil, errors.Wrapf(err, "invalid vote lock file %s", file.Name())
+		}
+		res.locks[key] = prepare
+	}
+	return res, nil
+}
+
+// Lock locks parent of prepare in epoch to its root. It returns the PREPARE that the parent is locked to, which has
+// another root than prepare if the parent is already locked.
+func (v *VoteLocks) Lock(epoch int64, prepare *pb.Payload) (*pb.Payload, error) {
+	key := voteLockKey{epoch: epoch, prevHash: string(prepare.PrevHash)}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if locked, ok := v.locks[key]; ok {
+		return locked, nil
+	}
+	if v.dir != "" {
+		bytes, err := proto.Marshal(prepare)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeFileSynced(filepath.Join(v.dir, key.fileName()), bytes); err != nil {
+			return nil, errors.Wrap(err, "can't write vote lock")
+		}
+	}
+	v.locks[key] = prepare
+	return prepare, nil
+}
+
+// Release drops the lock that is taken by the PREPARE of key, it's called once the block of key is committed, so no
+// other block can be committed on its parent. It's a no-op if parent is locked by another PREPARE.
+func (v *VoteLocks) Release(key InstanceKey) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for lockKey, prepare := range v.locks {
+		instance, err := InstanceFromPb(prepare.Instance)
+		if err != nil || instance != key.Instance || merkle.MerkleRootToString(PayloadRoot(prepare)) != key.Root {
+			continue
+		}
+		if v.dir != "" {
+			if err := os.Remove(filepath.Join(v.dir, lockKey.fileName())); err != nil && !os.IsNotExist(err) {
+				// The lock is loaded again after restart, it's harmless since its block is committed.
+				continue
+			}
+		}
+		delete(v.locks, lockKey)
+	}
+}
+
+// writeFileSynced writes bytes to path through a temporary file. Both the file and its directory are synced, so that
+// path is on disk with complete content once it returns.
+func writeFileSynced(path string, bytes []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(bytes); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+// Locked returns the PREPARE that prevHash is locked to in epoch.
+func (v *VoteLocks) Locked(epoch int64, prevHash []byte) (*pb.Payload, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	prepare, ok := v.locks[voteLockKey{epoch: epoch, prevHash: string(prevHash)}]
+	return prepare, ok
+}
@@ -10,17 +10,19 @@ import (
 
 type Common interface {
 	Name() string
-	SendEcho(*common.Peer, *pb.MerkleProof, []byte)
-	SendReady(*common.Peer, []byte)
+	SendEcho(*common.Peer, common.Instance, *pb.MerkleProof, []byte)
+	SendReady(*common.Peer, common.Instance, []byte)
 	pb.ReadyServer
 	pb.EchoServer
 	pb.PrepareServer
+	pb.TransactionServiceServer
+	pb.ViewChangeServer
 }
 
 var _ Common = &common.Common{}
 
 type Mao interface {
-	SendPrepare(*common.Peer, *pb.MerkleProof, []byte, []byte)
+	SendPrepare(*common.Peer, common.Instance, *pb.MerkleProof, []byte, []byte)
 	// TODO: we can change it to block
 	RBCSend([]byte)
 	Common
@@ -2,6 +2,7 @@ package erasure
 
 import (
 	"bytes"
+	"encoding/binary"
 
 	"github.com/gopricy/mao-bft/pb"
 	"github.com/gopricy/mao-bft/rbc/merkle"
@@ -9,12 +10,42 @@ import (
 	"github.com/pkg/errors"
 )
 
+// headerSize is the size of length header prepended to data, so that padding can be trimmed exactly.
+const headerSize = 8
+
+// maxShards is the most shards Reed-Solomon codes over GF(2^8) can have.
+const maxShards = 256
+
+// DataShards returns the number of data shards of t shards tolerating f byzantine peers, which is t - 2f. It returns
+// error unless t >= 3f + 1, so that there are at least f + 1 data shards and the t - f shards of correct peers can
+// reconstruct data even if f of them are yet to arrive.
+func DataShards(f, t int) (int, error) {
+	if f < 0 {
+		return 0, errors.Errorf("byzantine limit %d is negative", f)
+	}
+	if t < 3*f+1 {
+		return 0, errors.Errorf("%d shards can't tolerate %d byzantine peers, at least %d are needed", t, f, 3*f+1)
+	}
+	if t > maxShards {
+		return 0, errors.Errorf("%d shards are more than %d", t, maxShards)
+	}
+	return t - 2*f, nil
+}
+
+// Split encodes data into t shards, any t - 2f of them can reconstruct data.
 func Split(data []byte, f, t int) ([][]byte, error) {
-	enc, err := reedsolomon.New(t-2*f, 2*f)
+	dataShards, err := DataShards(f, t)
 	if err != nil {
 		return nil, err
 	}
-	shards, err := enc.Split(data)
+	enc, err := reedsolomon.New(dataShards, t-dataShards)
+	if err != nil {
+		return nil, err
+	}
+	framed := make([]byte, headerSize+len(data))
+	binary.BigEndian.PutUint64(framed, uint64(len(data)))
+	copy(framed[headerSize:], data)
+	shards, err := enc.Split(framed)
 	if err != nil {
 		return nil, err
 	}
@@ -35,7 +66,11 @@ func Reconstruct(payloads []*pb.Payload, f, t int) ([]byte, error) {
 }
 
 func ReconstructBytes(shards [][]byte, f int) ([]byte, error) {
-	enc, err := reedsolomon.New(len(shards)-2*f, 2*f)
+	dataShards, err := DataShards(f, len(shards))
+	if err != nil {
+		return nil, err
+	}
+	enc, err := reedsolomon.New(dataShards, len(shards)-dataShards)
 	if err != nil {
 		return nil, err
 	}
@@ -43,16 +78,16 @@ func ReconstructBytes(shards [][]byte, f int) ([]byte, error) {
 		return nil, errors.Wrap(err, "Failed to reconstruct the data")
 	}
 	res := new(bytes.Buffer)
-	if err := enc.Join(res, shards, len(shards[0])*(len(shards)-2*f)); err != nil {
+	if err := enc.Join(res, shards, len(shards[0])*dataShards); err != nil {
 		return nil, errors.Wrap(err, "Failed to concat the data")
 	}
-	trim := res.Bytes()
-	for {
-		if trim[len(trim)-1] == byte(0) {
-			trim = trim[:len(trim)-1]
-		} else {
-			break
-		}
+	framed := res.Bytes()
+	if len(framed) < headerSize {
+		return nil, errors.New("Data is shorter than its header")
+	}
+	size := binary.BigEndian.Uint64(framed)
+	if size > uint64(len(framed)-headerSize) {
+		return nil, errors.New("Data is shorter than its header claims")
 	}
-	return trim, nil
+	return framed[headerSize : headerSize+size], nil
 }
@@ -1,6 +1,7 @@
 package erasure_test
 
 import (
+	"encoding/binary"
 	"math/rand"
 	"testing"
 
@@ -21,7 +22,7 @@ func testData() []byte {
 }
 
 func TestSplit(t *testing.T) {
-	f := rand.Intn(5)
+	f := rand.Intn(4) + 1
 	n := 3*f + 1
 	shards, err := erasure.Split(testbytes, f, n)
 	assert.Nil(t, err)
@@ -30,18 +31,14 @@ func TestSplit(t *testing.T) {
 	for i := 0; i < n-2*f; i++ {
 		data = append(data, shards[i]...)
 	}
-	for {
-		if data[len(data)-1] == byte(0) {
-			data = data[:len(data)-1]
-		} else {
-			break
-		}
-	}
-	assert.Equal(t, testbytes, data)
+	// Data shards are the length header, data and padding.
+	size := int(binary.BigEndian.Uint64(data))
+	assert.Equal(t, len(testbytes), size)
+	assert.Equal(t, testbytes, data[8:8+size])
 }
 
 func TestReconstruct(t *testing.T) {
-	f := rand.Intn(5)
+	f := rand.Intn(4) + 1
 	n := 3*f + 1
 	shards, err := erasure.Split(testbytes, f, n)
 	assert.Nil(t, err)
@@ -59,3 +56,40 @@ func TestReconstruct(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, testbytes, data)
 }
+
+func TestReconstruct_TrailingZeros(t *testing.T) {
+	data := append(testData(), 0, 0)
+	shards, err := erasure.Split(data, 1, 4)
+	assert.Nil(t, err)
+	shards[0], shards[3] = nil, nil
+	res, err := erasure.ReconstructBytes(shards, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, data, res)
+}
+
+func TestReconstruct_ClusterSizes(t *testing.T) {
+	for _, c := range []struct{ n, f int }{{5, 1}, {7, 2}, {10, 3}} {
+		dataShards, err := erasure.DataShards(c.f, c.n)
+		assert.Nil(t, err)
+		assert.Equal(t, c.n-2*c.f, dataShards)
+		shards, err := erasure.Split(testbytes, c.f, c.n)
+		assert.Nil(t, err)
+		assert.Equal(t, c.n, len(shards))
+		// Any N - 2f shards are enough.
+		for _, i := range rand.Perm(c.n)[:2*c.f] {
+			shards[i] = nil
+		}
+		data, err := erasure.ReconstructBytes(shards, c.f)
+		assert.Nil(t, err, "n %d, f %d", c.n, c.f)
+		assert.Equal(t, testbytes, data)
+	}
+}
+
+func TestDataShards_Invalid(t *testing.T) {
+	for _, c := range []struct{ n, f int }{{3, 1}, {6, 2}, {0, 0}, {4, -1}, {300, 1}} {
+		_, err := erasure.DataShards(c.f, c.n)
+		assert.Error(t, err, "n %d, f %d", c.n, c.f)
+		_, err = erasure.Split(testbytes, c.f, c.n)
+		assert.Error(t, err, "n %d, f %d", c.n, c.f)
+	}
+}
@@ -2,8 +2,7 @@ package leader
 
 import (
 	"encoding/hex"
-	"math"
-	"time"
+	"sync/atomic"
 
 	"github.com/gopricy/mao-bft/pb"
 	"github.com/gopricy/mao-bft/rbc/common"
@@ -15,6 +14,9 @@ import (
 type Leader struct {
 	name string
 	common.Common
+	// sequence is the sequence number of last broadcast. It starts from the time of the first broadcast, so that a
+	// restarted leader never reuses an instance.
+	sequence int64
 }
 
 func NewLeader(name string, app common.Application, faultLimit int, peers map[string]*common.Peer, privateKey *[64]byte) *Leader {
@@ -22,21 +24,50 @@ func NewLeader(name string, app common.Application, faultLimit int, peers map[st
 	return &Leader{Common: common.NewCommon(name, setting, app, privateKey)}
 }
 
+// NewReplica creates a leader for view change mode, it only broadcasts when it leads the current view.
+func NewReplica(name string, app common.Application, setting common.RBCSetting, privateKey *[64]byte) *Leader {
+	return &Leader{Common: common.NewCommon(name, setting, app, privateKey)}
+}
+
+// nextInstance returns the instance of a new broadcast.
+func (l *Leader) nextInstance() common.Instance {
+	atomic.CompareAndSwapInt64(&l.sequence, 0, l.Clock.Now().UnixNano())
+	return common.Instance{
+		Broadcaster: l.Name(),
+		Epoch:       l.CurrentView(),
+		Sequence:    atomic.AddInt64(&l.sequence, 1),
+	}
+}
+
 func (l *Leader) RBCSend(bytes []byte) {
+	if l.ViewChangeEnabled() && !l.IsLeader() {
+		l.Infof("Not leader of current view, skip broadcast")
+		return
+	}
 	block, err := mao_utils.DecodeBlock(bytes)
 	if err != nil {
 		panic(err)
 	}
+	// Small blocks are cheaper to send whole than as shards with Merkle proofs.
+	if l.Replicated(bytes) {
+		instance := l.nextInstance()
+		l.Debugf("Broadcast instance %s in replicated mode", instance)
+		l.BroadcastReplicated(instance, block.Content.PrevHash, bytes)
+		return
+	}
 
 	splits := [][]byte{}
 	l.Debugf("Split data into %d shards with any %d shards can reconstruct data",
-		len(l.AllPeers), len(l.AllPeers)-2*l.ByzantineLimit)
+		len(l.AllPeers), l.DataShards())
 
 	splits, err = erasure.Split(bytes, l.ByzantineLimit, len(l.AllPeers))
 	if err != nil {
 		panic(err)
 	}
 
+	instance := l.nextInstance()
+	l.Debugf("Broadcast instance %s", instance)
+
 	var contents []merkle.Content
 	for _, s := range splits {
 		contents = append(contents, merkle.BytesContent(s))
@@ -47,59 +78,26 @@ func (l *Leader) RBCSend(bytes []byte) {
 		panic(err)
 	}
 
-	i := 0
-	for _, p := range l.AllPeers {
-		switch l.Mode {
-		case 1:
-			l.Infof(`Byzantine Mode 1(send the same data shard to all peers): PREPARE "%.4s" to %#v`, hex.EncodeToString(splits[0]), p)
-			proof, err := merkle.GetProof(merkleTree, contents[0])
-			if err != nil {
-				panic(err)
-			}
-			l.SendPrepare(p, proof, block.Content.PrevHash, splits[0])
-		default:
-			l.Debugf(`Send PREPARE "%.4s" to %#v`, hex.EncodeToString(splits[i]), p)
-			proof, err := merkle.GetProof(merkleTree, contents[i])
-			if err != nil {
-				panic(err)
-			}
-			l.SendPrepare(p, proof, block.Content.PrevHash, splits[i])
+	for _, p := range l.Peers() {
+		// Each peer gets the shard of its own slot, peers check it when echoing.
+		i := l.ShardIndex(p.Name)
+		l.Debugf(`Send PREPARE "%.4s" to %#v`, hex.EncodeToString(splits[i]), p)
+		proof, err := merkle.GetProofAt(merkleTree, i)
+		if err != nil {
+			panic(err)
 		}
-		i++
+		l.SendPrepare(p, instance, proof, block.Content.PrevHash, splits[i])
 	}
 }
 
-func (l *Leader) SendPrepare(p *common.Peer, merkleProof *pb.MerkleProof, prevHash []byte, data []byte) {
+func (l *Leader) SendPrepare(p *common.Peer, instance common.Instance, merkleProof *pb.MerkleProof, prevHash []byte, data []byte) {
 
 	payload := &pb.Payload{
 		MerkleProof: merkleProof,
 		PrevHash:    prevHash,
-		Data:        l.Sign(data),
+		Data:        data,
+		Instance:    instance.Pb(),
 	}
-	if l.Mode == 2 {
-		l.Infof(`Byzantine Mode 2(send data without signature)`)
-		payload.Data = data
-	}
-
-	go func() {
-		retry := 0
-		for {
-			_, err := pb.NewPrepareClient(p.GetConn()).Prepare(l.CreateContext(), payload)
-			if err == nil {
-				break
-			}
-			if l.Mode == 4 {
-				retry++
-				if retry > 10 {
-					retry = 10
-				}
-				l.Debugf("SendPreapre failed. Wait for %d to reconnect.", int(math.Pow(2, float64(retry))))
-				time.Sleep(time.Second * time.Duration(int(math.Pow(2, float64(retry)))))
-			}
-		}
-	}()
-	// _, err := pb.NewPrepareClient(p.GetConn()).Prepare(l.CreateContext(), payload)
-	// if err != nil {
-	// 	panic(err)
-	// }
+	payload.Signature = l.SignEnvelope(common.PayloadEnvelope(pb.MessageType_MT_PREPARE, payload))
+	l.SendMessage(p, common.KindPrepare, payload)
 }
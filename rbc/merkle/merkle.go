@@ -45,7 +45,8 @@ func getNodeSibling(node *Node) (*Node, bool, error) {
 		return nil, true, errors.New("parent doesn't have sibling")
 	}
 	parent := node.Parent
-	if mao_utils.IsSameBytes(parent.Left.Hash, node.Hash) {
+	// Nodes are compared by identity, leaves with the same content have the same hash.
+	if parent.Left == node {
 		return parent.Right, false, nil
 	}
 	return parent.Left, true, nil
@@ -74,6 +75,19 @@ func (tree *MerkleTree) Init(contents []Content) error {
 	return nil
 }
 
+// RootOfBytes returns the root of Merkle tree whose leaves are given byte slices, in order.
+func RootOfBytes(leaves [][]byte) ([]byte, error) {
+	var contents []Content
+	for _, l := range leaves {
+		contents = append(contents, BytesContent(l))
+	}
+	tree := &MerkleTree{}
+	if err := tree.Init(contents); err != nil {
+		return nil, err
+	}
+	return tree.Root.Hash, nil
+}
+
 // buildTree returns a root
 func buildTree(nodes []*Node) (Node, error) {
 	if len(nodes) == 1 {
@@ -119,6 +133,14 @@ func GetProof(tree *MerkleTree, content Content) (*pb.MerkleProof, error) {
 	return &pb.MerkleProof{}, errors.New("does not find the content in tree: " + content.String())
 }
 
+// GetProofAt returns a MerkleProof for the leaf at index, unlike GetProof it works with leaves of the same content.
+func GetProofAt(tree *MerkleTree, index int) (*pb.MerkleProof, error) {
+	if index < 0 || index >= len(tree.Leaves) {
+		return nil, errors.New("leaf index is out of range")
+	}
+	return computeMerkleProofFromLeaf(tree.Leaves[index], tree.Root)
+}
+
 func computeMerkleProofFromLeaf(node *Node, root *Node) (*pb.MerkleProof, error) {
 	if root.Parent != nil {
 		return nil, errors.New("root is invalid, it contains parent")
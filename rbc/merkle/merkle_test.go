@@ -182,4 +182,18 @@ func TestGetLeafIndexSingleNode(t *testing.T) {
 	// Test 'a'
 	aProof, _ := GetProof(&tree, &testContent{x: "a"})
 	assert.Equal(t, GetLeafIndex(aProof), 0)
-}
\ No newline at end of file
+}
+
+func TestGetProofAtSameContent(t *testing.T) {
+	contents := []Content{&testContent{x: "a"}, &testContent{x: "a"}, &testContent{x: "a"}}
+	tree := MerkleTree{}
+	assert.Nil(t, tree.Init(contents))
+	for i := range contents {
+		proof, err := GetProofAt(&tree, i)
+		assert.Nil(t, err)
+		assert.Equal(t, i, GetLeafIndex(proof))
+		assert.True(t, VerifyProof(proof, contents[i]))
+	}
+	_, err := GetProofAt(&tree, 3)
+	assert.NotNil(t, err)
+}
@@ -6,7 +6,8 @@ import (
 	"testing"
 
 	"github.com/fatih/color"
-	"github.com/gopricy/mao-bft/pb"
+	"github.com/google/uuid"
+	"github.com/gopricy/mao-bft/rbc/ca"
 	"github.com/gopricy/mao-bft/rbc/common"
 	"github.com/gopricy/mao-bft/rbc/follower"
 	"github.com/gopricy/mao-bft/rbc/leader"
@@ -19,9 +20,23 @@ import (
 const leaderPort = 8010
 const address = "127.0.0.1"
 
-func InitPeers(byzantineLimit int) (rbcSetting common.RBCSetting, allPrivateKeys []*[64]byte, connCloser func() error) {
+// clientPortOffset is the distance of the client port of a peer from its port, see InitTLS.
+const clientPortOffset = 100
+
+func InitPeers(byzantineLimit int) (rbcSetting common.RBCSetting, allPrivateKeys []*[64]byte) {
+	rbcSetting, allPrivateKeys, err := InitCluster(3*byzantineLimit+1, byzantineLimit)
+	if err != nil {
+		panic(err)
+	}
+	return
+}
+
+// InitCluster is InitPeers with n peers, a leader and n - 1 followers, tolerating byzantineLimit byzantine peers. It
+// returns error unless n >= 3 * byzantineLimit + 1.
+func InitCluster(n, byzantineLimit int) (rbcSetting common.RBCSetting, allPrivateKeys []*[64]byte, err error) {
 	rbcSetting.ByzantineLimit = byzantineLimit
-	followerNum := byzantineLimit * 3
+	rbcSetting.ClusterID = uuid.New().String()
+	followerNum := n - 1
 	pub, priv := sign.GenerateKey()
 	rbcSetting.AllPeers = make(map[string]*common.Peer)
 	rbcSetting.AllPeers["mao"] = &common.Peer{Name: "mao", PORT: leaderPort, IP: address, PubKey: pub}
@@ -32,15 +47,28 @@ func InitPeers(byzantineLimit int) (rbcSetting common.RBCSetting, allPrivateKeys
 		rbcSetting.AllPeers[name] = &common.Peer{Name: fmt.Sprintf("f%d", i+1), PORT: leaderPort + 1 + i, IP: address, PubKey: pub}
 		allPrivateKeys = append(allPrivateKeys, priv)
 	}
-	connCloser = func() error {
-		for _, p := range rbcSetting.AllPeers {
-			if err := p.CONN.Close(); err != nil {
-				return err
-			}
+	err = rbcSetting.Validate()
+	return
+}
+
+// InitTLS issues certificates of all peers in rbcSetting by a new CA, it returns TLS identity of each peer.
+func InitTLS(rbcSetting *common.RBCSetting) (map[string]common.TLSIdentity, error) {
+	authority, err := ca.New()
+	if err != nil {
+		return nil, err
+	}
+	rbcSetting.CACert = authority.CertPEM
+	identities := make(map[string]common.TLSIdentity)
+	for name, p := range rbcSetting.AllPeers {
+		cert, key, err := authority.Issue(name)
+		if err != nil {
+			return nil, err
 		}
-		return nil
+		p.Cert = cert
+		p.ClientPORT = p.PORT + clientPortOffset
+		identities[name] = common.TLSIdentity{Cert: cert, Key: key}
 	}
-	return
+	return identities, nil
 }
 
 func StartFollowers(t *testing.T, apps []common.Application, privKeys []*[64]byte, rs common.RBCSetting, g *errgroup.Group) (stoppers []func()) {
@@ -58,20 +86,38 @@ func StartFollowers(t *testing.T, apps []common.Application, privKeys []*[64]byt
 
 // if g is provided, it is a nonblocking call. if g is nil, it is a blocking call
 func NewFollower(app common.Application, index int, privKey sign.PrivateKey, rs common.RBCSetting, g *errgroup.Group) (error, func()) {
+	return newFollower(app, index, privKey, nil, rs, g)
+}
+
+// NewTLSFollower is NewFollower with mutual TLS, rs should be initialized by InitTLS.
+func NewTLSFollower(app common.Application, index int, privKey sign.PrivateKey, identity common.TLSIdentity,
+	rs common.RBCSetting, g *errgroup.Group) (error, func()) {
+	return newFollower(app, index, privKey, &identity, rs, g)
+}
+
+func newFollower(app common.Application, index int, privKey sign.PrivateKey, identity *common.TLSIdentity,
+	rs common.RBCSetting, g *errgroup.Group) (error, func()) {
 	name := fmt.Sprintf("f%d", index)
 	p := rs.AllPeers[name].PORT
 	f := follower.NewFollower(name, app, rs.ByzantineLimit, rs.AllPeers, privKey)
+	f.RBCSetting = rs
+	if identity != nil {
+		if err := f.EnableTLS(*identity); err != nil {
+			return err, func() {}
+		}
+	}
 	lis, err := net.Listen("tcp", fmt.Sprintf("%s:%d", address, p))
 
 	if err != nil {
 		return err, func() {}
 	}
-	s := grpc.NewServer()
+	s := grpc.NewServer(f.ServerOptions()...)
 
-	pb.RegisterReadyServer(s, f)
-	pb.RegisterEchoServer(s, f)
-	pb.RegisterPrepareServer(s, f)
-	pb.RegisterSyncServer(s, f)
+	common.RegisterNode(s, f)
+	stopClients, err := serveClients(f, f.ClientServerOptions(), rs.AllPeers[name])
+	if err != nil {
+		return err, func() {}
+	}
 	if g == nil {
 		f.Debugf(color.CyanString("Follower %d starts to listen on %s:%d", index, address, p))
 		err = s.Serve(lis)
@@ -81,7 +127,12 @@ func NewFollower(app common.Application, index int, privKey sign.PrivateKey, rs
 	g.Go(func() error {
 		return s.Serve(lis)
 	})
-	return nil, s.GracefulStop
+	return nil, func() {
+		// Streams of peers end once the node stops, so that the server can stop gracefully.
+		f.Stop()
+		stopClients()
+		s.GracefulStop()
+	}
 }
 
 func StartLeader(t *testing.T, app common.Application, privKey sign.PrivateKey, rs common.RBCSetting,
@@ -94,17 +145,35 @@ func StartLeader(t *testing.T, app common.Application, privKey sign.PrivateKey,
 // if g is provided, it is a nonblocking call. if g is nil, it is a blocking call
 func NewLeader(app common.Application, privKey sign.PrivateKey, rs common.RBCSetting, g *errgroup.Group) (
 	mao *leader.Leader, stopper func(), err error) {
+	return newLeader(app, privKey, nil, rs, g)
+}
+
+// NewTLSLeader is NewLeader with mutual TLS, rs should be initialized by InitTLS.
+func NewTLSLeader(app common.Application, privKey sign.PrivateKey, identity common.TLSIdentity, rs common.RBCSetting,
+	g *errgroup.Group) (mao *leader.Leader, stopper func(), err error) {
+	return newLeader(app, privKey, &identity, rs, g)
+}
+
+func newLeader(app common.Application, privKey sign.PrivateKey, identity *common.TLSIdentity, rs common.RBCSetting,
+	g *errgroup.Group) (mao *leader.Leader, stopper func(), err error) {
 	l := leader.NewLeader("mao", app, rs.ByzantineLimit, rs.AllPeers, privKey)
+	l.RBCSetting = rs
+	if identity != nil {
+		if err := l.EnableTLS(*identity); err != nil {
+			return nil, func() {}, err
+		}
+	}
 	lis, err := net.Listen("tcp", fmt.Sprintf("%s:%d", address, leaderPort))
 	if err != nil {
 		return nil, func() {}, err
 	}
-	s := grpc.NewServer()
+	s := grpc.NewServer(l.ServerOptions()...)
 
-	pb.RegisterEchoServer(s, l)
-	pb.RegisterReadyServer(s, l)
-	pb.RegisterPrepareServer(s, l)
-	pb.RegisterSyncServer(s, l)
+	common.RegisterNode(s, l)
+	stopClients, err := serveClients(l, l.ClientServerOptions(), rs.AllPeers[l.Name()])
+	if err != nil {
+		return nil, func() {}, err
+	}
 	l.Debugf("RBC Leader starts to listen on %s:%d", address, leaderPort)
 	if g == nil {
 		err = s.Serve(lis)
@@ -113,6 +182,85 @@ func NewLeader(app common.Application, privKey sign.PrivateKey, rs common.RBCSet
 	g.Go(func() error {
 		return s.Serve(lis)
 	})
-	return l, s.GracefulStop, nil
+	return l, func() {
+		// Streams of peers end once the node stops, so that the server can stop gracefully.
+		l.Stop()
+		stopClients()
+		s.GracefulStop()
+	}, nil
+
+}
+
+// serveClients serves clients of node on ClientPORT of p in the background, it returns the stopper of the server.
+// Nothing is served if p has no ClientPORT, clients then connect to PORT.
+func serveClients(node common.Node, options []grpc.ServerOption, p *common.Peer) (func(), error) {
+	if p.ClientPORT == 0 {
+		return func() {}, nil
+	}
+	lis, err := net.Listen("tcp", fmt.Sprintf("%s:%d", address, p.ClientPORT))
+	if err != nil {
+		return func() {}, err
+	}
+	s := grpc.NewServer(options...)
+	common.RegisterClientService(s, node)
+	go s.Serve(lis)
+	return s.GracefulStop, nil
+}
+
+// NewReplica starts a node in view change mode, rs should have positive ViewChangeTimeout.
+// if g is provided, it is a nonblocking call. if g is nil, it is a blocking call
+func NewReplica(app common.Application, name string, privKey sign.PrivateKey, rs common.RBCSetting, g *errgroup.Group) (
+	replica *leader.Leader, stopper func(), err error) {
+	r := leader.NewReplica(name, app, rs, privKey)
+	p := rs.AllPeers[name].PORT
+	lis, err := net.Listen("tcp", fmt.Sprintf("%s:%d", address, p))
+	if err != nil {
+		return nil, func() {}, err
+	}
+	s := grpc.NewServer(r.ServerOptions()...)
+
+	common.RegisterNode(s, r)
+	r.Debugf("RBC Replica starts to listen on %s:%d", address, p)
+	if g == nil {
+		err = s.Serve(lis)
+		return r, func() {}, err
+	}
+	g.Go(func() error {
+		return s.Serve(lis)
+	})
+	return r, func() {
+		// Streams of peers end once the node stops, so that the server can stop gracefully.
+		r.Stop()
+		s.GracefulStop()
+	}, nil
+}
+
+// NewMemoryFollower creates a follower that talks to peers through network, it's stopped by detaching it.
+func NewMemoryFollower(app common.Application, index int, privKey sign.PrivateKey, rs common.RBCSetting,
+	network *common.MemoryNetwork) *follower.Follower {
+	name := fmt.Sprintf("f%d", index)
+	f := follower.NewFollower(name, app, rs.ByzantineLimit, rs.AllPeers, privKey)
+	f.RBCSetting = rs
+	f.Transport = network.Endpoint(name)
+	network.Attach(name, f)
+	return f
+}
+
+// NewMemoryLeader creates a leader that talks to peers through network, it's stopped by detaching it.
+func NewMemoryLeader(app common.Application, privKey sign.PrivateKey, rs common.RBCSetting,
+	network *common.MemoryNetwork) *leader.Leader {
+	l := leader.NewLeader("mao", app, rs.ByzantineLimit, rs.AllPeers, privKey)
+	l.RBCSetting = rs
+	l.Transport = network.Endpoint("mao")
+	network.Attach("mao", l)
+	return l
+}
 
+// NewMemoryReplica creates a node in view change mode that talks to peers through network.
+func NewMemoryReplica(app common.Application, name string, privKey sign.PrivateKey, rs common.RBCSetting,
+	network *common.MemoryNetwork) *leader.Leader {
+	r := leader.NewReplica(name, app, rs, privKey)
+	r.Transport = network.Endpoint(name)
+	network.Attach(name, r)
+	return r
 }
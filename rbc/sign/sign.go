@@ -1,6 +1,8 @@
 package sign
 
 import (
+	"io"
+
 	inner "golang.org/x/crypto/nacl/sign"
 )
 
@@ -15,6 +17,15 @@ func GenerateKey() (PublicKey, PrivateKey){
 	return pub, prv
 }
 
+// GenerateKeyFrom generates a key pair from rand, the same rand gives the same key pair.
+func GenerateKeyFrom(rand io.Reader) (PublicKey, PrivateKey) {
+	pub, prv, err := inner.GenerateKey(rand)
+	if err != nil {
+		panic(err)
+	}
+	return pub, prv
+}
+
 func Sign(privateKey PrivateKey, message []byte) []byte{
 	return inner.Sign(nil, message, privateKey)
 }
@@ -22,3 +33,20 @@ func Sign(privateKey PrivateKey, message []byte) []byte{
 func Verify(publicKey PublicKey, message []byte)(decoded []byte, verified bool){
 	return inner.Open(nil, message, publicKey)
 }
+
+// SignatureSize is the size of a detached signature.
+const SignatureSize = inner.Overhead
+
+// SignDetached returns signature of message without the message.
+func SignDetached(privateKey PrivateKey, message []byte) []byte {
+	return Sign(privateKey, message)[:SignatureSize]
+}
+
+// VerifyDetached checks signature of message returned by SignDetached.
+func VerifyDetached(publicKey PublicKey, message []byte, signature []byte) bool {
+	if len(signature) != SignatureSize {
+		return false
+	}
+	_, verified := inner.Open(nil, append(append([]byte{}, signature...), message...), publicKey)
+	return verified
+}
@@ -26,3 +26,12 @@ func TestVerify(t *testing.T){
 	_, verified = Verify(testPubKey, wrongSignedMessage)
 	assert.False(t, verified)
 }
+
+func TestVerifyDetached(t *testing.T) {
+	signature := SignDetached(testPrivateKey, []byte(testMessage))
+	assert.Equal(t, SignatureSize, len(signature))
+	assert.True(t, VerifyDetached(testPubKey, []byte(testMessage), signature))
+	assert.False(t, VerifyDetached(testPubKey, []byte("Tess"), signature))
+	assert.False(t, VerifyDetached(testPubKey, []byte(testMessage), SignDetached(testWrongKey, []byte(testMessage))))
+	assert.False(t, VerifyDetached(testPubKey, []byte(testMessage), signature[1:]))
+}
@@ -0,0 +1,308 @@
+package sim
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gopricy/mao-bft/rbc/adversary"
+	"github.com/gopricy/mao-bft/rbc/common"
+	mao_utils "github.com/gopricy/mao-bft/utils"
+)
+
+// Crash is the strategy of a faulty node that crashes before anything happens.
+const Crash = "crash"
+
+// Fault is how a faulty node behaves.
+type Fault struct {
+	// Strategy is Crash or the name of a built-in adversary strategy.
+	Strategy string
+	// Targets are the peers Strategy acts on, it's all peers if there's none.
+	Targets []string
+}
+
+// Scenario is an execution of RBC, the leader broadcasts a chain of Blocks blocks while faulty nodes misbehave.
+type Scenario struct {
+	Config
+	Blocks int
+	// Interval is the time between two broadcasts.
+	Interval time.Duration
+	// Faulty maps each faulty node to its fault, there are at most ByzantineLimit of them.
+	Faulty map[string]Fault
+}
+
+// RandomScenario returns a scenario drawn from r with up to 2 faults tolerated.
+func RandomScenario(r *rand.Rand) Scenario {
+	sc := Scenario{
+		Config: Config{
+			Seed:           r.Int63(),
+			ByzantineLimit: 1 + r.Intn(2),
+			MinLatency:     time.Duration(r.Intn(10)) * time.Millisecond,
+			Reorder:        r.Intn(2) == 0,
+		},
+		Blocks:   1 + r.Intn(4),
+		Interval: time.Duration(r.Intn(50)) * time.Millisecond,
+		Faulty:   make(map[string]Fault),
+	}
+	sc.MaxLatency = sc.MinLatency + time.Duration(r.Intn(200))*time.Millisecond
+	if r.Intn(5) == 0 {
+		sc.Loss = r.Float64() / 10
+	}
+	// Blocks of the simulation are small, so they're all replicated.
+	if r.Intn(2) == 0 {
+		sc.ReplicatedLimit = 1 << 20
+	}
+	// Larger clusters than 3f + 1 have quorums that aren't fractions of N.
+	if r.Intn(3) == 0 {
+		sc.Nodes = 3*sc.ByzantineLimit + 2 + r.Intn(3)
+	}
+	names := New(sc.Config).Names()
+	strategies := []string{Crash}
+	for _, name := range adversary.Names() {
+		if name != "honest" {
+			strategies = append(strategies, name)
+		}
+	}
+	for _, i := range r.Perm(len(names))[:r.Intn(sc.ByzantineLimit+1)] {
+		fault := Fault{Strategy: strategies[r.Intn(len(strategies))]}
+		for _, j := range r.Perm(len(names))[:r.Intn(len(names))] {
+			fault.Targets = append(fault.Targets, names[j])
+		}
+		sort.Strings(fault.Targets)
+		sc.Faulty[names[i]] = fault
+	}
+	return sc
+}
+
+func (sc Scenario) String() string {
+	var faults []string
+	for name, fault := range sc.Faulty {
+		faults = append(faults, fmt.Sprintf("%s: %s %v", name, fault.Strategy, fault.Targets))
+	}
+	sort.Strings(faults)
+	return fmt.Sprintf("seed %d, n %d, f %d, latency %s-%s, loss %.3f, reorder %t, replicated limit %d, "+
+		"%d blocks every %s, faulty {%s}",
+		sc.Seed, sc.N(), sc.ByzantineLimit, sc.MinLatency, sc.MaxLatency, sc.Loss, sc.Reorder, sc.ReplicatedLimit,
+		sc.Blocks, sc.Interval, strings.Join(faults, ", "))
+}
+
+// Violation is a RBC property that an execution breaks.
+type Violation struct {
+	Property string
+	Detail   string
+}
+
+func (v Violation) String() string {
+	return v.Property + ": " + v.Detail
+}
+
+// Run runs sc to the end, it returns the simulation and the blocks broadcast.
+func (sc Scenario) Run() (*Simulation, [][]byte) {
+	s := New(sc.Config)
+	for name, fault := range sc.Faulty {
+		if fault.Strategy == Crash {
+			s.Crash(name)
+			continue
+		}
+		a, err := adversary.New(fault.Strategy, fault.Targets...)
+		if err != nil {
+			panic(err)
+		}
+		s.Node(name).SetAdversary(a)
+	}
+	var broadcast [][]byte
+	for _, block := range s.Chain(sc.Blocks, sc.Interval) {
+		bytes, err := mao_utils.EncodeBlock(block)
+		if err != nil {
+			panic(err)
+		}
+		broadcast = append(broadcast, bytes)
+	}
+	s.Run(0)
+	return s, broadcast
+}
+
+// Check runs sc and returns the RBC properties it breaks.
+func (sc Scenario) Check() []Violation {
+	s, broadcast := sc.Run()
+	delivered := make(map[string][]common.Delivery)
+	for _, name := range s.Names() {
+		delivered[name] = s.Deliveries(name)
+	}
+	return sc.check(delivered, broadcast)
+}
+
+// check returns the RBC properties broken by delivered of each node, when the leader broadcasts broadcast:
+//   - Integrity: a correct node delivers each instance at most once, and only what a correct leader broadcasts.
+//   - Agreement: correct nodes that deliver an instance deliver the same data.
+//   - Fork freedom: correct nodes deliver at most one block on each parent, across all instances and epochs.
+//   - Totality: once a correct node delivers an instance, all correct nodes deliver it.
+//   - Validity: all correct nodes deliver everything a correct leader broadcasts.
+//
+// Totality and validity are only checked if no message is lost, lost messages are never resent in simulation.
+func (sc Scenario) check(delivered map[string][]common.Delivery, broadcast [][]byte) []Violation {
+	var res []Violation
+	violate := func(property, format string, args ...interface{}) {
+		res = append(res, Violation{Property: property, Detail: fmt.Sprintf(format, args...)})
+	}
+	var correct []string
+	for name := range delivered {
+		if _, ok := sc.Faulty[name]; !ok {
+			correct = append(correct, name)
+		}
+	}
+	sort.Strings(correct)
+	_, faultyLeader := sc.Faulty[LeaderName]
+
+	// data maps each instance to what the first correct node delivered.
+	data := make(map[common.Instance][]byte)
+	by := make(map[common.Instance]string)
+	for _, name := range correct {
+		seen := make(map[common.Instance]bool)
+		for _, d := range delivered[name] {
+			if seen[d.Instance] {
+				violate("integrity", "%s delivers %s twice", name, d.Instance)
+			}
+			seen[d.Instance] = true
+			if !faultyLeader && !contains(broadcast, d.Data) {
+				violate("integrity", "%s delivers %s which is never broadcast", name, d.Instance)
+			}
+			if first, ok := data[d.Instance]; !ok {
+				data[d.Instance], by[d.Instance] = d.Data, name
+			} else if !bytes.Equal(first, d.Data) {
+				violate("agreement", "%s and %s deliver different data in %s", by[d.Instance], name, d.Instance)
+			}
+		}
+	}
+
+	// parents maps each parent to the first block that a correct node delivered on it.
+	parents := make(map[string]common.Delivery)
+	forked := make(map[string]bool)
+	for _, name := range correct {
+		for _, d := range delivered[name] {
+			block, err := mao_utils.DecodeBlock(d.Data)
+			if err != nil || block.Content == nil {
+				continue
+			}
+			parent := string(block.Content.PrevHash)
+			first, ok := parents[parent]
+			if !ok {
+				parents[parent] = d
+				continue
+			}
+			if !bytes.Equal(first.Data, d.Data) && !forked[parent] {
+				forked[parent] = true
+				violate("fork", "%s and %s are delivered on the same parent", first.Instance, d.Instance)
+			}
+		}
+	}
+	if sc.Loss > 0 {
+		return res
+	}
+
+	for _, name := range correct {
+		seen := make(map[common.Instance]bool)
+		for _, d := range delivered[name] {
+			seen[d.Instance] = true
+		}
+		for instance := range data {
+			if !seen[instance] {
+				violate("totality", "%s delivers %s but %s doesn't", by[instance], instance, name)
+			}
+		}
+		if faultyLeader {
+			continue
+		}
+		for i, b := range broadcast {
+			found := false
+			for _, d := range delivered[name] {
+				found = found || bytes.Equal(d.Data, b)
+			}
+			if !found {
+				violate("validity", "%s never delivers block %d", name, i)
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].String() < res[j].String() })
+	return res
+}
+
+func contains(all [][]byte, b []byte) bool {
+	for _, a := range all {
+		if bytes.Equal(a, b) {
+			return true
+		}
+	}
+	return false
+}
+
+// Shrink returns the simplest scenario derived from sc that still fails, by dropping faults and blocks and calming
+// the network one step at a time.
+func Shrink(sc Scenario, fails func(Scenario) bool) Scenario {
+	for {
+		simpler := false
+		for _, candidate := range sc.simplifications() {
+			if fails(candidate) {
+				sc, simpler = candidate, true
+				break
+			}
+		}
+		if !simpler {
+			return sc
+		}
+	}
+}
+
+// simplifications returns scenarios that are one step simpler than sc.
+func (sc Scenario) simplifications() []Scenario {
+	var res []Scenario
+	var names []string
+	for name := range sc.Faulty {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		candidate := sc.withFaulty()
+		delete(candidate.Faulty, name)
+		res = append(res, candidate)
+	}
+	if sc.Blocks > 1 {
+		candidate := sc.withFaulty()
+		candidate.Blocks--
+		res = append(res, candidate)
+	}
+	if sc.Loss > 0 {
+		candidate := sc.withFaulty()
+		candidate.Loss = 0
+		res = append(res, candidate)
+	}
+	if sc.Reorder {
+		candidate := sc.withFaulty()
+		candidate.Reorder = false
+		res = append(res, candidate)
+	}
+	if sc.MaxLatency > sc.MinLatency {
+		candidate := sc.withFaulty()
+		candidate.MaxLatency = sc.MinLatency
+		res = append(res, candidate)
+	}
+	if sc.Interval > 0 {
+		candidate := sc.withFaulty()
+		candidate.Interval = 0
+		res = append(res, candidate)
+	}
+	return res
+}
+
+// withFaulty returns a copy of sc that doesn't share Faulty with it.
+func (sc Scenario) withFaulty() Scenario {
+	faulty := make(map[string]Fault)
+	for name, fault := range sc.Faulty {
+		faulty[name] = fault
+	}
+	sc.Faulty = faulty
+	return sc
+}
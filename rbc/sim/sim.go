@@ -0,0 +1,542 @@
+// Package sim runs a RBC cluster over a simulated network in virtual time. A simulation is single threaded and all
+// its randomness comes from a seed, so a run is reproduced exactly by running the same seed again.
+package sim
+
+import (
+	"container/heap"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/gopricy/mao-bft/pb"
+	"github.com/gopricy/mao-bft/rbc/acs"
+	"github.com/gopricy/mao-bft/rbc/common"
+	"github.com/gopricy/mao-bft/rbc/follower"
+	"github.com/gopricy/mao-bft/rbc/leader"
+	"github.com/gopricy/mao-bft/rbc/sign"
+	"github.com/gopricy/mao-bft/rbc/thresholdsign"
+	mao_utils "github.com/gopricy/mao-bft/utils"
+	"github.com/pkg/errors"
+)
+
+// LeaderName is the name of the leader, followers are named f1 to fN-1.
+const LeaderName = "mao"
+
+// maxSteps bounds a run, so that a bug that keeps nodes busy can't hang a test.
+const maxSteps = 1000000
+
+// start is the virtual time a simulation starts at.
+var start = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Config describes a simulated cluster and its network.
+type Config struct {
+	Seed int64
+	// ByzantineLimit is f, the number of byzantine nodes the cluster tolerates.
+	ByzantineLimit int
+	// Nodes is N, the cluster has a leader and N - 1 followers. It's 3f + 1 if it's not set, and must be at least that.
+	Nodes int
+	// Latency of each message is uniformly random between MinLatency and MaxLatency.
+	MinLatency time.Duration
+	MaxLatency time.Duration
+	// Loss is the probability that a message is lost.
+	Loss float64
+	// Reorder lets messages between two nodes overtake each other, links are FIFO otherwise.
+	Reorder bool
+	// ACS runs all nodes in leaderless ACS mode, the leader is then a node like the others.
+	ACS bool
+	// ReplicatedLimit is RBCSetting.ReplicatedLimit of all nodes.
+	ReplicatedLimit int
+}
+
+// Event is a step of a simulation, it's a message handled by To, a lost message, or a timer.
+type Event struct {
+	// At is virtual time since the simulation started.
+	At       time.Duration
+	Kind     string
+	From     string
+	To       string
+	Instance string
+	// Root is the Merkle root the message is about in short hex, or round and value of an agreement message.
+	Root string
+	// Err is returned by To when it handled the message.
+	Err string
+}
+
+// Kinds of events that aren't messages.
+const (
+	KindLost  = "LOST"
+	KindTimer = "TIMER"
+)
+
+func (e Event) String() string {
+	res := fmt.Sprintf("%12s %-12s %4s -> %-4s %s %s", e.At, e.Kind, e.From, e.To, e.Instance, e.Root)
+	if e.Err != "" {
+		res += " error: " + e.Err
+	}
+	return res
+}
+
+// App records data delivered to a node.
+type App struct {
+	Delivered [][]byte
+}
+
+func (a *App) RBCReceive(data []byte) (bool, error) {
+	a.Delivered = append(a.Delivered, data)
+	return false, nil
+}
+
+func (a *App) IsCommitted([]byte) bool { return true }
+
+func (a *App) GetSyncQuestion() (*pb.SyncRequest, error) { return nil, nil }
+
+func (a *App) GetSyncAnswer(*pb.SyncRequest) (*pb.SyncResponse, error) {
+	return nil, errors.New("sync is not simulated")
+}
+
+// Simulation is a cluster of a leader and followers on a simulated network.
+type Simulation struct {
+	config Config
+	now    time.Time
+	queue  eventQueue
+	// seq orders events at the same time in the order they're scheduled.
+	seq       uint64
+	links     map[link]*linkState
+	nodes     map[string]*common.Common
+	crashed   map[string]bool
+	apps      map[string]*App
+	delivered map[string][]common.Delivery
+	trace     []Event
+	// mu guards trace and delivered, so that a simulation can be inspected from other goroutines.
+	mu sync.Mutex
+
+	names []string
+
+	Leader    *leader.Leader
+	Followers []*follower.Follower
+	// ACSNodes are set instead of Leader and Followers in ACS mode.
+	ACSNodes []*acs.Node
+}
+
+type link struct {
+	from, to string
+}
+
+type linkState struct {
+	rand *rand.Rand
+	// last is when the last message on the link arrives, later messages can't arrive earlier on a FIFO link.
+	last time.Time
+}
+
+// N returns the number of nodes of the cluster that config describes.
+func (config Config) N() int {
+	if config.Nodes == 0 {
+		return 3*config.ByzantineLimit + 1
+	}
+	return config.Nodes
+}
+
+// New creates a cluster as config describes, nothing happens until it runs. It panics if the cluster can't tolerate
+// ByzantineLimit faults.
+func New(config Config) *Simulation {
+	s := &Simulation{
+		config:    config,
+		now:       start,
+		links:     make(map[link]*linkState),
+		nodes:     make(map[string]*common.Common),
+		crashed:   make(map[string]bool),
+		apps:      make(map[string]*App),
+		delivered: make(map[string][]common.Delivery),
+	}
+	keys := rand.New(rand.NewSource(config.Seed))
+	rs := common.RBCSetting{
+		AllPeers:        make(map[string]*common.Peer),
+		ByzantineLimit:  config.ByzantineLimit,
+		ClusterID:       fmt.Sprintf("sim-%d", config.Seed),
+		ReplicatedLimit: config.ReplicatedLimit,
+	}
+	names := []string{LeaderName}
+	for i := 1; i < config.N(); i++ {
+		names = append(names, fmt.Sprintf("f%d", i))
+	}
+	privateKeys := make(map[string]sign.PrivateKey)
+	for _, name := range names {
+		pub, priv := sign.GenerateKeyFrom(keys)
+		rs.AllPeers[name] = &common.Peer{Name: name, PubKey: pub}
+		privateKeys[name] = priv
+	}
+	if err := rs.Validate(); err != nil {
+		panic(err)
+	}
+
+	s.names = names
+	if config.ACS {
+		public, shares, err := thresholdsign.DealFrom(keys, len(names), config.ByzantineLimit+1)
+		if err != nil {
+			panic(err)
+		}
+		// Shares of the coin are dealt to peers in order of their names.
+		sorted := append([]string(nil), names...)
+		sort.Strings(sorted)
+		share := make(map[string]*thresholdsign.PrivateKeyShare)
+		for i, name := range sorted {
+			share[name] = shares[i]
+		}
+		for _, name := range names {
+			s.apps[name] = &App{}
+			n := acs.NewNode(name, s.apps[name], rs, privateKeys[name], thresholdsign.NewCoin(public, share[name]))
+			s.attach(&n.Common, rs)
+			s.ACSNodes = append(s.ACSNodes, n)
+		}
+		return s
+	}
+	s.apps[LeaderName] = &App{}
+	s.Leader = leader.NewLeader(LeaderName, s.apps[LeaderName], rs.ByzantineLimit, rs.AllPeers, privateKeys[LeaderName])
+	s.attach(&s.Leader.Common, rs)
+	for _, name := range names[1:] {
+		s.apps[name] = &App{}
+		f := follower.NewFollower(name, s.apps[name], rs.ByzantineLimit, rs.AllPeers, privateKeys[name])
+		s.attach(&f.Common, rs)
+		s.Followers = append(s.Followers, f)
+	}
+	return s
+}
+
+func (s *Simulation) attach(c *common.Common, rs common.RBCSetting) {
+	name := c.Name()
+	c.RBCSetting = rs
+	c.Transport = &endpoint{sim: s, name: name}
+	c.Clock = s
+	c.OnDeliver(func(d common.Delivery) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.delivered[name] = append(s.delivered[name], d)
+	})
+	s.nodes[name] = c
+}
+
+// Names returns names of all nodes, the leader first.
+func (s *Simulation) Names() []string {
+	return append([]string(nil), s.names...)
+}
+
+// Node returns the node called name.
+func (s *Simulation) Node(name string) *common.Common {
+	return s.nodes[name]
+}
+
+// App returns the application of the node called name.
+func (s *Simulation) App(name string) *App {
+	return s.apps[name]
+}
+
+// Deliveries returns what the node called name has delivered in order.
+func (s *Simulation) Deliveries(name string) []common.Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]common.Delivery(nil), s.delivered[name]...)
+}
+
+// Crash stops the node called name, messages to and from it are lost from now on.
+func (s *Simulation) Crash(name string) {
+	s.crashed[name] = true
+}
+
+// Trace returns all events that have happened.
+func (s *Simulation) Trace() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Event(nil), s.trace...)
+}
+
+// Now returns virtual time, it implements common.Clock.
+func (s *Simulation) Now() time.Time {
+	return s.now
+}
+
+// AfterFunc schedules f after d in virtual time, it implements common.Clock.
+func (s *Simulation) AfterFunc(d time.Duration, f func()) {
+	s.schedule(&event{at: s.now.Add(d), run: f, record: Event{Kind: KindTimer}})
+}
+
+// Elapsed returns virtual time since the simulation started.
+func (s *Simulation) Elapsed() time.Duration {
+	return s.now.Sub(start)
+}
+
+// Broadcast makes the leader broadcast block after d.
+func (s *Simulation) Broadcast(d time.Duration, block *pb.Block) {
+	bytes, err := mao_utils.EncodeBlock(block)
+	if err != nil {
+		panic(err)
+	}
+	s.AfterFunc(d, func() { s.Leader.RBCSend(bytes) })
+}
+
+// Propose makes the node called name propose batch after d in ACS mode.
+func (s *Simulation) Propose(d time.Duration, name string, batch []byte) {
+	for _, n := range s.ACSNodes {
+		if n.Name() == name {
+			s.AfterFunc(d, func() { n.Propose(batch) })
+			return
+		}
+	}
+	panic(name + " is not an ACS node")
+}
+
+// Block returns a block on parent that holds a deposit, id makes blocks on the same parent different.
+func Block(parent []byte, id string) *pb.Block {
+	txn := &pb.Transaction{
+		TransactionUuid: id,
+		Message:         &pb.Transaction_DepositMsg{DepositMsg: &pb.DepositMessage{AccountId: id, Amount: 1}},
+	}
+	block, err := mao_utils.CreateBlockFromTxsAndPrevHash([]*pb.Transaction{txn}, parent)
+	if err != nil {
+		panic(err)
+	}
+	return block
+}
+
+// Chain broadcasts n blocks on top of each other, one every interval.
+func (s *Simulation) Chain(n int, interval time.Duration) []*pb.Block {
+	var blocks []*pb.Block
+	parent := []byte{0}
+	for i := 0; i < n; i++ {
+		block := Block(parent, string(rune('a'+i)))
+		s.Broadcast(time.Duration(i)*interval, block)
+		blocks = append(blocks, block)
+		parent = block.CurHash
+	}
+	return blocks
+}
+
+// Step runs the next event, it returns false if there's none.
+func (s *Simulation) Step() bool {
+	if s.queue.Len() == 0 {
+		return false
+	}
+	e := heap.Pop(&s.queue).(*event)
+	s.now = e.at
+	record := e.record
+	record.At = s.Elapsed()
+	if e.run == nil && s.crashed[record.To] {
+		record.Kind = KindLost
+	}
+	// The event is traced before it runs, so that it comes before the events it causes.
+	s.mu.Lock()
+	index := len(s.trace)
+	s.trace = append(s.trace, record)
+	s.mu.Unlock()
+	if e.run != nil {
+		e.run()
+	} else if record.Kind != KindLost {
+		if err := s.handle(e); err != nil {
+			s.mu.Lock()
+			s.trace[index].Err = err.Error()
+			s.mu.Unlock()
+		}
+	}
+	return true
+}
+
+// Run runs events until there's none left, or until virtual time passes limit since the simulation started. A zero
+// limit runs until there's no event. It returns the number of events run.
+func (s *Simulation) Run(limit time.Duration) int {
+	steps := 0
+	for ; steps < maxSteps && s.queue.Len() > 0; steps++ {
+		if limit > 0 && s.queue[0].at.Sub(start) > limit {
+			break
+		}
+		s.Step()
+	}
+	return steps
+}
+
+// event is a message to deliver or a function to run at a virtual time.
+type event struct {
+	at  time.Time
+	seq uint64
+	// run is set for timers.
+	run  func()
+	body proto.Message
+	// record is added to the trace when the event runs.
+	record Event
+}
+
+func (s *Simulation) schedule(e *event) {
+	s.seq++
+	e.seq = s.seq
+	heap.Push(&s.queue, e)
+}
+
+// send schedules body from from to to, unless it's lost.
+func (s *Simulation) send(from, to, kind string, body proto.Message) {
+	record := Event{Kind: kind, From: from, To: to}
+	record.Instance, record.Root = describe(body)
+	l := s.link(from, to)
+	if s.crashed[from] || l.rand.Float64() < s.config.Loss {
+		record.Kind, record.At = KindLost, s.Elapsed()
+		s.mu.Lock()
+		s.trace = append(s.trace, record)
+		s.mu.Unlock()
+		return
+	}
+	latency := s.config.MinLatency
+	if s.config.MaxLatency > s.config.MinLatency {
+		latency += time.Duration(l.rand.Int63n(int64(s.config.MaxLatency - s.config.MinLatency + 1)))
+	}
+	at := s.now.Add(latency)
+	if !s.config.Reorder {
+		if at.Before(l.last) {
+			at = l.last
+		}
+		l.last = at
+	}
+	// Nodes never share messages, like they don't over gRPC.
+	s.schedule(&event{at: at, body: proto.Clone(body), record: record})
+}
+
+// link returns state of the link from from to to, its randomness only depends on the seed and the link.
+func (s *Simulation) link(from, to string) *linkState {
+	l := link{from: from, to: to}
+	if state, ok := s.links[l]; ok {
+		return state
+	}
+	h := fnv.New64a()
+	h.Write([]byte(from + "->" + to))
+	state := &linkState{rand: rand.New(rand.NewSource(s.config.Seed ^ int64(h.Sum64())))}
+	s.links[l] = state
+	return state
+}
+
+// handle hands the message of e to its receiver.
+func (s *Simulation) handle(e *event) error {
+	node := s.nodes[e.record.To]
+	ctx := common.AuthenticatedContext(context.Background(), e.record.From)
+	var err error
+	switch body := e.body.(type) {
+	case *pb.Payload:
+		if e.record.Kind == common.KindPrepare {
+			_, err = node.Prepare(ctx, body)
+		} else {
+			_, err = node.Echo(ctx, body)
+		}
+	case *pb.ReadyRequest:
+		_, err = node.Ready(ctx, body)
+	case *pb.ViewChangeRequest:
+		_, err = node.ViewChange(ctx, body)
+	case *pb.ProposeTransactionRequest:
+		_, err = node.ProposeTransaction(ctx, body)
+	case *pb.EquivocationEvidence:
+		_, err = node.ReportEquivocation(ctx, body)
+	case *pb.AgreementMessage:
+		_, err = node.Agreement(ctx, body)
+	default:
+		err = errors.Errorf("can't handle %T", body)
+	}
+	return err
+}
+
+// describe returns instance and root of a RBC message.
+func describe(body proto.Message) (string, string) {
+	var instance *pb.InstanceId
+	var root []byte
+	switch body := body.(type) {
+	case *pb.Payload:
+		instance, root = body.Instance, common.PayloadRoot(body)
+	case *pb.ReadyRequest:
+		instance, root = body.Instance, body.MerkleRoot
+	case *pb.AgreementMessage:
+		res := ""
+		if i, err := common.InstanceFromPb(body.Instance); err == nil {
+			res = i.String()
+		}
+		return res, fmt.Sprintf("r%d=%t", body.Round, body.Value)
+	default:
+		return "", ""
+	}
+	res := ""
+	if i, err := common.InstanceFromPb(instance); err == nil {
+		res = i.String()
+	}
+	return res, fmt.Sprintf("%.8s", hex.EncodeToString(root))
+}
+
+// eventQueue orders events by time, and events at the same time by when they're scheduled.
+type eventQueue []*event
+
+func (q eventQueue) Len() int { return len(q) }
+
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].at.Equal(q[j].at) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].at.Before(q[j].at)
+}
+
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *eventQueue) Push(x interface{}) { *q = append(*q, x.(*event)) }
+
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+// endpoint is the transport of node name on the simulated network.
+type endpoint struct {
+	sim  *Simulation
+	name string
+}
+
+var _ common.QueuedTransport = &endpoint{}
+
+func (e *endpoint) Queued() {}
+
+func (e *endpoint) Prepare(ctx context.Context, p *common.Peer, req *pb.Payload) error {
+	e.sim.send(e.name, p.Name, common.KindPrepare, req)
+	return nil
+}
+
+func (e *endpoint) Echo(ctx context.Context, p *common.Peer, req *pb.Payload) error {
+	e.sim.send(e.name, p.Name, common.KindEcho, req)
+	return nil
+}
+
+func (e *endpoint) Ready(ctx context.Context, p *common.Peer, req *pb.ReadyRequest) error {
+	e.sim.send(e.name, p.Name, common.KindReady, req)
+	return nil
+}
+
+func (e *endpoint) ViewChange(ctx context.Context, p *common.Peer, req *pb.ViewChangeRequest) error {
+	e.sim.send(e.name, p.Name, common.KindViewChange, req)
+	return nil
+}
+
+func (e *endpoint) ProposeTransaction(ctx context.Context, p *common.Peer, req *pb.ProposeTransactionRequest) error {
+	e.sim.send(e.name, p.Name, common.KindTransaction, req)
+	return nil
+}
+
+func (e *endpoint) ReportEquivocation(ctx context.Context, p *common.Peer, req *pb.EquivocationEvidence) error {
+	e.sim.send(e.name, p.Name, common.KindEquivocation, req)
+	return nil
+}
+
+func (e *endpoint) Agreement(ctx context.Context, p *common.Peer, req *pb.AgreementMessage) error {
+	e.sim.send(e.name, p.Name, common.AgreementKind(req.Type), req)
+	return nil
+}
+
+// Sync isn't simulated, App of simulated nodes never asks for it.
+func (e *endpoint) Sync(ctx context.Context, p *common.Peer, req *pb.SyncRequest) (*pb.SyncResponse, error) {
+	return nil, errors.New("sync is not simulated")
+}
@@ -0,0 +1,261 @@
+package sim
+
+import (
+	"flag"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gopricy/mao-bft/rbc/common"
+	"github.com/gopricy/mao-bft/rbc/merkle"
+	mao_utils "github.com/gopricy/mao-bft/utils"
+	"github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+// seed replays a simulation, e.g. go test ./rbc/sim -run TestSimulation_Replay -sim.seed 42 -v
+var seed = flag.Int64("sim.seed", 0, "seed of the simulation to replay")
+
+func init() {
+	logging.SetLevel(logging.WARNING, "RBC")
+}
+
+// lossy is a network that loses and reorders messages.
+func lossy(seed int64) Config {
+	return Config{
+		Seed:           seed,
+		ByzantineLimit: 1,
+		MinLatency:     time.Millisecond,
+		MaxLatency:     100 * time.Millisecond,
+		Loss:           0.05,
+		Reorder:        true,
+	}
+}
+
+func TestSimulation_AllNodesDeliver(t *testing.T) {
+	s := New(Config{Seed: 1, ByzantineLimit: 1, MinLatency: time.Millisecond, MaxLatency: 50 * time.Millisecond})
+	s.Chain(3, 10*time.Millisecond)
+	s.Run(0)
+
+	for _, name := range s.Names() {
+		deliveries := s.Deliveries(name)
+		assert.Equal(t, 3, len(deliveries), name)
+		assert.Equal(t, s.Deliveries(LeaderName), deliveries, name)
+	}
+	for _, e := range s.Trace() {
+		assert.Empty(t, e.Err, e.String())
+	}
+}
+
+func TestSimulation_ReplicatedMode(t *testing.T) {
+	config := lossy(1)
+	config.Loss = 0
+	config.ReplicatedLimit = 200
+	s := New(config)
+	// The first block is replicated, the second one is erasure coded.
+	small := Block([]byte{0}, "a")
+	large := Block(small.CurHash, strings.Repeat("b", 1000))
+	s.Broadcast(0, small)
+	s.Broadcast(10*time.Millisecond, large)
+	s.Run(0)
+
+	// Both modes deliver the same way, under the root they vote on.
+	replicated, err := mao_utils.EncodeBlock(small)
+	assert.Nil(t, err)
+	coded, err := mao_utils.EncodeBlock(large)
+	assert.Nil(t, err)
+	root, err := common.DataRoot(coded, 1, 4)
+	assert.Nil(t, err)
+	roots := map[string]merkle.RootString{
+		string(replicated): merkle.MerkleRootToString(common.ValueHash(replicated)),
+		string(coded):      merkle.MerkleRootToString(root),
+	}
+	for _, name := range s.Names() {
+		deliveries := s.Deliveries(name)
+		assert.Equal(t, 2, len(deliveries), name)
+		for _, d := range deliveries {
+			assert.Equal(t, roots[string(d.Data)], d.Root, name)
+		}
+	}
+	for _, e := range s.Trace() {
+		assert.Empty(t, e.Err, e.String())
+	}
+}
+
+func TestSimulation_Reproducible(t *testing.T) {
+	run := func(seed int64) []Event {
+		s := New(lossy(seed))
+		s.Chain(5, 20*time.Millisecond)
+		s.Run(0)
+		return s.Trace()
+	}
+	first := run(7)
+	assert.Equal(t, first, run(7))
+	assert.NotEqual(t, first, run(8))
+}
+
+func TestSimulation_VirtualTime(t *testing.T) {
+	s := New(Config{Seed: 1, ByzantineLimit: 1, MinLatency: time.Minute, MaxLatency: time.Hour})
+	s.Chain(2, 24*time.Hour)
+	began := time.Now()
+	// Only the first broadcast happens before the limit.
+	s.Run(12 * time.Hour)
+	assert.Equal(t, 1, len(s.Deliveries("f1")))
+	s.Run(0)
+	assert.Equal(t, 2, len(s.Deliveries("f1")))
+	assert.True(t, s.Elapsed() > 24*time.Hour)
+	assert.True(t, time.Since(began) < time.Minute)
+}
+
+func TestSimulation_Crash(t *testing.T) {
+	s := New(Config{Seed: 3, ByzantineLimit: 1, MaxLatency: 10 * time.Millisecond})
+	s.Crash("f3")
+	s.Chain(2, time.Millisecond)
+	s.Run(0)
+	for _, name := range []string{LeaderName, "f1", "f2"} {
+		assert.Equal(t, 2, len(s.Deliveries(name)), name)
+	}
+	assert.Empty(t, s.Deliveries("f3"))
+}
+
+func TestSimulation_Replay(t *testing.T) {
+	if *seed == 0 {
+		t.Skip("no seed to replay")
+	}
+	s := New(lossy(*seed))
+	s.Chain(5, 20*time.Millisecond)
+	s.Run(0)
+	for _, e := range s.Trace() {
+		t.Log(e)
+	}
+}
+
+// executions is how many random scenarios TestChecker_RandomScenarios checks.
+var executions = flag.Int("sim.executions", 200, "number of random scenarios to check")
+
+func TestChecker_RandomScenarios(t *testing.T) {
+	n := *executions
+	if testing.Short() {
+		n /= 10
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < n; i++ {
+		sc := RandomScenario(r)
+		violations := sc.Check()
+		if len(violations) == 0 {
+			continue
+		}
+		minimal := Shrink(sc, func(sc Scenario) bool { return len(sc.Check()) != 0 })
+		s, _ := minimal.Run()
+		for _, e := range s.Trace() {
+			t.Log(e)
+		}
+		t.Fatalf("%s breaks %v\nminimal: %s breaks %v", sc, violations, minimal, minimal.Check())
+	}
+}
+
+func TestChecker_Check(t *testing.T) {
+	sc := Scenario{Config: Config{Seed: 1, ByzantineLimit: 1}, Blocks: 2}
+	s, broadcast := sc.Run()
+	delivered := make(map[string][]common.Delivery)
+	for _, name := range s.Names() {
+		delivered[name] = s.Deliveries(name)
+	}
+	assert.Empty(t, sc.check(delivered, broadcast))
+
+	// Nodes that deliver twice, deliver other data, or miss a delivery are caught.
+	tampered := make(map[string][]common.Delivery)
+	for name, d := range delivered {
+		tampered[name] = d
+	}
+	tampered["f1"] = append(tampered["f1"], tampered["f1"][0])
+	other := tampered["f2"][1]
+	other.Data = []byte("forged")
+	tampered["f2"] = []common.Delivery{tampered["f2"][0], other}
+	tampered["f3"] = tampered["f3"][:1]
+	var properties []string
+	for _, v := range sc.check(tampered, broadcast) {
+		properties = append(properties, v.Property)
+	}
+	sort.Strings(properties)
+	assert.Equal(t, []string{"agreement", "integrity", "integrity", "totality", "validity", "validity"}, properties)
+
+	// Faulty nodes are never checked.
+	sc.Faulty = map[string]Fault{"f1": {Strategy: Crash}, "f2": {Strategy: "mutate"}, "f3": {Strategy: "drop"}}
+	assert.Empty(t, sc.check(tampered, broadcast))
+}
+
+func TestChecker_Fork(t *testing.T) {
+	for _, strategy := range []string{"fork", "fork-epoch"} {
+		for _, limit := range []int{0, 1 << 20} {
+			config := lossy(5)
+			config.Loss, config.ReplicatedLimit = 0, limit
+			sc := Scenario{Config: config, Blocks: 3, Interval: 20 * time.Millisecond,
+				Faulty: map[string]Fault{LeaderName: {Strategy: strategy}}}
+			assert.Empty(t, sc.Check(), sc.String())
+		}
+	}
+
+	// Blocks delivered on the same parent in different instances are caught.
+	sc := Scenario{Config: Config{Seed: 1, ByzantineLimit: 1}, Blocks: 1}
+	s, broadcast := sc.Run()
+	delivered := make(map[string][]common.Delivery)
+	for _, name := range s.Names() {
+		delivered[name] = s.Deliveries(name)
+	}
+	fork, err := mao_utils.EncodeBlock(Block([]byte{0}, "fork"))
+	assert.Nil(t, err)
+	forked := delivered["f1"][0]
+	forked.Instance.Sequence++
+	forked.Data = fork
+	delivered["f1"] = append(delivered["f1"], forked)
+	var properties []string
+	for _, v := range sc.check(delivered, broadcast) {
+		properties = append(properties, v.Property)
+	}
+	assert.Contains(t, properties, "fork")
+}
+
+func TestChecker_ClusterSizes(t *testing.T) {
+	strategies := []string{"equivocate", "mutate", Crash}
+	for _, c := range []struct{ n, f int }{{5, 1}, {7, 2}, {10, 3}} {
+		config := lossy(int64(c.n))
+		config.Nodes, config.ByzantineLimit, config.Loss = c.n, c.f, 0
+		// f followers are faulty, so totality and validity are checked with the fewest correct nodes.
+		sc := Scenario{Config: config, Blocks: 3, Interval: 20 * time.Millisecond, Faulty: make(map[string]Fault)}
+		names := New(config).Names()
+		for i := 0; i < c.f; i++ {
+			sc.Faulty[names[c.n-1-i]] = Fault{Strategy: strategies[i]}
+		}
+		assert.Empty(t, sc.Check(), sc.String())
+	}
+}
+
+func TestNew_InvalidClusterSize(t *testing.T) {
+	assert.Equal(t, 7, Config{ByzantineLimit: 2}.N())
+	assert.Panics(t, func() { New(Config{ByzantineLimit: 2, Nodes: 6}) })
+	assert.NotPanics(t, func() { New(Config{ByzantineLimit: 2, Nodes: 8}) })
+}
+
+func TestShrink(t *testing.T) {
+	sc := Scenario{
+		Config: Config{Seed: 1, ByzantineLimit: 2, MaxLatency: time.Second, Loss: 0.1, Reorder: true},
+		Blocks: 4,
+		Faulty: map[string]Fault{"f1": {Strategy: "drop"}, "f2": {Strategy: "mutate"}},
+	}
+	// Scenario fails as long as f2 mutates with 2 blocks.
+	fails := func(sc Scenario) bool {
+		_, ok := sc.Faulty["f2"]
+		return ok && sc.Blocks >= 2
+	}
+	minimal := Shrink(sc, fails)
+	assert.Equal(t, Scenario{
+		Config: Config{Seed: 1, ByzantineLimit: 2},
+		Blocks: 2,
+		Faulty: map[string]Fault{"f2": {Strategy: "mutate"}},
+	}, minimal)
+	// sc is untouched.
+	assert.Equal(t, 2, len(sc.Faulty))
+}
@@ -0,0 +1,104 @@
+package thresholdsign
+
+import (
+	"crypto/sha256"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Coin is a common coin, the coin of a name is a bit of the hash of the threshold signature over name. Nobody knows
+// it before Threshold parties share their signature shares, so with a threshold of f+1 it's unpredictable until a
+// correct party tosses it, and every party that tosses it gets the same value.
+type Coin struct {
+	public *PublicKey
+	share  *PrivateKeyShare
+	tosses map[string]*toss
+	mu     sync.Mutex
+}
+
+// toss is the state of the coin of a name.
+type toss struct {
+	// shares maps index of each party to its verified share.
+	shares map[int][]byte
+	known  bool
+	value  bool
+}
+
+// NewCoin returns the coin of the party that holds share of public.
+func NewCoin(public *PublicKey, share *PrivateKeyShare) *Coin {
+	return &Coin{public: public, share: share, tosses: make(map[string]*toss)}
+}
+
+func (c *Coin) getToss(name []byte) *toss {
+	t, ok := c.tosses[string(name)]
+	if !ok {
+		t = &toss{shares: make(map[int][]byte)}
+		c.tosses[string(name)] = t
+	}
+	return t
+}
+
+// Share returns the share of this party of the coin of name, it's sent to other parties.
+func (c *Coin) Share(name []byte) []byte {
+	share := Sign(c.share, name)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.add(c.getToss(name), c.share.Index, share)
+	return share
+}
+
+// AddShare adds the share of party index of the coin of name, it returns error if the share is invalid. Shares of a
+// party that's already shared are ignored.
+func (c *Coin) AddShare(index int, name []byte, share []byte) error {
+	c.mu.Lock()
+	t := c.getToss(name)
+	_, ok := t.shares[index]
+	known := t.known
+	c.mu.Unlock()
+	if ok || known {
+		return nil
+	}
+	// Pairings are slow, so shares aren't verified with the lock held.
+	if !VerifyShare(c.public, index, name, share) {
+		return errors.Errorf("invalid coin share of party %d", index)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.add(t, index, share)
+	return nil
+}
+
+func (c *Coin) add(t *toss, index int, share []byte) {
+	if t.known {
+		return
+	}
+	t.shares[index] = share
+	if len(t.shares) < c.public.Threshold {
+		return
+	}
+	signature, err := Combine(c.public, t.shares)
+	if err != nil {
+		return
+	}
+	t.known, t.value = true, sha256.Sum256(signature)[0]&1 == 1
+	t.shares = nil
+}
+
+// Toss returns the coin of name, ok is false until Threshold parties shared it.
+func (c *Coin) Toss(name []byte) (value bool, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.tosses[string(name)]
+	if !ok || !t.known {
+		return false, false
+	}
+	return t.value, true
+}
+
+// Forget drops the state of the coin of name, once it's no longer tossed.
+func (c *Coin) Forget(name []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.tosses, string(name))
+}
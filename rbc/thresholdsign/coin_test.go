@@ -0,0 +1,64 @@
+package thresholdsign_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gopricy/mao-bft/rbc/thresholdsign"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoin(t *testing.T) {
+	public, shares := deal(t, 4, 2)
+	var coins []*thresholdsign.Coin
+	for _, share := range shares {
+		coins = append(coins, thresholdsign.NewCoin(public, share))
+	}
+	heads := 0
+	for round := 0; round < 20; round++ {
+		name := []byte(fmt.Sprintf("round %d", round))
+		// A party's own share isn't enough.
+		share := coins[0].Share(name)
+		_, ok := coins[0].Toss(name)
+		assert.False(t, ok)
+		assert.Nil(t, coins[0].AddShare(0, name, share))
+		_, ok = coins[0].Toss(name)
+		assert.False(t, ok)
+
+		// Shares of different parties toss the same coin.
+		assert.Nil(t, coins[1].AddShare(0, name, share))
+		assert.Nil(t, coins[0].AddShare(1, name, coins[1].Share(name)))
+		assert.Nil(t, coins[2].AddShare(3, name, coins[3].Share(name)))
+		coins[2].Share(name)
+		value, ok := coins[0].Toss(name)
+		assert.True(t, ok)
+		for _, c := range coins[1:3] {
+			other, ok := c.Toss(name)
+			assert.True(t, ok)
+			assert.Equal(t, value, other)
+		}
+		if value {
+			heads++
+		}
+	}
+	assert.True(t, heads > 0 && heads < 20, "%d heads", heads)
+}
+
+func TestCoin_InvalidShare(t *testing.T) {
+	public, shares := deal(t, 4, 2)
+	coin := thresholdsign.NewCoin(public, shares[0])
+	name := []byte("round 0")
+	share := thresholdsign.Sign(shares[1], name)
+	assert.Error(t, coin.AddShare(2, name, share))
+	assert.Error(t, coin.AddShare(1, []byte("round 1"), share))
+	coin.Share(name)
+	_, ok := coin.Toss(name)
+	assert.False(t, ok)
+
+	assert.Nil(t, coin.AddShare(1, name, share))
+	_, ok = coin.Toss(name)
+	assert.True(t, ok)
+	coin.Forget(name)
+	_, ok = coin.Toss(name)
+	assert.False(t, ok)
+}
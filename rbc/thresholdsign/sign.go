@@ -1 +1,166 @@
+// Package thresholdsign is threshold BLS signatures on the bn256 curve. A dealer splits a private key into N shares
+// with Shamir's secret sharing, each party signs with its share, and any Threshold valid signature shares of a message
+// combine into the signature of the whole key, which is the same whichever shares are combined.
 package thresholdsign
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"math/big"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bn256"
+)
+
+// p is the prime of the field that bn256 G1 is defined over, G1 is y² = x³ + 3.
+var p, _ = new(big.Int).SetString("65000549695646603732796438742359905742825358107623003571877145026864184071783", 10)
+
+// PublicKey verifies signatures and signature shares.
+type PublicKey struct {
+	// Threshold is the number of signature shares that make a signature.
+	Threshold int
+	// Key verifies combined signatures.
+	Key *bn256.G2
+	// Shares verifies signature shares, Shares[i] is the key of party i.
+	Shares []*bn256.G2
+}
+
+// PrivateKeyShare is the share of party Index of a private key.
+type PrivateKeyShare struct {
+	Index int
+	Key   *big.Int
+}
+
+// Deal splits a new private key into n shares, threshold of which make a signature.
+func Deal(n, threshold int) (*PublicKey, []*PrivateKeyShare, error) {
+	return DealFrom(rand.Reader, n, threshold)
+}
+
+// DealFrom is Deal with randomness from rand, the same rand gives the same keys.
+func DealFrom(rand io.Reader, n, threshold int) (*PublicKey, []*PrivateKeyShare, error) {
+	if threshold < 1 || threshold > n {
+		return nil, nil, errors.Errorf("threshold %d is out of range of %d parties", threshold, n)
+	}
+	// The private key is the constant of a random polynomial of degree threshold-1, party i gets it at i+1.
+	coefficients := make([]*big.Int, threshold)
+	for i := range coefficients {
+		k, _, err := bn256.RandomG1(rand)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to read randomness")
+		}
+		coefficients[i] = k
+	}
+	public := &PublicKey{Threshold: threshold, Key: new(bn256.G2).ScalarBaseMult(coefficients[0])}
+	var shares []*PrivateKeyShare
+	for i := 0; i < n; i++ {
+		x := big.NewInt(int64(i + 1))
+		key := new(big.Int)
+		for j := threshold - 1; j >= 0; j-- {
+			key.Mul(key, x)
+			key.Add(key, coefficients[j])
+			key.Mod(key, bn256.Order)
+		}
+		shares = append(shares, &PrivateKeyShare{Index: i, Key: key})
+		public.Shares = append(public.Shares, new(bn256.G2).ScalarBaseMult(key))
+	}
+	return public, shares, nil
+}
+
+// hash returns the point of G1 that message is signed as. It hashes message with a counter until the hash is x of a
+// point on the curve, so that nobody knows its discrete logarithm.
+func hash(message []byte) *bn256.G1 {
+	// (p+1)/4 is the exponent of square roots, since p = 3 mod 4.
+	exponent := new(big.Int).Rsh(new(big.Int).Add(p, big.NewInt(1)), 2)
+	for counter := uint32(0); ; counter++ {
+		h := sha256.New()
+		binary.Write(h, binary.BigEndian, counter)
+		h.Write(message)
+		x := new(big.Int).SetBytes(h.Sum(nil))
+		x.Mod(x, p)
+		rhs := new(big.Int).Exp(x, big.NewInt(3), p)
+		rhs.Add(rhs, big.NewInt(3))
+		rhs.Mod(rhs, p)
+		y := new(big.Int).Exp(rhs, exponent, p)
+		if new(big.Int).Exp(y, big.NewInt(2), p).Cmp(rhs) != 0 {
+			continue
+		}
+		point := make([]byte, 64)
+		xBytes, yBytes := x.Bytes(), y.Bytes()
+		copy(point[32-len(xBytes):32], xBytes)
+		copy(point[64-len(yBytes):], yBytes)
+		if res, ok := new(bn256.G1).Unmarshal(point); ok {
+			return res
+		}
+	}
+}
+
+// Sign returns the signature share of share over message.
+func Sign(share *PrivateKeyShare, message []byte) []byte {
+	return new(bn256.G1).ScalarMult(hash(message), share.Key).Marshal()
+}
+
+// verify checks that signature is signed over message by the private key of key.
+func verify(key *bn256.G2, message []byte, signature []byte) bool {
+	s, ok := new(bn256.G1).Unmarshal(signature)
+	if !ok {
+		return false
+	}
+	// e(H(m)^k, g2) = e(H(m), g2^k)
+	left := bn256.Pair(s, new(bn256.G2).ScalarBaseMult(big.NewInt(1))).Marshal()
+	right := bn256.Pair(hash(message), key).Marshal()
+	return string(left) == string(right)
+}
+
+// VerifyShare checks that share is the signature share of party index over message.
+func VerifyShare(public *PublicKey, index int, message []byte, share []byte) bool {
+	if index < 0 || index >= len(public.Shares) {
+		return false
+	}
+	return verify(public.Shares[index], message, share)
+}
+
+// Combine returns the signature that shares of parties make, shares maps index of each party to its share, which must
+// be verified by VerifyShare. Any Threshold of them are combined.
+func Combine(public *PublicKey, shares map[int][]byte) ([]byte, error) {
+	if len(shares) < public.Threshold {
+		return nil, errors.Errorf("%d shares can't make a signature of threshold %d", len(shares), public.Threshold)
+	}
+	var indices []int
+	for i := range shares {
+		if i < 0 || i >= len(public.Shares) {
+			return nil, errors.Errorf("party %d doesn't exist", i)
+		}
+		indices = append(indices, i)
+		if len(indices) == public.Threshold {
+			break
+		}
+	}
+	res := new(bn256.G1).ScalarBaseMult(big.NewInt(0))
+	for _, i := range indices {
+		s, ok := new(bn256.G1).Unmarshal(shares[i])
+		if !ok {
+			return nil, errors.Errorf("share of party %d is malformed", i)
+		}
+		// Lagrange coefficient of party i at 0.
+		numerator, denominator := big.NewInt(1), big.NewInt(1)
+		for _, j := range indices {
+			if j == i {
+				continue
+			}
+			numerator.Mul(numerator, big.NewInt(int64(j+1)))
+			denominator.Mul(denominator, big.NewInt(int64(j-i)))
+		}
+		denominator.Mod(denominator, bn256.Order)
+		coefficient := numerator.Mul(numerator, denominator.ModInverse(denominator, bn256.Order))
+		coefficient.Mod(coefficient, bn256.Order)
+		res.Add(res, new(bn256.G1).ScalarMult(s, coefficient))
+	}
+	return res.Marshal(), nil
+}
+
+// Verify checks that signature is the combined signature of public over message.
+func Verify(public *PublicKey, message []byte, signature []byte) bool {
+	return verify(public.Key, message, signature)
+}
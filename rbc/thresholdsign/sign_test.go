@@ -0,0 +1,70 @@
+package thresholdsign_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gopricy/mao-bft/rbc/thresholdsign"
+	"github.com/stretchr/testify/assert"
+)
+
+func deal(t *testing.T, n, threshold int) (*thresholdsign.PublicKey, []*thresholdsign.PrivateKeyShare) {
+	public, shares, err := thresholdsign.DealFrom(rand.New(rand.NewSource(1)), n, threshold)
+	assert.Nil(t, err)
+	assert.Len(t, shares, n)
+	return public, shares
+}
+
+func TestDeal(t *testing.T) {
+	_, _, err := thresholdsign.Deal(4, 0)
+	assert.Error(t, err)
+	_, _, err = thresholdsign.Deal(4, 5)
+	assert.Error(t, err)
+	_, _, err = thresholdsign.Deal(4, 4)
+	assert.Nil(t, err)
+}
+
+func TestCombine(t *testing.T) {
+	message := []byte("block")
+	// f+1 and 2f+1 of N=4, f=1 and N=7, f=2.
+	for _, c := range []struct{ n, threshold int }{{4, 2}, {4, 3}, {7, 3}, {7, 5}} {
+		public, shares := deal(t, c.n, c.threshold)
+		var signatures [][]byte
+		for i, share := range shares {
+			signatures = append(signatures, thresholdsign.Sign(share, message))
+			assert.True(t, thresholdsign.VerifyShare(public, i, message, signatures[i]))
+			assert.False(t, thresholdsign.VerifyShare(public, (i+1)%c.n, message, signatures[i]))
+			assert.False(t, thresholdsign.VerifyShare(public, i, []byte("other"), signatures[i]))
+		}
+
+		// Any threshold shares make the same signature.
+		first := map[int][]byte{}
+		last := map[int][]byte{}
+		for i := 0; i < c.threshold; i++ {
+			first[i] = signatures[i]
+			last[c.n-1-i] = signatures[c.n-1-i]
+		}
+		signature, err := thresholdsign.Combine(public, first)
+		assert.Nil(t, err)
+		assert.True(t, thresholdsign.Verify(public, message, signature))
+		assert.False(t, thresholdsign.Verify(public, []byte("other"), signature))
+		other, err := thresholdsign.Combine(public, last)
+		assert.Nil(t, err)
+		assert.Equal(t, signature, other)
+
+		// Fewer shares make nothing.
+		delete(first, 0)
+		_, err = thresholdsign.Combine(public, first)
+		assert.Error(t, err)
+	}
+}
+
+func TestCombine_Malformed(t *testing.T) {
+	public, shares := deal(t, 4, 2)
+	share := thresholdsign.Sign(shares[0], []byte("block"))
+	_, err := thresholdsign.Combine(public, map[int][]byte{0: share, 7: share})
+	assert.Error(t, err)
+	_, err = thresholdsign.Combine(public, map[int][]byte{0: share, 1: []byte("share")})
+	assert.Error(t, err)
+	assert.False(t, thresholdsign.VerifyShare(public, 0, []byte("block"), []byte("share")))
+}